@@ -55,6 +55,14 @@ function generateServiceMeshMembersCrd() {
   yq -s -y --indentless '.[] | select(.kind=="CustomResourceDefinition" and .metadata.name=="servicemeshmembers.maistra.io") | .' ${DEPLOYMENT_FILE} > ${BUNDLE_DIR}/servicemeshmembers.crd.yaml
 }
 
+function generateServiceMeshControlPlaneTemplatesCrd() {
+  yq -s -y --indentless '.[] | select(.kind=="CustomResourceDefinition" and .metadata.name=="servicemeshcontrolplanetemplates.maistra.io") | .' ${DEPLOYMENT_FILE} > ${BUNDLE_DIR}/servicemeshcontrolplanetemplates.crd.yaml
+}
+
+function generateServiceMeshUpgradeChecksCrd() {
+  yq -s -y --indentless '.[] | select(.kind=="CustomResourceDefinition" and .metadata.name=="servicemeshupgradechecks.maistra.io") | .' ${DEPLOYMENT_FILE} > ${BUNDLE_DIR}/servicemeshupgradechecks.crd.yaml
+}
+
 function generateCSV() {
   IMAGE_SRC=$(yq -s -r '.[] | select(.kind=="Deployment" and .metadata.name=="istio-operator") | .spec.template.spec.containers[0].image' ${DEPLOYMENT_FILE})
   if [ "$IMAGE_SRC" == "" ]; then
@@ -113,6 +121,8 @@ checkDependencies
 generateServiceMeshControlPlanesCrd
 generateServiceMeshMemberRollsCrd
 generateServiceMeshMembersCrd
+generateServiceMeshControlPlaneTemplatesCrd
+generateServiceMeshUpgradeChecksCrd
 generateCSV
 generatePackage
 
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/pflag"
+
+	"github.com/maistra/istio-operator/pkg/apis/maistra"
+	"github.com/maistra/istio-operator/pkg/apis/maistra/openapi"
+)
+
+const (
+	controlPlaneCRDName = "servicemeshcontrolplanes.maistra.io"
+
+	// the service of the conversion webhook in the default deployment.  The
+	// operator points the webhook at the namespace it is installed into and
+	// adds the CA bundle of the webhook server.
+	conversionServiceName      = "admission-controller"
+	conversionServiceNamespace = "istio-operator"
+	conversionServicePath      = "/convert-smcp"
+)
+
+// generatedFields lists the fields of the CRD's spec that are generated
+var generatedFields = []string{"validation", "versions", "preserveUnknownFields", "conversion"}
+
+// crd-schema-gen generates the OpenAPI schema of the ServiceMeshControlPlane
+// CustomResourceDefinition from the typed API structs.  Each version of the
+// API is described by its own schema, so the CRD specifies spec.versions and
+// the conversion webhook instead of spec.validation.
+//
+//	crd-schema-gen deploy/maistra-operator.yaml ...
+//	  replaces the versions of the ServiceMeshControlPlane CRD in the files
+//	crd-schema-gen
+//	  prints the versions of the ServiceMeshControlPlane CRD
+//	crd-schema-gen --istio-values v1.1
+//	  prints the schema of the Istio helm values supported by v1.1
+func main() {
+	istioValuesVersion := ""
+	pflag.StringVar(&istioValuesVersion, "istio-values", "", "Print the schema of the Istio helm values supported by the specified version, e.g. v1.1")
+	pflag.Parse()
+
+	if err := run(istioValuesVersion, pflag.Args()); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(istioValuesVersion string, files []string) error {
+	if len(istioValuesVersion) > 0 {
+		version, err := maistra.ParseVersion(istioValuesVersion)
+		if err != nil {
+			return fmt.Errorf("%v; supported versions are: %v", err, maistra.GetSupportedVersions())
+		}
+		schema, err := openapi.ToUnstructured(openapi.IstioValues(version), "")
+		if err != nil {
+			return err
+		}
+		data, err := yaml.Marshal(schema)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+
+	versions, err := versionsYAML()
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		_, err = os.Stdout.WriteString(versions)
+		return err
+	}
+	for _, file := range files {
+		if err := updateFile(file, versions); err != nil {
+			return fmt.Errorf("error updating %s: %v", file, err)
+		}
+	}
+	return nil
+}
+
+// versionsYAML returns the spec.versions, spec.preserveUnknownFields and
+// spec.conversion fields of the CRD, indented for use within the CRD's spec.
+// v1 remains the storage version.
+func versionsYAML() (string, error) {
+	v1Schema, err := openapi.ToUnstructured(openapi.ControlPlaneValidation().OpenAPIV3Schema, openapi.HelmValuesPaths...)
+	if err != nil {
+		return "", err
+	}
+	v2Schema, err := openapi.ToUnstructured(openapi.ControlPlaneV2Validation().OpenAPIV3Schema, openapi.HelmValuesPaths...)
+	if err != nil {
+		return "", err
+	}
+	data, err := yaml.Marshal(map[string]interface{}{
+		"versions": []interface{}{
+			map[string]interface{}{
+				"name":    "v1",
+				"served":  true,
+				"storage": true,
+				"schema": map[string]interface{}{
+					"openAPIV3Schema": v1Schema,
+				},
+			},
+			map[string]interface{}{
+				"name":    "v2",
+				"served":  true,
+				"storage": false,
+				"schema": map[string]interface{}{
+					"openAPIV3Schema": v2Schema,
+				},
+			},
+		},
+		"preserveUnknownFields": false,
+		"conversion": map[string]interface{}{
+			"strategy": "Webhook",
+			"webhookClientConfig": map[string]interface{}{
+				"service": map[string]interface{}{
+					"name":      conversionServiceName,
+					"namespace": conversionServiceNamespace,
+					"path":      conversionServicePath,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	for index, line := range lines {
+		lines[index] = "  " + line
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// updateFile replaces the generated fields of the ServiceMeshControlPlane CRD
+// contained in the file.  The other documents in the file are not modified.
+func updateFile(file string, versions string) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	documents := strings.Split(string(data), "\n---\n")
+	found := false
+	for index, document := range documents {
+		if !isControlPlaneCRD(document) {
+			continue
+		}
+		documents[index] = replaceGeneratedFields(document, versions)
+		found = true
+	}
+	if !found {
+		return fmt.Errorf("no CustomResourceDefinition named %s", controlPlaneCRDName)
+	}
+	return ioutil.WriteFile(file, []byte(strings.Join(documents, "\n---\n")), 0644)
+}
+
+func isControlPlaneCRD(document string) bool {
+	return strings.Contains(document, "kind: CustomResourceDefinition\n") &&
+		strings.Contains(document, "  name: "+controlPlaneCRDName+"\n")
+}
+
+// replaceGeneratedFields removes the generated fields from the spec of the
+// document and appends the new fields
+func replaceGeneratedFields(document string, fields string) string {
+	trailer := ""
+	if strings.HasSuffix(document, "\n") {
+		trailer = "\n"
+	}
+	var lines []string
+	inField := false
+	for _, line := range strings.Split(strings.TrimSuffix(document, "\n"), "\n") {
+		if isGeneratedField(line) {
+			inField = true
+			continue
+		}
+		if inField {
+			// nested fields and the items of indentless lists
+			if strings.HasPrefix(line, "   ") || strings.HasPrefix(line, "  - ") || len(strings.TrimSpace(line)) == 0 {
+				continue
+			}
+			inField = false
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n") + "\n" + strings.TrimSuffix(fields, "\n") + trailer
+}
+
+func isGeneratedField(line string) bool {
+	for _, field := range generatedFields {
+		if strings.HasPrefix(line, "  "+field+":") {
+			return true
+		}
+	}
+	return false
+}
@@ -5,6 +5,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
@@ -14,6 +15,7 @@ import (
 	maistrav1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
 	"github.com/maistra/istio-operator/pkg/controller"
 	"github.com/maistra/istio-operator/pkg/controller/common"
+	"github.com/maistra/istio-operator/pkg/controller/servicemesh/webhooks/validation"
 	"github.com/maistra/istio-operator/pkg/version"
 
 	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
@@ -62,6 +64,12 @@ func main() {
 	pflag.StringVar(&common.Options.ChartsDir, "chartsDir", "", "The root location of the helm charts.")
 	pflag.StringVar(&common.Options.DefaultTemplatesDir, "defaultTemplatesDir", "", "The root location of the default templates.")
 	pflag.StringVar(&common.Options.UserTemplatesDir, "userTemplatesDir", "", "The root location of the user supplied templates.")
+	pflag.StringVar(&common.Options.ApplyStrategy, "applyStrategy", string(common.ApplyStrategyThreeWayMerge), "The strategy used to update resources that do not specify one: three-way-merge, server-side or replace")
+	pflag.IntVar(&common.Options.RenderCacheSize, "renderCacheSize", 32, "The maximum number of rendered helm charts kept in the render cache; 0 disables the cache")
+	pflag.StringVar(&common.Options.RenderCacheDir, "renderCacheDir", "", "The directory in which rendered helm charts are persisted, so they can be reused after the operator's process restarts")
+	pflag.DurationVar(&common.Options.OrphanSweepInterval, "orphanSweepInterval", 10*time.Minute, "The interval at which resources belonging to deleted control planes are deleted; 0 disables sweeping")
+	pflag.BoolVar(&common.Options.OrphanSweepDryRun, "orphanSweepDryRun", true, "Only report resources belonging to deleted control planes, instead of deleting them; set to false to delete them")
+	pflag.DurationVar(&common.Options.UpgradeCheckInterval, "upgradeCheckInterval", time.Hour, "The interval at which ServiceMeshUpgradeChecks are repeated and available control plane upgrades are checked")
 
 	printVersion := false
 	pflag.BoolVar(&printVersion, "version", printVersion, "Prints version information and exits")
@@ -71,6 +79,18 @@ func main() {
 		fmt.Printf("%s\n", version.Info)
 		os.Exit(0)
 	}
+	if _, err := common.ParseApplyStrategy(common.Options.ApplyStrategy); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid --applyStrategy: %v\n", err)
+		os.Exit(1)
+	}
+	if err := common.LoadVersionDescriptors(); err != nil {
+		fmt.Fprintf(os.Stderr, "error loading version descriptors: %v\n", err)
+		os.Exit(1)
+	}
+	if err := validation.ValidateVersionDescriptors(); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid version descriptors: %v\n", err)
+		os.Exit(1)
+	}
 
 	// The logger instantiated here can be changed to any logger
 	// implementing the logr.Logger interface. This logger will
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubescheme "k8s.io/client-go/kubernetes/scheme"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+
+	"github.com/maistra/istio-operator/pkg/apis"
+	maistrav1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+	"github.com/maistra/istio-operator/pkg/controller/common"
+	"github.com/maistra/istio-operator/pkg/controller/servicemesh/controlplane"
+)
+
+// render renders the resources the operator would create for a
+// ServiceMeshControlPlane, without accessing the cluster, so they can be
+// reviewed before the control plane is applied.
+//
+//	render --resourceDir resources --output rendered smcp.yaml
+//	  writes the resources of each component to rendered/<component>.yaml
+func main() {
+	operatorNamespace := ""
+	namespace := ""
+	outputDir := ""
+	cniEnabled := true
+	verbose := false
+	pflag.StringVar(&common.Options.ResourceDir, "resourceDir", "/usr/local/share/istio-operator", "The location of the resources - helm charts, templates, etc.")
+	pflag.StringVar(&common.Options.ChartsDir, "chartsDir", "", "The root location of the helm charts.")
+	pflag.StringVar(&common.Options.DefaultTemplatesDir, "defaultTemplatesDir", "", "The root location of the default templates.")
+	pflag.StringVar(&common.Options.UserTemplatesDir, "userTemplatesDir", "", "The root location of the user supplied templates.")
+	pflag.StringVar(&common.Options.ApplyStrategy, "applyStrategy", string(common.ApplyStrategyThreeWayMerge), "The strategy used to update resources that do not specify one: three-way-merge, server-side or replace")
+	pflag.StringVar(&operatorNamespace, "operatorNamespace", "istio-operator", "The namespace the operator is installed in")
+	pflag.StringVar(&namespace, "namespace", "istio-system", "The namespace of the ServiceMeshControlPlane, if it does not specify one")
+	pflag.StringVar(&outputDir, "output", "rendered", "The directory the rendered resources are written to")
+	pflag.BoolVar(&cniEnabled, "cni", cniEnabled, "Whether the cluster uses Istio CNI")
+	pflag.BoolVar(&verbose, "verbose", verbose, "Log the progress of the rendering")
+	pflag.Parse()
+
+	if verbose {
+		logf.SetLogger(logf.ZapLoggerTo(os.Stderr, true))
+	}
+
+	if err := run(pflag.Args(), namespace, operatorNamespace, outputDir, common.CNIConfig{Enabled: cniEnabled}); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, namespace, operatorNamespace, outputDir string, cniConfig common.CNIConfig) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected the ServiceMeshControlPlane file as the only argument")
+	}
+	if _, err := common.ParseApplyStrategy(common.Options.ApplyStrategy); err != nil {
+		return fmt.Errorf("invalid --applyStrategy: %v", err)
+	}
+	if err := common.LoadVersionDescriptors(); err != nil {
+		return fmt.Errorf("error loading version descriptors: %v", err)
+	}
+
+	data, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+	smcp := &maistrav1.ServiceMeshControlPlane{}
+	if err := yaml.Unmarshal(data, smcp); err != nil {
+		return fmt.Errorf("failed to parse ServiceMeshControlPlane %s: %v", args[0], err)
+	}
+	if smcp.Kind != "ServiceMeshControlPlane" {
+		return fmt.Errorf("%s does not contain a ServiceMeshControlPlane", args[0])
+	}
+	if len(smcp.Namespace) == 0 {
+		smcp.Namespace = namespace
+	}
+
+	scheme := runtime.NewScheme()
+	if err := kubescheme.AddToScheme(scheme); err != nil {
+		return err
+	}
+	if err := apis.AddToScheme(scheme); err != nil {
+		return err
+	}
+
+	log := logf.Log.WithName("render")
+	ctx := common.NewContextWithLog(common.NewContext(), log)
+	components, err := controlplane.RenderOffline(ctx, smcp, operatorNamespace, scheme, cniConfig)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return err
+	}
+	for _, component := range components {
+		buf := &bytes.Buffer{}
+		for _, obj := range component.Objects {
+			objYAML, err := yaml.Marshal(obj.Object)
+			if err != nil {
+				return err
+			}
+			buf.WriteString("---\n")
+			buf.Write(objYAML)
+		}
+		file := filepath.Join(outputDir, component.Name+".yaml")
+		if err := ioutil.WriteFile(file, buf.Bytes(), 0644); err != nil {
+			return err
+		}
+		fmt.Printf("%s: %d resources\n", file, len(component.Objects))
+	}
+	return nil
+}
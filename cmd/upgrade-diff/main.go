@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubescheme "k8s.io/client-go/kubernetes/scheme"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+
+	"github.com/maistra/istio-operator/pkg/apis"
+	"github.com/maistra/istio-operator/pkg/apis/maistra"
+	maistrav1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+	"github.com/maistra/istio-operator/pkg/controller/common"
+	"github.com/maistra/istio-operator/pkg/controller/servicemesh/controlplane"
+)
+
+// upgrade-diff renders a ServiceMeshControlPlane for two maistra versions,
+// without accessing the cluster, and prints the resources that would be
+// added, removed or changed by updating spec.version.
+//
+//	upgrade-diff --resourceDir resources --to v1.2 smcp.yaml
+//	  compares the version specified in smcp.yaml with v1.2
+func main() {
+	operatorNamespace := ""
+	namespace := ""
+	fromVersion := ""
+	toVersion := ""
+	cniEnabled := true
+	verbose := false
+	pflag.StringVar(&common.Options.ResourceDir, "resourceDir", "/usr/local/share/istio-operator", "The location of the resources - helm charts, templates, etc.")
+	pflag.StringVar(&common.Options.ChartsDir, "chartsDir", "", "The root location of the helm charts.")
+	pflag.StringVar(&common.Options.DefaultTemplatesDir, "defaultTemplatesDir", "", "The root location of the default templates.")
+	pflag.StringVar(&common.Options.UserTemplatesDir, "userTemplatesDir", "", "The root location of the user supplied templates.")
+	pflag.StringVar(&operatorNamespace, "operatorNamespace", "istio-operator", "The namespace the operator is installed in")
+	pflag.StringVar(&namespace, "namespace", "istio-system", "The namespace of the ServiceMeshControlPlane, if it does not specify one")
+	pflag.StringVar(&fromVersion, "from", "", "The version to compare, defaults to the version of the ServiceMeshControlPlane")
+	pflag.StringVar(&toVersion, "to", "", "The version to compare with, e.g. v1.2")
+	pflag.BoolVar(&cniEnabled, "cni", cniEnabled, "Whether the cluster uses Istio CNI")
+	pflag.BoolVar(&verbose, "verbose", verbose, "Log the progress of the rendering")
+	pflag.Parse()
+
+	if verbose {
+		logf.SetLogger(logf.ZapLoggerTo(os.Stderr, true))
+	}
+
+	if err := run(pflag.Args(), namespace, operatorNamespace, fromVersion, toVersion, common.CNIConfig{Enabled: cniEnabled}); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, namespace, operatorNamespace, fromVersion, toVersion string, cniConfig common.CNIConfig) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected the ServiceMeshControlPlane file as the only argument")
+	}
+	if err := common.LoadVersionDescriptors(); err != nil {
+		return fmt.Errorf("error loading version descriptors: %v", err)
+	}
+
+	data, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+	smcp := &maistrav1.ServiceMeshControlPlane{}
+	if err := yaml.Unmarshal(data, smcp); err != nil {
+		return fmt.Errorf("failed to parse ServiceMeshControlPlane %s: %v", args[0], err)
+	}
+	if smcp.Kind != "ServiceMeshControlPlane" {
+		return fmt.Errorf("%s does not contain a ServiceMeshControlPlane", args[0])
+	}
+	if len(smcp.Namespace) == 0 {
+		smcp.Namespace = namespace
+	}
+
+	if len(fromVersion) == 0 {
+		fromVersion = smcp.Spec.Version
+		if len(fromVersion) == 0 {
+			fromVersion = maistra.LegacyVersion.String()
+		}
+	}
+	for _, version := range []string{fromVersion, toVersion} {
+		if _, err := common.ResolveVersion(version); err != nil {
+			return err
+		}
+	}
+
+	scheme := runtime.NewScheme()
+	if err := kubescheme.AddToScheme(scheme); err != nil {
+		return err
+	}
+	if err := apis.AddToScheme(scheme); err != nil {
+		return err
+	}
+
+	log := logf.Log.WithName("upgrade-diff")
+	ctx := common.NewContextWithLog(common.NewContext(), log)
+	diffs, err := controlplane.DiffVersions(ctx, smcp, fromVersion, toVersion, operatorNamespace, scheme, cniConfig)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Comparing %s/%s version %s with version %s: %d resource(s) differ\n", smcp.Namespace, smcp.Name, fromVersion, toVersion, len(diffs))
+	for _, diff := range diffs {
+		printDiff(os.Stdout, diff)
+	}
+	return nil
+}
+
+func printDiff(out io.Writer, diff common.ResourceDiff) {
+	fmt.Fprintf(out, "%-8s %s\n", diff.Action, diff.Resource)
+	for _, field := range diff.Fields {
+		switch {
+		case field.Old == nil:
+			fmt.Fprintf(out, "    + %s: %s\n", field.Path, formatValue(field.New))
+		case field.New == nil:
+			fmt.Fprintf(out, "    - %s: %s\n", field.Path, formatValue(field.Old))
+		default:
+			fmt.Fprintf(out, "    ~ %s: %s -> %s\n", field.Path, formatValue(field.Old), formatValue(field.New))
+		}
+	}
+}
+
+func formatValue(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
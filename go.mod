@@ -9,6 +9,8 @@ require (
 	github.com/openshift/library-go v0.0.0-20190916131355-a00adb84bd57
 	github.com/operator-framework/operator-sdk v0.10.1-0.20190917191403-5f663690a3bb
 	github.com/pkg/errors v0.8.1
+	github.com/prometheus/client_golang v0.9.3-0.20190127221311-3c4408c8b829
+	github.com/prometheus/client_model v0.0.0-20190129233127-fd36f4220a90
 	github.com/spf13/pflag v1.0.3
 	gopkg.in/yaml.v2 v2.2.2
 	istio.io/api v0.0.0-20190917173507-9eb49cc4666a
@@ -0,0 +1,225 @@
+// Package conversion converts ServiceMeshControlPlanes between the v1 API,
+// which exposes the values of the Istio helm charts, and the v2 API, which
+// describes the control plane using typed fields.  The typed fields are
+// translated to the values used by the charts of the control plane's version.
+package conversion
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/maistra/istio-operator/pkg/apis/maistra"
+	maistrav1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+	maistrav2 "github.com/maistra/istio-operator/pkg/apis/maistra/v2"
+)
+
+const controlPlaneKind = "ServiceMeshControlPlane"
+
+// typedFields holds the fields of the v2 spec that are backed by Istio helm
+// values
+type typedFields struct {
+	Security *maistrav2.SecurityConfig            `json:"security,omitempty"`
+	Tracing  *maistrav2.TracingConfig             `json:"tracing,omitempty"`
+	Gateways *maistrav2.GatewaysConfig            `json:"gateways,omitempty"`
+	Addons   *maistrav2.AddonsConfig              `json:"addons,omitempty"`
+	Runtime  *maistrav2.ControlPlaneRuntimeConfig `json:"runtime,omitempty"`
+}
+
+// ConvertV1ToV2 converts a v1 ServiceMeshControlPlane to v2.  The values are
+// translated using the charts of the version specified by the control plane.
+func ConvertV1ToV2(in *maistrav1.ServiceMeshControlPlane, out *maistrav2.ServiceMeshControlPlane) error {
+	out.APIVersion = maistrav2.SchemeGroupVersion.String()
+	out.Kind = controlPlaneKind
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Status.DeepCopyInto(&out.Status)
+	return ConvertSpecV1ToV2(&in.Spec, &out.Spec, versionOf(in.Spec.Version))
+}
+
+// ConvertV2ToV1 converts a v2 ServiceMeshControlPlane to v1.  An error is
+// returned if the control plane specifies fields that are not supported by
+// its version.
+func ConvertV2ToV1(in *maistrav2.ServiceMeshControlPlane, out *maistrav1.ServiceMeshControlPlane) error {
+	out.APIVersion = maistrav1.SchemeGroupVersion.String()
+	out.Kind = controlPlaneKind
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Status.DeepCopyInto(&out.Status)
+	return ConvertSpecV2ToV1(&in.Spec, &out.Spec, versionOf(in.Spec.Version))
+}
+
+// versionOf returns the version whose charts are used to translate the
+// values of a control plane.  Control planes without a version are treated as
+// v1.0.  Ranges and versions that are not supported are translated using the
+// default version, whose charts support the most values.
+func versionOf(version string) maistra.Version {
+	v, err := maistra.ParseVersion(version)
+	if err != nil {
+		return maistra.DefaultVersion
+	} else if v == maistra.UndefinedVersion {
+		return maistra.LegacyVersion
+	}
+	return v
+}
+
+// ConvertSpecV1ToV2 converts a v1 spec to v2.  Istio values that back a typed
+// field of the v2 API in the specified version are moved to the field, if the
+// field can represent them.  All other values are kept in spec.techPreview,
+// so no values are lost converting the spec back to v1.
+func ConvertSpecV1ToV2(in *maistrav1.ControlPlaneSpec, out *maistrav2.ControlPlaneSpec, version maistra.Version) error {
+	convertCommonSpecV1ToV2(in, out)
+
+	values := in.Istio.DeepCopy()
+	typed := map[string]interface{}{}
+	for _, field := range valueFields {
+		if !field.supportedBy(version) {
+			continue
+		}
+		value, found, err := unstructured.NestedFieldNoCopy(values, splitPath(field.valuePath)...)
+		if err != nil || !found {
+			continue
+		}
+		fieldValue, ok := field.kind.fromValue(value)
+		if !ok {
+			continue
+		}
+		if err := unstructured.SetNestedField(typed, fieldValue, splitPath(field.path)...); err != nil {
+			return err
+		}
+		removeValue(values, splitPath(field.valuePath))
+	}
+
+	fields := typedFields{}
+	data, err := json.Marshal(typed)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	out.Security = fields.Security
+	out.Tracing = fields.Tracing
+	out.Gateways = fields.Gateways
+	out.Addons = fields.Addons
+	out.Runtime = fields.Runtime
+
+	out.TechPreview = nil
+	if len(values) > 0 {
+		out.TechPreview = values
+	}
+	out.ThreeScale = in.ThreeScale.DeepCopy()
+	return nil
+}
+
+// ConvertSpecV2ToV1 converts a v2 spec to v1.  The typed fields are
+// translated to the Istio values used by the charts of the specified version
+// and take precedence over the same values specified in spec.techPreview.  An
+// error is returned if a field is not supported by the version.
+func ConvertSpecV2ToV1(in *maistrav2.ControlPlaneSpec, out *maistrav1.ControlPlaneSpec, version maistra.Version) error {
+	convertCommonSpecV2ToV1(in, out)
+
+	var allErrors []error
+	if in.Runtime != nil {
+		for component := range in.Runtime.Components {
+			if _, ok := componentValuePaths[component]; !ok {
+				allErrors = append(allErrors, fmt.Errorf("unknown component %s in runtime.components", component))
+			}
+		}
+	}
+
+	data, err := json.Marshal(typedFields{
+		Security: in.Security,
+		Tracing:  in.Tracing,
+		Gateways: in.Gateways,
+		Addons:   in.Addons,
+		Runtime:  in.Runtime,
+	})
+	if err != nil {
+		return err
+	}
+	typed := map[string]interface{}{}
+	if err := json.Unmarshal(data, &typed); err != nil {
+		return err
+	}
+
+	values := in.TechPreview.DeepCopy()
+	if values == nil {
+		values = maistrav1.HelmValuesType{}
+	}
+	for _, field := range valueFields {
+		fieldValue, found, err := unstructured.NestedFieldNoCopy(typed, splitPath(field.path)...)
+		if err != nil || !found {
+			continue
+		}
+		if !field.supportedBy(version) {
+			allErrors = append(allErrors, fmt.Errorf("%s is not supported by version %s", field.path, version))
+			continue
+		}
+		value, err := field.kind.toValue(fieldValue)
+		if err != nil {
+			allErrors = append(allErrors, fmt.Errorf("invalid %s: %v", field.path, err))
+			continue
+		}
+		if err := unstructured.SetNestedField(values, value, splitPath(field.valuePath)...); err != nil {
+			allErrors = append(allErrors, fmt.Errorf("cannot set %s: %v", field.path, err))
+		}
+	}
+	if len(allErrors) > 0 {
+		return utilerrors.NewAggregate(allErrors)
+	}
+
+	out.Istio = nil
+	if len(values) > 0 {
+		out.Istio = values
+	}
+	out.ThreeScale = in.ThreeScale.DeepCopy()
+	return nil
+}
+
+// convertCommonSpecV1ToV2 copies the fields that are the same in v1 and v2
+func convertCommonSpecV1ToV2(in *maistrav1.ControlPlaneSpec, out *maistrav2.ControlPlaneSpec) {
+	in = in.DeepCopy()
+	out.Template = in.Template
+	out.Templates = in.Templates
+	out.Version = in.Version
+	out.UpgradePolicy = in.UpgradePolicy
+	out.RollbackPolicy = in.RollbackPolicy
+	out.RevisionHistoryLimit = in.RevisionHistoryLimit
+	out.RollbackTo = in.RollbackTo
+	out.Overlays = in.Overlays
+	out.DeletionPolicy = in.DeletionPolicy
+	out.NetworkType = in.NetworkType
+}
+
+// convertCommonSpecV2ToV1 copies the fields that are the same in v1 and v2
+func convertCommonSpecV2ToV1(in *maistrav2.ControlPlaneSpec, out *maistrav1.ControlPlaneSpec) {
+	in = in.DeepCopy()
+	out.Template = in.Template
+	out.Templates = in.Templates
+	out.Version = in.Version
+	out.UpgradePolicy = in.UpgradePolicy
+	out.RollbackPolicy = in.RollbackPolicy
+	out.RevisionHistoryLimit = in.RevisionHistoryLimit
+	out.RollbackTo = in.RollbackTo
+	out.Overlays = in.Overlays
+	out.DeletionPolicy = in.DeletionPolicy
+	out.NetworkType = in.NetworkType
+}
+
+// removeValue removes the value at the path, along with the maps that are
+// left empty by its removal
+func removeValue(values map[string]interface{}, path []string) {
+	if len(path) == 1 {
+		delete(values, path[0])
+		return
+	}
+	child, ok := values[path[0]].(map[string]interface{})
+	if !ok {
+		return
+	}
+	removeValue(child, path[1:])
+	if len(child) == 0 {
+		delete(values, path[0])
+	}
+}
@@ -0,0 +1,256 @@
+package conversion
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/maistra/istio-operator/pkg/apis/maistra"
+	maistrav1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+	maistrav2 "github.com/maistra/istio-operator/pkg/apis/maistra/v2"
+	"github.com/maistra/istio-operator/pkg/controller/common/test/assert"
+)
+
+func TestConvertV1ToV2(t *testing.T) {
+	in := newV1ControlPlane("v1.1", `{
+		"global": {
+			"controlPlaneSecurityEnabled": true,
+			"mtls": {"enabled": false, "auto": true},
+			"proxy": {"image": "proxyv2"}
+		},
+		"tracing": {"enabled": true, "jaeger": {"template": "all-in-one"}},
+		"pilot": {"traceSampling": 1.5, "replicaCount": 2, "autoscaleEnabled": false},
+		"mixer": {"policy": {"autoscaleMin": 1, "autoscaleMax": 5}},
+		"gateways": {"istio-egressgateway": {"enabled": false}},
+		"kiali": {"enabled": true}
+	}`)
+	out := &maistrav2.ServiceMeshControlPlane{}
+	if err := ConvertV1ToV2(in, out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	assert.Equals(out.APIVersion, maistrav2.SchemeGroupVersion.String(), "Unexpected apiVersion", t)
+	assert.Equals(out.Name, in.Name, "Unexpected name", t)
+	assert.Equals(out.Spec.Version, "v1.1", "Unexpected version", t)
+	assert.Equals(out.Spec.Template, maistrav1.DefaultTemplate, "Unexpected template", t)
+	assert.DeepEquals(out.Spec.Security, &maistrav2.SecurityConfig{
+		ControlPlane: &maistrav2.ControlPlaneSecurityConfig{MTLS: boolPtr(true)},
+		DataPlane:    &maistrav2.DataPlaneSecurityConfig{MTLS: boolPtr(false), AutoMTLS: boolPtr(true)},
+	}, "Unexpected security", t)
+	assert.DeepEquals(out.Spec.Tracing, &maistrav2.TracingConfig{Type: maistrav2.TracerTypeJaeger, Sampling: int32Ptr(150)}, "Unexpected tracing", t)
+	assert.DeepEquals(out.Spec.Gateways, &maistrav2.GatewaysConfig{Egress: &maistrav2.GatewayConfig{Enabled: boolPtr(false)}}, "Unexpected gateways", t)
+	assert.DeepEquals(out.Spec.Addons, &maistrav2.AddonsConfig{Kiali: &maistrav2.AddonConfig{Enabled: boolPtr(true)}}, "Unexpected addons", t)
+	assert.DeepEquals(out.Spec.Runtime, &maistrav2.ControlPlaneRuntimeConfig{
+		Components: map[maistrav2.ControlPlaneComponentName]maistrav2.ComponentRuntimeConfig{
+			maistrav2.ControlPlaneComponentNamePilot: {
+				ReplicaCount: int32Ptr(2),
+				Autoscaling:  &maistrav2.AutoscalerConfig{Enabled: boolPtr(false)},
+			},
+			maistrav2.ControlPlaneComponentNamePolicy: {
+				Autoscaling: &maistrav2.AutoscalerConfig{MinReplicas: int32Ptr(1), MaxReplicas: int32Ptr(5)},
+			},
+		},
+	}, "Unexpected runtime", t)
+	assert.DeepEquals(out.Spec.TechPreview, helmValues(t, `{
+		"global": {"proxy": {"image": "proxyv2"}},
+		"tracing": {"jaeger": {"template": "all-in-one"}}
+	}`), "Expected values without a typed field to be kept in techPreview", t)
+}
+
+func TestConvertV1ToV2KeepsValuesNotSupportedByVersion(t *testing.T) {
+	in := newV1ControlPlane("v1.0", `{"global": {"mtls": {"enabled": true, "auto": true}}, "pilot": {"traceSampling": 0.005}}`)
+	out := &maistrav2.ServiceMeshControlPlane{}
+	if err := ConvertV1ToV2(in, out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.DeepEquals(out.Spec.Security, &maistrav2.SecurityConfig{
+		DataPlane: &maistrav2.DataPlaneSecurityConfig{MTLS: boolPtr(true)},
+	}, "Unexpected security", t)
+	assert.True(out.Spec.Tracing == nil, "Expected sampling that cannot be represented to be kept in techPreview", t)
+	assert.DeepEquals(out.Spec.TechPreview, helmValues(t, `{"global": {"mtls": {"auto": true}}, "pilot": {"traceSampling": 0.005}}`),
+		"Expected values not supported by the version to be kept in techPreview", t)
+}
+
+func TestRoundTrip(t *testing.T) {
+	testCases := []struct {
+		name    string
+		version string
+		values  string
+	}{
+		{
+			name:    "typed-values",
+			version: "v1.1",
+			values:  `{"global": {"mtls": {"enabled": true, "auto": false}}, "tracing": {"enabled": false}, "pilot": {"traceSampling": 100, "autoscaleEnabled": true}}`,
+		},
+		{
+			name:    "untyped-values",
+			version: "v1.1",
+			values:  `{"global": {"proxy": {"autoInject": "disabled"}}, "pilot": {"replicaCount": "2"}, "grafana": {"enabled": "true"}}`,
+		},
+		{
+			name:    "legacy-version",
+			version: "",
+			values:  `{"global": {"mtls": {"auto": true}}, "kiali": {"enabled": false}}`,
+		},
+		{
+			name:    "range",
+			version: "~1.1",
+			values:  `{"global": {"mtls": {"auto": true}}, "gateways": {"enabled": true, "istio-ingressgateway": {"enabled": true, "ports": []}}}`,
+		},
+		{
+			name:    "no-values",
+			version: "v1.2",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			in := newV1ControlPlane(tc.version, tc.values)
+			v2 := &maistrav2.ServiceMeshControlPlane{}
+			if err := ConvertV1ToV2(in, v2); err != nil {
+				t.Fatalf("unexpected error converting to v2: %v", err)
+			}
+			out := &maistrav1.ServiceMeshControlPlane{}
+			if err := ConvertV2ToV1(v2, out); err != nil {
+				t.Fatalf("unexpected error converting to v1: %v", err)
+			}
+			assert.DeepEquals(toJSON(t, out), toJSON(t, in), "Expected the control plane to be unchanged by the round trip", t)
+		})
+	}
+}
+
+func TestConvertV2ToV1(t *testing.T) {
+	in := &maistrav2.ServiceMeshControlPlane{
+		ObjectMeta: metav1.ObjectMeta{Name: "basic-install", Namespace: "istio-system"},
+		Spec: maistrav2.ControlPlaneSpec{
+			Version: "v1.1",
+			Security: &maistrav2.SecurityConfig{
+				DataPlane: &maistrav2.DataPlaneSecurityConfig{MTLS: boolPtr(true)},
+			},
+			Tracing:     &maistrav2.TracingConfig{Type: maistrav2.TracerTypeNone, Sampling: int32Ptr(10000)},
+			TechPreview: helmValues(t, `{"global": {"mtls": {"enabled": false}, "proxy": {"image": "proxyv2"}}}`),
+		},
+	}
+	out := &maistrav1.ServiceMeshControlPlane{}
+	if err := ConvertV2ToV1(in, out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Equals(out.APIVersion, maistrav1.SchemeGroupVersion.String(), "Unexpected apiVersion", t)
+	assert.DeepEquals(toJSON(t, out.Spec.Istio), toJSON(t, helmValues(t, `{
+		"global": {"mtls": {"enabled": true}, "proxy": {"image": "proxyv2"}},
+		"tracing": {"enabled": false},
+		"pilot": {"traceSampling": 100}
+	}`)), "Expected typed fields to take precedence over techPreview", t)
+}
+
+func TestConvertV2ToV1Errors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		spec          maistrav2.ControlPlaneSpec
+		expectedError string
+	}{
+		{
+			name: "not-supported-by-version",
+			spec: maistrav2.ControlPlaneSpec{
+				Version: "v1.0",
+				Security: &maistrav2.SecurityConfig{
+					DataPlane: &maistrav2.DataPlaneSecurityConfig{AutoMTLS: boolPtr(true)},
+				},
+			},
+			expectedError: "security.dataPlane.autoMtls is not supported by version v1.0",
+		},
+		{
+			name: "unknown-component",
+			spec: maistrav2.ControlPlaneSpec{
+				Version: "v1.1",
+				Runtime: &maistrav2.ControlPlaneRuntimeConfig{
+					Components: map[maistrav2.ControlPlaneComponentName]maistrav2.ComponentRuntimeConfig{
+						"mixer": {ReplicaCount: int32Ptr(1)},
+					},
+				},
+			},
+			expectedError: "unknown component mixer",
+		},
+		{
+			name: "unknown-tracer",
+			spec: maistrav2.ControlPlaneSpec{
+				Version: "v1.1",
+				Tracing: &maistrav2.TracingConfig{Type: "Zipkin"},
+			},
+			expectedError: "invalid tracing.type",
+		},
+		{
+			name: "invalid-sampling",
+			spec: maistrav2.ControlPlaneSpec{
+				Version: "v1.1",
+				Tracing: &maistrav2.TracingConfig{Sampling: int32Ptr(10001)},
+			},
+			expectedError: "invalid tracing.sampling",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ConvertV2ToV1(&maistrav2.ServiceMeshControlPlane{Spec: tc.spec}, &maistrav1.ServiceMeshControlPlane{})
+			if err == nil {
+				t.Fatalf("expected conversion to fail")
+			}
+			assert.True(strings.Contains(err.Error(), tc.expectedError), "Unexpected error: "+err.Error(), t)
+		})
+	}
+}
+
+func TestVersionOf(t *testing.T) {
+	assert.Equals(versionOf(""), maistra.Version(maistra.LegacyVersion), "Expected control planes without a version to be translated as v1.0", t)
+	assert.Equals(versionOf("v1.1.3"), maistra.Version(maistra.V1_1), "Expected patch releases to be translated as their version", t)
+	assert.Equals(versionOf("~1.0"), maistra.DefaultVersion, "Expected ranges to be translated as the default version", t)
+}
+
+func newV1ControlPlane(version, values string) *maistrav1.ServiceMeshControlPlane {
+	smcp := &maistrav1.ServiceMeshControlPlane{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: maistrav1.SchemeGroupVersion.String(),
+			Kind:       "ServiceMeshControlPlane",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "basic-install",
+			Namespace: "istio-system",
+		},
+		Spec: maistrav1.ControlPlaneSpec{
+			Version:  version,
+			Template: maistrav1.DefaultTemplate,
+		},
+	}
+	if values != "" {
+		if err := json.Unmarshal([]byte(values), &smcp.Spec.Istio); err != nil {
+			panic(err)
+		}
+	}
+	return smcp
+}
+
+func helmValues(t *testing.T, values string) maistrav1.HelmValuesType {
+	result := maistrav1.HelmValuesType{}
+	if err := json.Unmarshal([]byte(values), &result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return result
+}
+
+// toJSON returns the json representation of the object, so objects whose
+// values have different go types, e.g. int64 and float64, can be compared
+func toJSON(t *testing.T, obj interface{}) string {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return string(data)
+}
+
+func boolPtr(value bool) *bool {
+	return &value
+}
+
+func int32Ptr(value int32) *int32 {
+	return &value
+}
@@ -0,0 +1,170 @@
+package conversion
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/maistra/istio-operator/pkg/apis/maistra"
+	maistrav2 "github.com/maistra/istio-operator/pkg/apis/maistra/v2"
+)
+
+// valueField maps a typed field of the v2 API to the Istio helm value backing
+// it in the charts
+type valueField struct {
+	// path of the field within the v2 spec, e.g. security.dataPlane.mtls
+	path string
+	// valuePath is the path of the helm value, e.g. global.mtls.enabled
+	valuePath string
+	// minVersion is the oldest version whose charts support the value
+	minVersion maistra.Version
+	kind       valueKind
+}
+
+// valueKind converts between the representation of a field in the v2 API and
+// the representation of the value in the charts.  Both are json values, i.e.
+// bool, string, int64 or float64.
+type valueKind struct {
+	// fromValue returns the field value for a helm value.  It returns false if
+	// the helm value cannot be represented by the field.
+	fromValue func(value interface{}) (interface{}, bool)
+	// toValue returns the helm value for a field value
+	toValue func(field interface{}) (interface{}, error)
+}
+
+var (
+	boolValue = valueKind{
+		fromValue: func(value interface{}) (interface{}, bool) {
+			b, ok := value.(bool)
+			return b, ok
+		},
+		toValue: func(field interface{}) (interface{}, error) {
+			if b, ok := field.(bool); ok {
+				return b, nil
+			}
+			return nil, fmt.Errorf("expected a boolean, got %v", field)
+		},
+	}
+	int32Value = valueKind{
+		fromValue: func(value interface{}) (interface{}, bool) {
+			number, ok := toFloat(value)
+			if !ok || number != math.Trunc(number) || number < math.MinInt32 || number > math.MaxInt32 {
+				return nil, false
+			}
+			return int64(number), true
+		},
+		toValue: func(field interface{}) (interface{}, error) {
+			if number, ok := toFloat(field); ok && number == math.Trunc(number) {
+				return int64(number), nil
+			}
+			return nil, fmt.Errorf("expected an integer, got %v", field)
+		},
+	}
+	// tracerValue maps tracing.type to tracing.enabled
+	tracerValue = valueKind{
+		fromValue: func(value interface{}) (interface{}, bool) {
+			enabled, ok := value.(bool)
+			if !ok {
+				return nil, false
+			}
+			if enabled {
+				return string(maistrav2.TracerTypeJaeger), true
+			}
+			return string(maistrav2.TracerTypeNone), true
+		},
+		toValue: func(field interface{}) (interface{}, error) {
+			switch maistrav2.TracerType(fmt.Sprint(field)) {
+			case maistrav2.TracerTypeJaeger:
+				return true, nil
+			case maistrav2.TracerTypeNone:
+				return false, nil
+			}
+			return nil, fmt.Errorf("unknown tracer type %v, expected %s or %s", field, maistrav2.TracerTypeNone, maistrav2.TracerTypeJaeger)
+		},
+	}
+	// samplingValue maps tracing.sampling, in units of 0.01%, to
+	// pilot.traceSampling, in percent
+	samplingValue = valueKind{
+		fromValue: func(value interface{}) (interface{}, bool) {
+			percent, ok := toFloat(value)
+			if !ok {
+				return nil, false
+			}
+			sampling := math.Round(percent * 100)
+			if math.Abs(sampling-percent*100) > 1e-9 || sampling < 0 || sampling > 10000 {
+				return nil, false
+			}
+			return int64(sampling), true
+		},
+		toValue: func(field interface{}) (interface{}, error) {
+			sampling, ok := toFloat(field)
+			if !ok || sampling < 0 || sampling > 10000 {
+				return nil, fmt.Errorf("expected an integer between 0 and 10000, got %v", field)
+			}
+			return sampling / 100, nil
+		},
+	}
+)
+
+// componentValuePaths maps the components that may be configured in
+// runtime.components to the path of their values in the charts
+var componentValuePaths = map[maistrav2.ControlPlaneComponentName]string{
+	maistrav2.ControlPlaneComponentNamePilot:           "pilot",
+	maistrav2.ControlPlaneComponentNameGalley:          "galley",
+	maistrav2.ControlPlaneComponentNameCitadel:         "security",
+	maistrav2.ControlPlaneComponentNameSidecarInjector: "sidecarInjectorWebhook",
+	maistrav2.ControlPlaneComponentNamePolicy:          "mixer.policy",
+	maistrav2.ControlPlaneComponentNameTelemetry:       "mixer.telemetry",
+}
+
+// valueFields lists the typed fields of the v2 API and the values backing
+// them.  Values whose path changes between versions are listed once per
+// version range.
+var valueFields = func() []valueField {
+	fields := []valueField{
+		{path: "security.controlPlane.mtls", valuePath: "global.controlPlaneSecurityEnabled", kind: boolValue},
+		{path: "security.dataPlane.mtls", valuePath: "global.mtls.enabled", kind: boolValue},
+		{path: "security.dataPlane.autoMtls", valuePath: "global.mtls.auto", minVersion: maistra.V1_1, kind: boolValue},
+		{path: "tracing.type", valuePath: "tracing.enabled", kind: tracerValue},
+		{path: "tracing.sampling", valuePath: "pilot.traceSampling", kind: samplingValue},
+		{path: "gateways.enabled", valuePath: "gateways.enabled", kind: boolValue},
+		{path: "gateways.ingress.enabled", valuePath: "gateways.istio-ingressgateway.enabled", kind: boolValue},
+		{path: "gateways.egress.enabled", valuePath: "gateways.istio-egressgateway.enabled", kind: boolValue},
+		{path: "addons.prometheus.enabled", valuePath: "prometheus.enabled", kind: boolValue},
+		{path: "addons.grafana.enabled", valuePath: "grafana.enabled", kind: boolValue},
+		{path: "addons.kiali.enabled", valuePath: "kiali.enabled", kind: boolValue},
+	}
+	for component, valuePath := range componentValuePaths {
+		path := "runtime.components." + string(component)
+		fields = append(fields,
+			valueField{path: path + ".replicaCount", valuePath: valuePath + ".replicaCount", kind: int32Value},
+			valueField{path: path + ".autoscaling.enabled", valuePath: valuePath + ".autoscaleEnabled", kind: boolValue},
+			valueField{path: path + ".autoscaling.minReplicas", valuePath: valuePath + ".autoscaleMin", kind: int32Value},
+			valueField{path: path + ".autoscaling.maxReplicas", valuePath: valuePath + ".autoscaleMax", kind: int32Value},
+		)
+	}
+	return fields
+}()
+
+// supportedBy returns true if the charts of the version support the value
+func (f valueField) supportedBy(version maistra.Version) bool {
+	return f.minVersion == nil || version.Compare(f.minVersion) >= 0
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch number := value.(type) {
+	case float64:
+		return number, true
+	case int64:
+		return float64(number), true
+	case int32:
+		return float64(number), true
+	case int:
+		return float64(number), true
+	}
+	return 0, false
+}
+
+func splitPath(path string) []string {
+	return strings.Split(path, ".")
+}
@@ -0,0 +1,137 @@
+package openapi
+
+import (
+	"reflect"
+	"strings"
+
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+
+	"github.com/maistra/istio-operator/pkg/apis/maistra"
+	maistrav1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+	maistrav2 "github.com/maistra/istio-operator/pkg/apis/maistra/v2"
+)
+
+// unsupportedFields lists the fields of IstioHelmValues that are not
+// supported by the charts of a version.  A "*" matches any field name.
+var unsupportedFields = map[maistra.Version][]string{
+	maistra.V1_0: {
+		"certmanager",
+		"galley.enableAnalysis",
+		"galley.enableServiceDiscovery",
+		"global.certificates",
+		"global.defaultTolerations",
+		"global.meshID",
+		"global.mtls.auto",
+		"global.multiCluster.clusterName",
+		"global.operatorManageWebhooks",
+		"global.proxy.componentLogLevel",
+		"global.proxy.enableCoreDumpImage",
+		"global.proxy.envoyAccessLogService",
+		"global.proxy.excludeOutboundPorts",
+		"global.proxy.init",
+		"global.proxy.protocolDetectionTimeout",
+		"global.sds.token",
+		"global.tracer.stackdriver",
+		"global.trustDomainAliases",
+		"kiali.jaegerInClusterURL",
+		"pilot.configSource",
+		"pilot.enableProtocolSniffingForInbound",
+		"pilot.enableProtocolSniffingForOutbound",
+		"security.citadelHealthCheck",
+		"security.enableNamespacesByDefault",
+		"security.workloadCertTtl",
+		"sidecarInjectorWebhook.alwaysInjectSelector",
+		"sidecarInjectorWebhook.injectedAnnotations",
+		"sidecarInjectorWebhook.neverInjectSelector",
+		"tracing.jaeger.accessMode",
+		"tracing.jaeger.persist",
+		"tracing.jaeger.spanStorageType",
+		"tracing.jaeger.storageClassName",
+		"*.rollingMaxSurge",
+		"*.rollingMaxUnavailable",
+		"*.tolerations",
+	},
+	maistra.V1_1: removedInV1_1,
+	maistra.V1_2: removedInV1_1,
+}
+
+// HelmValuesPaths lists the paths of the helm values of the control plane.
+// The values may include fields that are not described by the schema, e.g.
+// values that are only supported by some versions of the charts, and $patch
+// directives, so only their structure is described, see ToUnstructured().
+// The validating webhook validates the values against the version of the
+// control plane and rejects unknown Istio values.
+var HelmValuesPaths = []string{"spec.istio", "spec.threeScale"}
+
+// removedInV1_1 lists the fields that are no longer supported as of v1.1
+var removedInV1_1 = []string{
+	"global.sds.useNormalJwt",
+	"global.sds.useTrustworthyJwt",
+	"servicegraph",
+}
+
+// IstioValues returns the schema of the Istio helm values supported by the
+// charts of the specified version.
+func IstioValues(version maistra.Version) *apiextensionsv1beta1.JSONSchemaProps {
+	schema := ForType(reflect.TypeOf(maistrav1.IstioHelmValues{}))
+	for _, path := range unsupportedFields[version] {
+		removeProperty(schema, strings.Split(path, "."))
+	}
+	return schema
+}
+
+// ControlPlaneValidation returns the validation for v1 of the
+// ServiceMeshControlPlane CustomResourceDefinition.  A single schema is used
+// for all versions of the control plane, so spec.istio is described by the
+// schema of the values supported by any version.  Version specific validation
+// is performed by the validating webhook.  The status is not described.
+func ControlPlaneValidation() *apiextensionsv1beta1.CustomResourceValidation {
+	spec := ForType(reflect.TypeOf(maistrav1.ControlPlaneSpec{}))
+	istio := ForType(reflect.TypeOf(maistrav1.IstioHelmValues{}))
+	istio.Description = "Istio holds the values used when rendering the Istio helm charts."
+	spec.Properties["istio"] = *istio
+	return controlPlaneValidation(spec)
+}
+
+// ControlPlaneV2Validation returns the validation for v2 of the
+// ServiceMeshControlPlane CustomResourceDefinition.  spec.techPreview is not
+// described, as its values are passed through to the charts.
+func ControlPlaneV2Validation() *apiextensionsv1beta1.CustomResourceValidation {
+	return controlPlaneValidation(ForType(reflect.TypeOf(maistrav2.ControlPlaneSpec{})))
+}
+
+func controlPlaneValidation(spec *apiextensionsv1beta1.JSONSchemaProps) *apiextensionsv1beta1.CustomResourceValidation {
+	spec.Description = "ControlPlaneSpec represents the configuration for installing a control plane."
+	status := openObject()
+	status.Description = "ControlPlaneStatus represents the current state of a control plane."
+
+	return &apiextensionsv1beta1.CustomResourceValidation{
+		OpenAPIV3Schema: &apiextensionsv1beta1.JSONSchemaProps{
+			Type: "object",
+			Properties: map[string]apiextensionsv1beta1.JSONSchemaProps{
+				"apiVersion": {Type: "string"},
+				"kind":       {Type: "string"},
+				"spec":       *spec,
+				"status":     *status,
+			},
+		},
+	}
+}
+
+// removeProperty removes the property at the path from the schema
+func removeProperty(schema *apiextensionsv1beta1.JSONSchemaProps, path []string) {
+	if len(path) == 0 || schema.Properties == nil {
+		return
+	}
+	for name, property := range schema.Properties {
+		if path[0] != "*" && path[0] != name {
+			continue
+		}
+		if len(path) == 1 {
+			delete(schema.Properties, name)
+			continue
+		}
+		removeProperty(&property, path[1:])
+		schema.Properties[name] = property
+	}
+}
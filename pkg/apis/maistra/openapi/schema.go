@@ -0,0 +1,336 @@
+package openapi
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"strings"
+
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	maistrav1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+)
+
+const (
+	// preserveUnknownFieldsExtension marks an object whose fields are not
+	// described by the schema, so the API server does not prune them.
+	preserveUnknownFieldsExtension = "x-kubernetes-preserve-unknown-fields"
+	// intOrStringExtension marks a value that may be either an integer or a
+	// string.
+	intOrStringExtension = "x-kubernetes-int-or-string"
+)
+
+var (
+	helmValuesType     = reflect.TypeOf(maistrav1.HelmValuesType{})
+	gatewaysConfigType = reflect.TypeOf(maistrav1.GatewaysConfig{})
+	globalConfigType   = reflect.TypeOf(maistrav1.GlobalConfig{})
+	istioValuesType    = reflect.TypeOf(maistrav1.IstioHelmValues{})
+	intOrStringType    = reflect.TypeOf(intstr.IntOrString{})
+	quantityType       = reflect.TypeOf(resource.Quantity{})
+	timeType           = reflect.TypeOf(metav1.Time{})
+	// maistraAPIPath is the path of the package containing the versions of
+	// the maistra API
+	maistraAPIPath = path.Dir(helmValuesType.PkgPath())
+	// intOrStringValues lists the json names of the helm values that are
+	// strings, but are commonly specified as numbers, e.g. image tags and
+	// port lists.  The charts render them as strings, so they accept either.
+	// The values of maps, e.g. env, are affected, too.
+	intOrStringValues = sets.NewString(
+		"applicationPorts",
+		"cpus",
+		"env",
+		"excludeInboundPorts",
+		"excludeOutboundPorts",
+		"includeInboundPorts",
+		"javaOptsHeap",
+		"maxSpans",
+		"max_traces",
+		"port",
+		"tag",
+	)
+)
+
+// IsIntOrStringValue returns true if the string value with the given json name
+// may be specified as an integer, too.
+func IsIntOrStringValue(name string) bool {
+	return intOrStringValues.Has(name)
+}
+
+// ForType returns a structural schema describing the json representation of
+// the type.  The following are described as open objects, i.e. objects whose
+// fields are not described:
+//   - HelmValuesType, which is passed through to the charts unmodified
+//   - structs that are not defined in the maistra API, e.g. the Kubernetes API
+//     types, which are validated by the API server when they are rendered
+//   - GatewaysConfig, whose gateways are keyed by name
+//   - the component specific overrides of the global values, which are
+//     described once, by IstioHelmValues.Global
+//
+// String values are described as strings, except for the helm values that are
+// commonly specified as numbers, which are described as integer or string.
+//
+// The Kubernetes schema extensions cannot be represented by the vendored
+// JSONSchemaProps, so open objects are represented as objects without
+// properties and integer or string values as anyOf integer or string.  Use
+// ToUnstructured to add the extensions.
+func ForType(t reflect.Type) *apiextensionsv1beta1.JSONSchemaProps {
+	return forType(t, map[reflect.Type]bool{})
+}
+
+func forType(t reflect.Type, visiting map[reflect.Type]bool) *apiextensionsv1beta1.JSONSchemaProps {
+	t = indirect(t)
+	switch t {
+	case helmValuesType, gatewaysConfigType:
+		return openObject()
+	case intOrStringType, quantityType:
+		return intOrString()
+	case timeType:
+		return &apiextensionsv1beta1.JSONSchemaProps{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if !strings.HasPrefix(t.PkgPath(), maistraAPIPath+"/") || visiting[t] {
+			return openObject()
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+		schema := &apiextensionsv1beta1.JSONSchemaProps{
+			Type:       "object",
+			Properties: map[string]apiextensionsv1beta1.JSONSchemaProps{},
+		}
+		addProperties(schema, t, visiting)
+		return schema
+	case reflect.Map:
+		return &apiextensionsv1beta1.JSONSchemaProps{
+			Type: "object",
+			AdditionalProperties: &apiextensionsv1beta1.JSONSchemaPropsOrBool{
+				Allows: true,
+				Schema: forType(t.Elem(), visiting),
+			},
+		}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// []byte is encoded as a base64 string
+			return &apiextensionsv1beta1.JSONSchemaProps{Type: "string", Format: "byte"}
+		}
+		return &apiextensionsv1beta1.JSONSchemaProps{
+			Type: "array",
+			Items: &apiextensionsv1beta1.JSONSchemaPropsOrArray{
+				Schema: forType(t.Elem(), visiting),
+			},
+		}
+	case reflect.String:
+		return &apiextensionsv1beta1.JSONSchemaProps{Type: "string"}
+	case reflect.Bool:
+		return &apiextensionsv1beta1.JSONSchemaProps{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &apiextensionsv1beta1.JSONSchemaProps{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &apiextensionsv1beta1.JSONSchemaProps{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &apiextensionsv1beta1.JSONSchemaProps{Type: "number"}
+	}
+	// interfaces and anything else may hold any value
+	return openObject()
+}
+
+// addProperties adds the fields of the struct to the schema's properties.  The
+// fields of inlined structs are added to the same schema.
+func addProperties(schema *apiextensionsv1beta1.JSONSchemaProps, t reflect.Type, visiting map[reflect.Type]bool) {
+	for index := 0; index < t.NumField(); index++ {
+		field := t.Field(index)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if field.Anonymous && name == "" {
+			embeddedType := indirect(field.Type)
+			if embeddedType.Kind() == reflect.Struct {
+				addProperties(schema, embeddedType, visiting)
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			// unexported
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if t != istioValuesType && indirect(field.Type) == globalConfigType {
+			schema.Properties[name] = *openObject()
+			continue
+		}
+		if IsIntOrStringValue(name) {
+			schema.Properties[name] = *forIntOrStringValue(field.Type, visiting)
+			continue
+		}
+		schema.Properties[name] = *forType(field.Type, visiting)
+	}
+}
+
+// forIntOrStringValue returns the schema of a value that accepts integers in
+// place of strings
+func forIntOrStringValue(t reflect.Type, visiting map[reflect.Type]bool) *apiextensionsv1beta1.JSONSchemaProps {
+	t = indirect(t)
+	switch {
+	case t.Kind() == reflect.String:
+		return intOrString()
+	case t.Kind() == reflect.Map && indirect(t.Elem()).Kind() == reflect.String:
+		return &apiextensionsv1beta1.JSONSchemaProps{
+			Type: "object",
+			AdditionalProperties: &apiextensionsv1beta1.JSONSchemaPropsOrBool{
+				Allows: true,
+				Schema: intOrString(),
+			},
+		}
+	}
+	return forType(t, visiting)
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func openObject() *apiextensionsv1beta1.JSONSchemaProps {
+	return &apiextensionsv1beta1.JSONSchemaProps{Type: "object"}
+}
+
+func intOrString() *apiextensionsv1beta1.JSONSchemaProps {
+	return &apiextensionsv1beta1.JSONSchemaProps{
+		AnyOf: []apiextensionsv1beta1.JSONSchemaProps{
+			{Type: "integer"},
+			{Type: "string"},
+		},
+	}
+}
+
+// ToUnstructured converts the schema to its unstructured representation,
+// adding x-kubernetes-preserve-unknown-fields to open objects and
+// x-kubernetes-int-or-string to integer or string values.  The properties at
+// the specified paths, e.g. spec.istio, hold helm values, which are described
+// by their structure only, see helmValues().  An empty path specifies the
+// root of the schema.  The schema should not have been modified by
+// RemoveTypeObjectFieldsFromCRDSchema.
+func ToUnstructured(schema *apiextensionsv1beta1.JSONSchemaProps, helmValuesPaths ...string) (map[string]interface{}, error) {
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	unstructuredSchema := map[string]interface{}{}
+	if err := json.Unmarshal(data, &unstructuredSchema); err != nil {
+		return nil, err
+	}
+	addExtensions(unstructuredSchema)
+	for _, helmValuesPath := range helmValuesPaths {
+		if property := unstructuredPropertyAt(unstructuredSchema, helmValuesPath); property != nil {
+			helmValues(property)
+		}
+	}
+	return unstructuredSchema, nil
+}
+
+func addExtensions(schema map[string]interface{}) {
+	if isOpenObject(schema) {
+		schema[preserveUnknownFieldsExtension] = true
+	} else if isIntOrString(schema) {
+		schema[intOrStringExtension] = true
+	}
+	forEachChild(schema, addExtensions)
+}
+
+// helmValues describes the structure of helm values without restricting the
+// values themselves.  Templates may replace any value with a $patch
+// directive, e.g. {"$patch": "delete"} or a list starting with
+// {"$patch": "append"}, and the API server would reject those if the schema
+// specified the type of the value.  Objects keep their properties, so they
+// are documented, but their unknown fields are preserved, so the directives
+// and any settings that are not modelled reach the validating webhook, which
+// validates the values against the version of the control plane.  The
+// values of maps and everything that is not an object, i.e. scalars and
+// lists, are only described.
+func helmValues(schema map[string]interface{}) {
+	if schema["type"] != "object" {
+		description := schema["description"]
+		for key := range schema {
+			delete(schema, key)
+		}
+		if description != nil {
+			schema["description"] = description
+		}
+		schema[preserveUnknownFieldsExtension] = true
+		return
+	}
+	if _, isMap := schema["additionalProperties"]; !isMap {
+		schema[preserveUnknownFieldsExtension] = true
+	}
+	forEachChild(schema, helmValues)
+}
+
+// forEachChild calls f for the schemas of the properties, items and
+// additional properties of the schema
+func forEachChild(schema map[string]interface{}, f func(map[string]interface{})) {
+	if properties, ok := schema["properties"].(map[string]interface{}); ok {
+		for _, property := range properties {
+			if propertySchema, ok := property.(map[string]interface{}); ok {
+				f(propertySchema)
+			}
+		}
+	}
+	for _, key := range []string{"items", "additionalProperties"} {
+		if child, ok := schema[key].(map[string]interface{}); ok {
+			f(child)
+		}
+	}
+}
+
+// unstructuredPropertyAt returns the schema of the property at the path,
+// which is a list of property names separated by dots, or nil if the schema
+// does not describe it
+func unstructuredPropertyAt(schema map[string]interface{}, path string) map[string]interface{} {
+	if path == "" {
+		return schema
+	}
+	for _, name := range strings.Split(path, ".") {
+		properties, ok := schema["properties"].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		if schema, ok = properties[name].(map[string]interface{}); !ok {
+			return nil
+		}
+	}
+	return schema
+}
+
+func isOpenObject(schema map[string]interface{}) bool {
+	if schema["type"] != "object" {
+		return false
+	}
+	_, hasProperties := schema["properties"]
+	_, hasAdditionalProperties := schema["additionalProperties"]
+	return !hasProperties && !hasAdditionalProperties
+}
+
+func isIntOrString(schema map[string]interface{}) bool {
+	anyOf, ok := schema["anyOf"].([]interface{})
+	if !ok || len(anyOf) != 2 || schema["type"] != nil {
+		return false
+	}
+	types := map[interface{}]bool{}
+	for _, item := range anyOf {
+		if itemSchema, ok := item.(map[string]interface{}); ok {
+			types[itemSchema["type"]] = true
+		}
+	}
+	return types["integer"] && types["string"]
+}
@@ -0,0 +1,301 @@
+package openapi
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+
+	"github.com/maistra/istio-operator/pkg/apis/maistra"
+	maistrav1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+	"github.com/maistra/istio-operator/pkg/controller/common/test/assert"
+	"github.com/maistra/istio-operator/pkg/controller/hacks"
+)
+
+func TestIstioValuesSchema(t *testing.T) {
+	schema := ForType(reflect.TypeOf(maistrav1.IstioHelmValues{}))
+	cases := []struct {
+		path         string
+		expectedType string
+		open         bool
+	}{
+		{path: "pilot.autoscaleEnabled", expectedType: "boolean"},
+		{path: "pilot.replicaCount", expectedType: "integer"},
+		{path: "pilot.image", expectedType: "string"},
+		{path: "pilot.rollingMaxSurge"},
+		{path: "tracing.zipkin.tag"},
+		{path: "pilot.env", expectedType: "object"},
+		{path: "pilot.global", expectedType: "object", open: true},
+		{path: "pilot.resources", expectedType: "object", open: true},
+		{path: "pilot.tolerations", expectedType: "array"},
+		{path: "global.proxy.autoInject", expectedType: "string"},
+		{path: "global.proxy.includeInboundPorts"},
+		{path: "gateways", expectedType: "object", open: true},
+		{path: "grafana", expectedType: "object", open: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.path, func(t *testing.T) {
+			property := propertyAt(schema, tc.path)
+			if property == nil {
+				t.Fatalf("expected schema to describe %s", tc.path)
+			}
+			assert.Equals(property.Type, tc.expectedType, "Unexpected type", t)
+			if tc.expectedType == "" && len(property.AnyOf) != 2 {
+				t.Errorf("expected %s to be described as integer or string: %v", tc.path, property.AnyOf)
+			}
+			open := property.Type == "object" && len(property.Properties) == 0 && property.AdditionalProperties == nil
+			assert.Equals(open, tc.open, "Unexpected open object", t)
+		})
+	}
+}
+
+func TestIntOrStringMapValuesSchema(t *testing.T) {
+	schema := ForType(reflect.TypeOf(maistrav1.IstioHelmValues{}))
+	env := propertyAt(schema, "pilot.env")
+	if env == nil || env.AdditionalProperties == nil || env.AdditionalProperties.Schema == nil {
+		t.Fatalf("expected schema to describe the values of pilot.env: %v", env)
+	}
+	assert.Equals(len(env.AdditionalProperties.Schema.AnyOf), 2, "Expected env values to be described as integer or string", t)
+	nodeSelector := propertyAt(schema, "pilot.nodeSelector")
+	if nodeSelector == nil || nodeSelector.AdditionalProperties == nil || nodeSelector.AdditionalProperties.Schema == nil {
+		t.Fatalf("expected schema to describe the values of pilot.nodeSelector: %v", nodeSelector)
+	}
+	assert.Equals(nodeSelector.AdditionalProperties.Schema.Type, "string", "Expected node selector values to be described as strings", t)
+}
+
+func TestIstioValuesSchemaPerVersion(t *testing.T) {
+	cases := []struct {
+		version    maistra.Version
+		present    []string
+		notPresent []string
+	}{
+		{
+			version:    maistra.V1_0,
+			present:    []string{"servicegraph", "global.sds.useTrustworthyJwt"},
+			notPresent: []string{"kiali.jaegerInClusterURL", "global.proxy.envoyAccessLogService", "galley.tolerations", "pilot.rollingMaxSurge"},
+		},
+		{
+			version:    maistra.V1_1,
+			present:    []string{"kiali.jaegerInClusterURL", "global.proxy.envoyAccessLogService", "galley.tolerations", "pilot.rollingMaxSurge"},
+			notPresent: []string{"servicegraph", "global.sds.useTrustworthyJwt"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.version.String(), func(t *testing.T) {
+			schema := IstioValues(tc.version)
+			for _, path := range tc.present {
+				if propertyAt(schema, path) == nil {
+					t.Errorf("expected schema to describe %s", path)
+				}
+			}
+			for _, path := range tc.notPresent {
+				if propertyAt(schema, path) != nil {
+					t.Errorf("expected schema not to describe %s", path)
+				}
+			}
+		})
+	}
+}
+
+func TestControlPlaneSchemaIsStructural(t *testing.T) {
+	schema, err := ToUnstructured(ControlPlaneValidation().OpenAPIV3Schema)
+	if err != nil {
+		t.Fatalf("unexpected error converting schema: %v", err)
+	}
+	assertStructural(t, "", schema)
+	assert.Equals(nestedValue(schema, "properties.spec.properties.istio.properties.grafana."+preserveUnknownFieldsExtension), true, "Expected unmodelled values to be preserved", t)
+	assert.Equals(nestedValue(schema, "properties.spec.properties.istio.properties.pilot.properties.image.type"), "string", "Expected strings to be described as strings", t)
+	assert.Equals(nestedValue(schema, "properties.spec.properties.istio.properties.pilot.properties.image."+intOrStringExtension), nil, "Expected strings not to be described as integer or string", t)
+	assert.Equals(nestedValue(schema, "properties.spec.properties.istio.properties.pilot.properties.image.anyOf"), nil, "Expected strings not to be described as integer or string", t)
+	assert.Equals(nestedValue(schema, "properties.spec.properties.istio.properties.tracing.properties.zipkin.properties.tag."+intOrStringExtension), true, "Expected image tags to be described as integer or string", t)
+	assert.Equals(nestedValue(schema, "properties.spec.properties.version.type"), "string", "Expected spec.version to be described as a string", t)
+	assert.Equals(nestedValue(schema, "properties.spec.properties.template.type"), "string", "Expected spec.template to be described as a string", t)
+	assert.Equals(nestedValue(schema, "properties.spec."+preserveUnknownFieldsExtension), nil, "Expected unknown fields of the spec to be pruned", t)
+}
+
+func TestHelmValuesAreDescribedByStructureOnly(t *testing.T) {
+	schema, err := ToUnstructured(ControlPlaneValidation().OpenAPIV3Schema, HelmValuesPaths...)
+	if err != nil {
+		t.Fatalf("unexpected error converting schema: %v", err)
+	}
+	assertStructural(t, "", schema)
+	for _, path := range []string{
+		"properties.spec.properties.istio",
+		"properties.spec.properties.istio.properties.pilot",
+		"properties.spec.properties.istio.properties.global.properties.proxy",
+		"properties.spec.properties.threeScale",
+	} {
+		assert.Equals(nestedValue(schema, path+".type"), "object", "Expected "+path+" to be described as an object", t)
+		assert.Equals(nestedValue(schema, path+"."+preserveUnknownFieldsExtension), true, "Expected unknown fields of "+path+" to be preserved", t)
+	}
+	for _, path := range []string{
+		"properties.spec.properties.istio.properties.pilot.properties.image",
+		"properties.spec.properties.istio.properties.pilot.properties.tolerations",
+		"properties.spec.properties.istio.properties.tracing.properties.zipkin.properties.tag",
+		"properties.spec.properties.istio.properties.pilot.properties.env.additionalProperties",
+	} {
+		assert.Equals(nestedValue(schema, path+".type"), nil, "Expected type of "+path+" not to be specified", t)
+		assert.Equals(nestedValue(schema, path+"."+preserveUnknownFieldsExtension), true, "Expected "+path+" to accept any value", t)
+	}
+	assert.Equals(nestedValue(schema, "properties.spec.properties.istio.properties.pilot.properties.env."+preserveUnknownFieldsExtension), nil, "Expected maps not to be marked", t)
+	assert.Equals(nestedValue(schema, "properties.spec."+preserveUnknownFieldsExtension), nil, "Expected unknown fields of the spec to be pruned", t)
+	assert.Equals(nestedValue(schema, "properties.spec.properties.version.type"), "string", "Expected fields outside of the helm values to be typed", t)
+}
+
+func TestHelmValuesSchemaAcceptsPatchDirectives(t *testing.T) {
+	schema, err := ToUnstructured(ControlPlaneValidation().OpenAPIV3Schema, HelmValuesPaths...)
+	if err != nil {
+		t.Fatalf("unexpected error converting schema: %v", err)
+	}
+	istio := map[string]interface{}{
+		"global": map[string]interface{}{
+			"hub":              map[string]interface{}{"$patch": "delete"},
+			"imagePullSecrets": []interface{}{map[string]interface{}{"$patch": "append"}, "my-secret"},
+		},
+		"pilot": map[string]interface{}{
+			"env":          map[string]interface{}{"$patch": "replace", "GODEBUG": map[string]interface{}{"$patch": "delete"}},
+			"replicaCount": map[string]interface{}{"$patch": "delete"},
+		},
+		"gateways": map[string]interface{}{
+			"istio-egressgateway": map[string]interface{}{"$patch": "delete"},
+		},
+	}
+	spec := map[string]interface{}{
+		"istio":      istio,
+		"threeScale": map[string]interface{}{"$patch": "replace", "enabled": true},
+	}
+	if err := validateUnstructured("spec", nestedValue(schema, "properties.spec").(map[string]interface{}), spec); err != nil {
+		t.Errorf("expected $patch directives to be accepted: %v", err)
+	}
+	if err := validateUnstructured("spec", nestedValue(schema, "properties.spec").(map[string]interface{}), map[string]interface{}{"version": map[string]interface{}{"$patch": "delete"}}); err == nil {
+		t.Errorf("expected fields outside of the helm values to be validated")
+	}
+}
+
+func TestControlPlaneV2SchemaIsStructural(t *testing.T) {
+	schema, err := ToUnstructured(ControlPlaneV2Validation().OpenAPIV3Schema)
+	if err != nil {
+		t.Fatalf("unexpected error converting schema: %v", err)
+	}
+	assertStructural(t, "", schema)
+	assert.Equals(nestedValue(schema, "properties.spec.properties.security.properties.dataPlane.properties.autoMtls.type"), "boolean", "Expected typed fields to be described", t)
+	assert.Equals(nestedValue(schema, "properties.spec.properties.runtime.properties.components.additionalProperties.properties.replicaCount.type"), "integer", "Expected component runtime to be described", t)
+	assert.Equals(nestedValue(schema, "properties.spec.properties.techPreview."+preserveUnknownFieldsExtension), true, "Expected techPreview values to be preserved", t)
+}
+
+func TestControlPlaneSchemaWithoutTypeObjectFields(t *testing.T) {
+	crd := &apiextensionsv1beta1.CustomResourceDefinition{
+		Spec: apiextensionsv1beta1.CustomResourceDefinitionSpec{
+			Validation: ControlPlaneValidation(),
+		},
+	}
+	if err := hacks.RemoveTypeObjectFieldsFromCRDSchema(context.Background(), crd); err != nil {
+		t.Fatalf("unexpected error removing type:object fields: %v", err)
+	}
+	root := crd.Spec.Validation.OpenAPIV3Schema
+	assert.Equals(root.Type, "", "Expected type to be removed from the root of the schema", t)
+	grafana := propertyAt(root, "spec.istio.grafana")
+	if grafana == nil || !reflect.DeepEqual(*grafana, apiextensionsv1beta1.JSONSchemaProps{}) {
+		t.Errorf("expected open objects to accept any value: %v", grafana)
+	}
+	autoscaleEnabled := propertyAt(root, "spec.istio.pilot.autoscaleEnabled")
+	if autoscaleEnabled == nil || autoscaleEnabled.Type != "boolean" {
+		t.Errorf("expected other types to be retained: %v", autoscaleEnabled)
+	}
+}
+
+// assertStructural verifies that every node of the schema specifies a type,
+// unless it is marked as integer or string or as accepting any value, and that no node specifies both
+// properties and additionalProperties
+func assertStructural(t *testing.T, path string, schema map[string]interface{}) {
+	t.Helper()
+	if schema["type"] == nil && schema[intOrStringExtension] != true && schema[preserveUnknownFieldsExtension] != true {
+		t.Errorf("%s: no type specified", path)
+	}
+	properties, hasProperties := schema["properties"].(map[string]interface{})
+	if _, hasAdditionalProperties := schema["additionalProperties"]; hasProperties && hasAdditionalProperties {
+		t.Errorf("%s: both properties and additionalProperties specified", path)
+	}
+	for name, property := range properties {
+		assertStructural(t, path+"."+name, property.(map[string]interface{}))
+	}
+	for _, key := range []string{"items", "additionalProperties"} {
+		if child, ok := schema[key].(map[string]interface{}); ok {
+			assertStructural(t, path+"."+key, child)
+		}
+	}
+}
+
+func propertyAt(schema *apiextensionsv1beta1.JSONSchemaProps, path string) *apiextensionsv1beta1.JSONSchemaProps {
+	for _, name := range strings.Split(path, ".") {
+		property, ok := schema.Properties[name]
+		if !ok {
+			return nil
+		}
+		schema = &property
+	}
+	return schema
+}
+
+func nestedValue(schema map[string]interface{}, path string) interface{} {
+	var value interface{} = schema
+	for _, key := range strings.Split(path, ".") {
+		valueAsMap, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = valueAsMap[key]
+	}
+	return value
+}
+
+// validateUnstructured verifies the type of the value and of the values it
+// contains against the schema, as far as the schema specifies them
+func validateUnstructured(path string, schema map[string]interface{}, value interface{}) error {
+	if schema["type"] == nil {
+		// integer or string values are not used by the tests
+		return nil
+	}
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		if schema["type"] != "object" {
+			return fmt.Errorf("%s: expected %s, got object", path, schema["type"])
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		additionalProperties, _ := schema["additionalProperties"].(map[string]interface{})
+		for name, fieldValue := range typedValue {
+			if property, ok := properties[name].(map[string]interface{}); ok {
+				if err := validateUnstructured(path+"."+name, property, fieldValue); err != nil {
+					return err
+				}
+			} else if additionalProperties != nil {
+				if err := validateUnstructured(path+"."+name, additionalProperties, fieldValue); err != nil {
+					return err
+				}
+			}
+		}
+	case []interface{}:
+		if schema["type"] != "array" {
+			return fmt.Errorf("%s: expected %s, got array", path, schema["type"])
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for index, item := range typedValue {
+				if err := validateUnstructured(fmt.Sprintf("%s[%d]", path, index), items, item); err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		if schema["type"] != "string" {
+			return fmt.Errorf("%s: expected %s, got string", path, schema["type"])
+		}
+	case bool:
+		if schema["type"] != "boolean" {
+			return fmt.Errorf("%s: expected %s, got boolean", path, schema["type"])
+		}
+	}
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
 	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -51,30 +52,295 @@ type ControlPlaneStatus struct {
 
 	//LastAppliedConfiguration lists the last appllied ServiceMeshControlPlane
 	LastAppliedConfiguration ControlPlaneSpec `json:"lastAppliedConfiguration"`
+
+	// ResolvedVersion is the release of the charts and templates the version
+	// of the last applied configuration resolved to, e.g. v1.1.3 for a
+	// version of v1.1 or ~1.1.
+	// +optional
+	ResolvedVersion string `json:"resolvedVersion,omitempty"`
+
+	// AvailableVersion is the newer version the control plane can be
+	// upgraded to, if any.  See the UpgradeAvailable condition for details.
+	// +optional
+	AvailableVersion string `json:"availableVersion,omitempty"`
+
+	// AppliedTemplates lists the templates that were applied to produce the
+	// last applied configuration, including the templates they inherit from,
+	// in the order in which they were processed.
+	// +optional
+	AppliedTemplates []AppliedTemplate `json:"appliedTemplates,omitempty"`
+
+	// ValueSources maps the path of each value in the last applied
+	// configuration that was supplied by a template, e.g.
+	// istio.global.proxy, to the name of that template.  Values specified in
+	// the ServiceMeshControlPlane itself are not listed.
+	// +optional
+	ValueSources map[string]string `json:"valueSources,omitempty"`
+
+	// LastKnownGoodConfiguration is the last configuration that was applied
+	// successfully.  It is only recorded when a rollback policy is specified
+	// and is used to roll back failed updates.
+	// +optional
+	LastKnownGoodConfiguration *ControlPlaneSpec `json:"lastKnownGoodConfiguration,omitempty"`
+
+	// ConfigurationRevision is the number of the configuration revision that
+	// was last applied successfully.
+	// +optional
+	ConfigurationRevision int64 `json:"configurationRevision,omitempty"`
+
+	// ReconcileErrors is the number of consecutive errors that have occurred
+	// while reconciling the current generation.
+	// +optional
+	ReconcileErrors int32 `json:"reconcileErrors,omitempty"`
+
+	// Plan lists the changes that would be made to the cluster if the
+	// ServiceMeshControlPlane were reconciled.  It is only populated when the
+	// ServiceMeshControlPlane is annotated with maistra.io/dry-run=true.
+	// +optional
+	Plan *ControlPlanePlan `json:"plan,omitempty"`
+
+	// OverlayErrors lists the overlays in the last applied configuration that
+	// did not match any rendered resource.  Errors applying an overlay are
+	// reported by the Reconciled condition.
+	// +optional
+	OverlayErrors []OverlayError `json:"overlayErrors,omitempty"`
+
+	// Inventory lists the resources that have been applied for the control
+	// plane, in the order in which they were applied.  Resources that are no
+	// longer rendered are pruned once the control plane has been reconciled
+	// successfully.
+	// +optional
+	Inventory []ResourceKey `json:"inventory,omitempty"`
+}
+
+// OverlayError describes an overlay that could not be applied
+type OverlayError struct {
+	// Index of the overlay in the overlays of the last applied configuration
+	Index int `json:"index"`
+	// Kind of the resource targeted by the overlay
+	Kind string `json:"kind"`
+	// Name of the resource targeted by the overlay
+	Name string `json:"name"`
+	// Message describes the error
+	Message string `json:"message"`
+}
+
+// ControlPlanePlan represents the changes that reconciling a specific
+// generation of a ServiceMeshControlPlane would make to the cluster.
+type ControlPlanePlan struct {
+	// ObservedGeneration is the generation of the ServiceMeshControlPlane for
+	// which the plan was computed.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Changes lists the resources that would be created, updated, recreated or
+	// deleted.  Resources that are already up to date are not listed.
+	Changes []PlannedChange `json:"changes,omitempty"`
 }
 
+// PlannedChange represents a change to a single resource
+type PlannedChange struct {
+	// Resource is the key of the affected resource, e.g.
+	// istio-system/istio-pilot=apps/v1,Kind=Deployment
+	Resource string `json:"resource"`
+	// Action is the type of change that would be made to the resource
+	Action PlannedAction `json:"action"`
+	// ImmutableFields lists the immutable fields whose modification would
+	// require the resource to be recreated.
+	ImmutableFields []string `json:"immutableFields,omitempty"`
+}
+
+// PlannedAction represents the type of change that would be made to a resource
+type PlannedAction string
+
+const (
+	// PlannedActionCreate means the resource does not exist and would be created
+	PlannedActionCreate PlannedAction = "Create"
+	// PlannedActionUpdate means the resource would be patched in place
+	PlannedActionUpdate PlannedAction = "Update"
+	// PlannedActionRecreate means the resource would have to be deleted and
+	// created again, because the change modifies immutable fields
+	PlannedActionRecreate PlannedAction = "Recreate"
+	// PlannedActionDelete means the resource is no longer rendered and would
+	// be pruned
+	PlannedActionDelete PlannedAction = "Delete"
+)
+
 // HelmValuesType is typedef for Helm .Values
 type HelmValuesType map[string]interface{}
 
 // ControlPlaneSpec represents the configuration for installing a control plane
 type ControlPlaneSpec struct {
 	// Template selects the template to use for default values. Defaults to
-	// "default" when not set.
+	// "default" when neither Template nor Templates is set.
 	Template string `json:"template,omitempty"`
 
+	// Templates lists the templates to use for default values, in the order
+	// in which they are applied, instead of Template.  Only one of Template
+	// and Templates may be set.  Values in later templates take precedence
+	// over values in earlier templates, and values specified in this resource
+	// take precedence over values in all templates.  A map value of {"$patch": "delete"} removes an
+	// inherited key, {"$patch": "replace"} replaces an inherited map instead
+	// of merging it, and a list containing {"$patch": "append"} is appended
+	// to the inherited list instead of replacing it.
+	// +optional
+	Templates []string `json:"templates,omitempty"`
+
 	// Version specifies what Maistra version of the control plane to install.
 	// When creating a new ServiceMeshControlPlane with an empty version, the
 	// admission webhook sets the version to the current version.
 	// Existing ServiceMeshControlPlanes with an empty version are treated as
-	// having the version set to "v1.0"
+	// having the version set to "v1.0".  A version, e.g. v1.1, installs its
+	// newest patch release shipped with the operator.  A patch release, e.g.
+	// v1.1.3, or a range, e.g. ~1.1 or ">=1.1, <1.3", may be specified
+	// instead.  Ranges install the newest release they contain.
 	Version string `json:"version,omitempty"`
 
+	// UpgradePolicy determines whether the control plane is upgraded
+	// automatically when the operator ships a newer version.  Upgrades that
+	// are not automatic are reported in the UpgradeAvailable condition and
+	// must be approved by annotating the ServiceMeshControlPlane with
+	// maistra.io/approve-upgrade=<version>.  Defaults to Manual.
+	// +optional
+	UpgradePolicy UpgradePolicyType `json:"upgradePolicy,omitempty"`
+
+	// RollbackPolicy enables automatic rollback of failed updates to the last
+	// known good configuration.  Failed updates are not rolled back when not
+	// set.
+	// +optional
+	RollbackPolicy *RollbackPolicy `json:"rollbackPolicy,omitempty"`
+
+	// RevisionHistoryLimit is the number of configuration revisions to keep.
+	// Defaults to 10.
+	// +optional
+	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
+
+	// RollbackTo selects a configuration revision to apply instead of the
+	// configuration specified by this resource.  Remove it to resume applying
+	// the configuration specified by this resource.
+	// +optional
+	RollbackTo *RollbackConfig `json:"rollbackTo,omitempty"`
+
+	// Overlays lists patches that are applied to the resources rendered from
+	// the charts before they are created or updated.  Overlays are applied in
+	// order, after the overlays inherited from templates.
+	// +optional
+	Overlays []ResourceOverlay `json:"overlays,omitempty"`
+
+	// DeletionPolicy determines whether the resources created for the control
+	// plane are deleted or retained when the control plane is deleted.  All
+	// resources are deleted when not set.
+	// +optional
+	DeletionPolicy *DeletionPolicy `json:"deletionPolicy,omitempty"`
+
 	// NetworkType of the cluster.  Defaults to subnet.
 	NetworkType NetworkType    `json:"networkType,omitempty"`
 	Istio       HelmValuesType `json:"istio,omitempty"`
 	ThreeScale  HelmValuesType `json:"threeScale,omitempty"`
 }
 
+// UpgradePolicyType determines which upgrades of a control plane are
+// applied automatically
+type UpgradePolicyType string
+
+const (
+	// UpgradePolicyManual applies no upgrades automatically
+	UpgradePolicyManual UpgradePolicyType = "Manual"
+	// UpgradePolicyAutomaticPatch applies upgrades to newer patch releases of
+	// the version automatically, e.g. from v1.1.2 to v1.1.3
+	UpgradePolicyAutomaticPatch UpgradePolicyType = "AutomaticPatch"
+	// UpgradePolicyAutomaticMinor applies all upgrades automatically,
+	// including upgrades to newer versions, e.g. from v1.1 to v1.2
+	UpgradePolicyAutomaticMinor UpgradePolicyType = "AutomaticMinor"
+)
+
+// DeletionPolicy determines what happens to the resources created for a
+// control plane when the control plane is deleted
+type DeletionPolicy struct {
+	// Default is the policy for resources whose kind is not listed in Kinds.
+	// Defaults to Delete.
+	// +optional
+	Default DeletionPolicyType `json:"default,omitempty"`
+	// Kinds overrides the default policy for resources of the listed kinds,
+	// e.g. {"PersistentVolumeClaim": "Retain", "Secret": "Retain"}
+	// +optional
+	Kinds map[string]DeletionPolicyType `json:"kinds,omitempty"`
+}
+
+// DeletionPolicyType is the policy applied to a resource when the control
+// plane is deleted
+type DeletionPolicyType string
+
+const (
+	// DeletionPolicyDelete deletes the resource
+	DeletionPolicyDelete DeletionPolicyType = "Delete"
+	// DeletionPolicyRetain releases the resource from the control plane,
+	// removing its owner labels and references, so it survives the control
+	// plane and can be adopted by a control plane created later.
+	DeletionPolicyRetain DeletionPolicyType = "Retain"
+)
+
+// PolicyFor returns the policy that applies to resources of the specified
+// kind
+func (p *DeletionPolicy) PolicyFor(kind string) DeletionPolicyType {
+	if p == nil {
+		return DeletionPolicyDelete
+	}
+	if policy, ok := p.Kinds[kind]; ok && policy != "" {
+		return policy
+	}
+	if p.Default == "" {
+		return DeletionPolicyDelete
+	}
+	return p.Default
+}
+
+// ResourceOverlay is a patch applied to a rendered resource
+type ResourceOverlay struct {
+	// APIVersion of the resource to patch, e.g. apps/v1.  Resources of any
+	// version match when not set.
+	// +optional
+	APIVersion string `json:"apiVersion,omitempty"`
+	// Kind of the resource to patch, e.g. Deployment
+	Kind string `json:"kind"`
+	// Name of the resource to patch
+	Name string `json:"name"`
+	// Type of the patch.  Defaults to strategic.
+	// +optional
+	Type OverlayPatchType `json:"type,omitempty"`
+	// Patch is the patch to apply, in JSON or YAML
+	Patch string `json:"patch"`
+}
+
+// OverlayPatchType is the type of patch specified by an overlay
+type OverlayPatchType string
+
+const (
+	// OverlayPatchTypeStrategic is a strategic merge patch.  A JSON merge
+	// patch is applied to resources whose types do not support strategic
+	// merge patches, e.g. custom resources.
+	OverlayPatchTypeStrategic OverlayPatchType = "strategic"
+	// OverlayPatchTypeJSON is a JSON patch (RFC 6902)
+	OverlayPatchTypeJSON OverlayPatchType = "json"
+)
+
+// RollbackPolicy specifies when a failed update should be rolled back.  An
+// update is rolled back when either of the limits is exceeded.
+type RollbackPolicy struct {
+	// ProgressDeadlineSeconds is the number of seconds an update may take to
+	// complete, e.g. while waiting for a component to become ready, before it
+	// is rolled back.  No deadline is enforced when zero.
+	ProgressDeadlineSeconds int32 `json:"progressDeadlineSeconds,omitempty"`
+	// MaxReconcileErrors is the number of consecutive reconcile errors after
+	// which the update is rolled back.  Errors are not counted when zero.
+	MaxReconcileErrors int32 `json:"maxReconcileErrors,omitempty"`
+}
+
+// RollbackConfig identifies a configuration revision to roll back to
+type RollbackConfig struct {
+	// Revision is the number of the configuration revision, as listed in
+	// the ControllerRevision resources recorded for the control plane.
+	Revision int64 `json:"revision"`
+}
+
 // NetworkType is type definition representing the network type of the cluster
 type NetworkType string
 
@@ -105,6 +371,13 @@ type IstioHelmValues struct {
 	SidecarInjector *SidecarInjectorConfig `json:"sidecarInjectorWebhook,omitempty"`
 	Tracing         *TracingConfig         `json:"tracing,omitempty"`
 	Kiali           *KialiConfig           `json:"kiali,omitempty"`
+
+	// The following charts are configured using their helm values directly
+	CertManager  HelmValuesType `json:"certmanager,omitempty"`
+	IstioCNI     HelmValuesType `json:"istio_cni,omitempty"`
+	IstioCoreDNS HelmValuesType `json:"istiocoredns,omitempty"`
+	NodeAgent    HelmValuesType `json:"nodeagent,omitempty"`
+	ServiceGraph HelmValuesType `json:"servicegraph,omitempty"`
 }
 
 // Globals
@@ -116,6 +389,9 @@ type GlobalConfig struct {
 	// key is the arch type, value is preference: 0-never scheduled,
 	// 1-least preferred, 2-no preference, 3-most preferred
 	Arch map[string]int32 `json:"arch,omitempty"`
+	// Certificates are DNS certificates provisioned through Chiron linked into
+	// Pilot.
+	Certificates []HelmValuesType `json:"certificates,omitempty"`
 	// ConfigRootNamespace is the namespace to use for locating control plane
 	// configuration, like sidecar configuration, DestinationRules applying to
 	// the entire mesh, etc. Example, istio-config
@@ -164,9 +440,15 @@ type GlobalConfig struct {
 	// DefaultResources are default resource requirements to be applied to Istio
 	// pods.
 	DefaultResources *corev1.ResourceRequirements `json:"defaultResources,omitempty"`
+	// DefaultTolerations are the tolerations to be applied to Istio pods, if
+	// not specified for the component.
+	DefaultTolerations []corev1.Toleration `json:"defaultTolerations,omitempty"`
 	// DisablePolicyChecks specifies whether or not Mixer policy checks should
 	// be enabled.  Defaults to false.
 	DisablePolicyChecks *bool `json:"disablePolicyChecks,omitempty"`
+	// EnableHelmTest specifies whether or not the helm test templates are
+	// rendered.  Defaults to false.
+	EnableHelmTest *bool `json:"enableHelmTest,omitempty"`
 	// EnableTracing enables tracing for Istio.  Components relying on the istio
 	// ConfigMap must be restarted, e.g. Pilot.  Defaults to true.
 	EnableTracing *bool `json:"enableTracing,omitempty"`
@@ -194,9 +476,18 @@ type GlobalConfig struct {
 	// Defaults to istio-ingressgateway
 	KubernetesIngressSelector string `json:"k8sIngressSelector,omitempty"`
 
+	// LocalityLbSetting configures locality load balancing.
+	LocalityLbSetting HelmValuesType `json:"localityLbSetting,omitempty"`
+
+	// Logging configures the log level of the control plane components.
+	Logging *LoggingConfig `json:"logging,omitempty"`
+
 	// MeshExpansion represents the configuration for mesh expansion.
 	MeshExpansion *MeshExpansionConfig `json:"meshExpansion,omitempty"`
 
+	// MeshID identifies the mesh.  Defaults to the trust domain.
+	MeshID string `json:"meshID,omitempty"`
+
 	// MeshNetworks configures the mesh networks to be used by the Split Horizon EDS
 	MeshNetworks MeshNetworksType `json:"meshNetworks,omitempty"`
 	// MonitoringPort provided by Istio components.  Defaults to 15014
@@ -211,6 +502,8 @@ type GlobalConfig struct {
 	// associated.  The ISTIO_META_NETWORK environment variable on the sidecars
 	// is set to this value.
 	Network string `json:"network,omitempty"`
+	// OAuthProxy configures the oauth-proxy containers fronting the UIs.
+	OAuthProxy HelmValuesType `json:"oauthproxy,omitempty"`
 	// OmitSidecarInjectorConfigMap specifies whether or not the sidecar
 	// injector ConfigMap should be created.  This should always be false when
 	// installing a control plane.  Defaults to false.
@@ -219,6 +512,11 @@ type GlobalConfig struct {
 	// namespace or all namespaces.  Defaults to false.
 	OneNamespace *bool `json:"oneNamespace,omitempty"`
 
+	// OperatorManageWebhooks specifies whether or not the webhook
+	// configurations are managed by the operator instead of by the webhooks
+	// themselves.  Defaults to false.
+	OperatorManageWebhooks *bool `json:"operatorManageWebhooks,omitempty"`
+
 	// OutboundTrafficPolicy for sidecars.
 	OutboundTrafficPolicy *OutboundTrafficPolicyConfig `json:"outboundTrafficPolicy,omitempty"`
 
@@ -238,6 +536,9 @@ type GlobalConfig struct {
 	// Should default to cluster.local in Kubernetes environments.
 	// TODO: verify the default
 	TrustDomain string `json:"trustDomain,omitempty"`
+	// TrustDomainAliases are additional trust domains that are treated as
+	// equivalent to TrustDomain.
+	TrustDomainAliases []string `json:"trustDomainAliases,omitempty"`
 	// UseMCP specifies whether or not Mesh Control Protocol should be used for
 	// Mixer and Pilot.  Implies the use of Galley if true.  Defaults to true.
 	UseMCP *bool `json:"useMCP,omitempty"`
@@ -254,6 +555,13 @@ type GlobalConfig struct {
 	Tracer *ProxyTracerConfig `json:"tracer,omitempty"`
 }
 
+// LoggingConfig configures logging for the control plane components
+type LoggingConfig struct {
+	// Level is a comma-separated list of scope:level pairs, e.g.
+	// default:info.
+	Level string `json:"level,omitempty"`
+}
+
 // PodDisruptionBudget to apply to Istio pods.  Simply adds an "enabled" field
 // to the standard PodDisruptionBudget
 type PodDisruptionBudget struct {
@@ -294,6 +602,9 @@ type MeshNetworksType map[string]meshv1alpha1.Network
 type MTLSConfig struct {
 	// Enabled specifies whether or not mTLS is enabled.  Defaults to false.
 	EnabledField `json:",inline"`
+	// Auto specifies whether or not mTLS is used automatically when both
+	// sides of a connection support it.  Defaults to false.
+	Auto *bool `json:"auto,omitempty"`
 }
 
 // MultiClusterConfig configures multi-cluster.
@@ -302,6 +613,8 @@ type MultiClusterConfig struct {
 	// enabled, gateways.istio-egressgateway.enabled and gateways.istio-ingressgateway.enabled
 	// should also be set to true. Defaults to false.
 	EnabledField `json:",inline"`
+	// ClusterName is the name of the cluster within the mesh.
+	ClusterName string `json:"clusterName,omitempty"`
 }
 
 // OutboundTrafficPolicyMode is a type alias for OutboundTrafficPolicyMode
@@ -351,16 +664,30 @@ type ProxyConfig struct {
 	AutoInject string `json:"autoInject,omitempty"`
 	// ClusterDomain is the domain for the cluster.  Defaults to cluster.local
 	ClusterDomain string `json:"clusterDomain,omitempty"`
+	// ComponentLogLevel is the per component log level for the proxy, e.g.
+	// misc:error.
+	ComponentLogLevel string `json:"componentLogLevel,omitempty"`
 	// Concurrency controls the number of working threads used by the proxy container.
 	// 0 specifies one thread per core.  Defaults to 0
 	Concurrency *int32 `json:"concurrency,omitempty"`
+	// DNSRefreshRate is the rate at which DNS names are resolved.  Defaults
+	// to 300s
+	DNSRefreshRate string `json:"dnsRefreshRate,omitempty"`
 	// EnableCoreDump specifies whether or not core dumps will be generated if
 	// failures occur on the proxies.  Defaults to false
 	EnableCoreDump *bool `json:"enableCoreDump,omitempty"`
+	// EnableCoreDumpImage is the image used to enable core dumps.
+	EnableCoreDumpImage string `json:"enableCoreDumpImage,omitempty"`
+	// EnvoyAccessLogService configures an Envoy access log service.
+	EnvoyAccessLogService HelmValuesType `json:"envoyAccessLogService,omitempty"`
+	// EnvoyMetricsService configures an Envoy metrics service.
+	EnvoyMetricsService HelmValuesType `json:"envoyMetricsService,omitempty"`
 	// ExcludeInboundPorts represent ports to be blacklisted for ingress.
 	ExcludeInboundPorts string `json:"excludeInboundPorts,omitempty"`
 	// ExcludeIPRanges represent IP ranges to be blacklisted for egress.
 	ExcludeIPRanges string `json:"excludeIPRanges,omitempty"`
+	// ExcludeOutboundPorts represent ports to be blacklisted for egress.
+	ExcludeOutboundPorts string `json:"excludeOutboundPorts,omitempty"`
 	// Image represents the name of the proxy image to use.  Defaults to proxyv2
 	Image string `json:"image,omitempty"`
 	// IncludeInboundPorts represents ports to be whitelisted for ingress.
@@ -368,9 +695,19 @@ type ProxyConfig struct {
 	IncludeInboundPorts string `json:"includeInboundPorts,omitempty"`
 	// IncludeIPRanges represents IP ranges to be whitelisted for egress.
 	IncludeIPRanges string `json:"includeIPRanges,omitempty"`
+	// Init configures the istio-init container.
+	Init *ProxyInitContainerConfig `json:"init,omitempty"`
+	// KubevirtInterfaces lists the virtual interfaces whose inbound traffic
+	// is captured by the proxy.
+	KubevirtInterfaces string `json:"kubevirtInterfaces,omitempty"`
+	// LogLevel is the log level of the proxy, e.g. warning.
+	LogLevel string `json:"logLevel,omitempty"`
 	// Privileged specifies whether or not the Istio proxy container runs in
 	// privileged mode.  Defaults to false
 	Privileged *bool `json:"privileged,omitempty"`
+	// ProtocolDetectionTimeout is the time the proxy waits to detect the
+	// protocol of an inbound connection, e.g. 100ms.
+	ProtocolDetectionTimeout string `json:"protocolDetectionTimeout,omitempty"`
 	// ReadinessFailureThreshold represents the failure threshold for the
 	// readiness probe on the proxy container.  Defaults to 30
 	ReadinessFailureThreshold *int32 `json:"readinessFailureThreshold,omitempty"`
@@ -393,6 +730,12 @@ type ProxyConfig struct {
 	EnvoyStatsD *EnvoyStatsDConfig `json:"envoyStatsd,omitempty"`
 }
 
+// ProxyInitContainerConfig is the configuration for the istio-init container
+type ProxyInitContainerConfig struct {
+	// Resources specifies the resource requirements for the container.
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
 // ProxyInitConfig is the configuration for proxy_init
 type ProxyInitConfig struct {
 	// Image is the name of the proxy init image.  Defaults to proxy_init
@@ -423,6 +766,14 @@ type SDSConfig struct {
 	// the Envoy container so it can be used when generating keys/certs.
 	// Defaults to false
 	UseTrustworthyJWT *bool `json:"useTrustworthyJwt,omitempty"`
+	// Token configures the JWT used when requesting keys/certs from SDS.
+	Token *SDSTokenConfig `json:"token,omitempty"`
+}
+
+// SDSTokenConfig configures the JWT used with SDS
+type SDSTokenConfig struct {
+	// Aud is the audience of the JWT
+	Aud string `json:"aud,omitempty"`
 }
 
 // ProxyTracerType is a custom type for specifying the type of tracer configured.
@@ -439,12 +790,22 @@ const (
 type ProxyTracerConfig struct {
 	// Type of tracer.  Defaults to zipkin
 	Type ProxyTracerType `json:"type,omitempty"`
+	// Datadog configuration
+	Datadog *ProxyTracerDatadogConfig `json:"datadog,omitempty"`
+	// Stackdriver configuration
+	Stackdriver HelmValuesType `json:"stackdriver,omitempty"`
 	// LightStep configuration
 	LightStep *ProxyTracerLightStepConfig `json:"lightstep,omitempty"`
 	// Zipkin configuration
 	Zipkin *ProxyTracerZipkinConfig `json:"zipkin,omitempty"`
 }
 
+// ProxyTracerDatadogConfig represents the configuration of the Datadog tracer
+type ProxyTracerDatadogConfig struct {
+	// Address of the Datadog agent
+	Address string `json:"address,omitempty"`
+}
+
 // ProxyTracerLightStepConfig represents the configuration of the LightStep tracer
 type ProxyTracerLightStepConfig struct {
 	// AccessToken is the token used to access the LightStep server
@@ -473,6 +834,13 @@ type GalleyConfig struct {
 	CommonComponentConfig `json:",inline"`
 	// Defaults: Image: galley, ReplicaCount: 1
 	DeploymentFields `json:",inline"`
+
+	// EnableAnalysis specifies whether or not Galley analyzes the
+	// configuration.  Defaults to false.
+	EnableAnalysis *bool `json:"enableAnalysis,omitempty"`
+	// EnableServiceDiscovery specifies whether or not Galley provides
+	// service discovery.  Defaults to false.
+	EnableServiceDiscovery *bool `json:"enableServiceDiscovery,omitempty"`
 }
 
 // Gateways component
@@ -538,6 +906,17 @@ type GatewayConfig struct {
 	// Ports to be exposed by the gateway Service and Deployment.  The
 	// containerPort will be created matching the port value.
 	Ports []corev1.ServicePort `json:"ports,omitempty"`
+	// ApplicationPorts is a comma-separated list of the ports on which the
+	// gateway's sidecar captures application traffic.
+	ApplicationPorts string `json:"applicationPorts,omitempty"`
+	// Type of the gateway Service.  Defaults to LoadBalancer
+	Type corev1.ServiceType `json:"type,omitempty"`
+
+	// IOREnabled specifies whether or not routes are created for the gateway
+	// by IOR (Istio OpenShift Routing).  Defaults to false.
+	IOREnabled *bool `json:"ior_enabled,omitempty"`
+	// IORImage is the name of the IOR image.
+	IORImage string `json:"ior_image,omitempty"`
 }
 
 // SDSContainerConfig is used to configure an SDS container on a Pod.
@@ -548,6 +927,8 @@ type SDSContainerConfig struct {
 	// Image is the name of the image to use for the SDS container.  Defaults to
 	// node-agent-k8s
 	Image string `json:"image,omitempty"`
+	// Resources specifies the resource requirements for the SDS container.
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
 }
 
 // ConfigMapVolume defines a ConfigMap Volume that will be added to a Deployment
@@ -599,9 +980,20 @@ type MixerPolicyConfig struct {
 
 // MixerTelemetryConfig is the configuration for Mixer's telemetry component
 type MixerTelemetryConfig struct {
+	// Defaults to true
+	EnabledField `json:",inline"`
 	// Only Autoscaler and ReplicaCount fields are used
 	DeploymentFields `json:",inline"`
 
+	// Loadshedding configures load shedding for the telemetry service.
+	Loadshedding HelmValuesType `json:"loadshedding,omitempty"`
+	// ReportBatchMaxEntries is the number of requests batched before they are
+	// reported.  Defaults to 100
+	ReportBatchMaxEntries *int32 `json:"reportBatchMaxEntries,omitempty"`
+	// ReportBatchMaxTime is the maximum time requests are batched before
+	// they are reported.  Defaults to 1s
+	ReportBatchMaxTime string `json:"reportBatchMaxTime,omitempty"`
+
 	// SessionAffinityEnabled configures sessionAffinity: ClientIP on the
 	// associated Service. Defaults to false
 	SessionAffinityEnabled *bool `json:"sessionAffinityEnabled,omitempty"`
@@ -631,7 +1023,7 @@ type PrometheusMixerAdapterConfig struct {
 	// Defaults to true.
 	EnabledField `json:",inline"`
 	// MetricExpiryDuration ... Defaults to 10m
-	MetricExpiryDuration string
+	MetricExpiryDuration string `json:"metricsExpiryDuration,omitempty"`
 }
 
 // StdioMixerAdapterConfig is the configuration for the stdio mixer adapter
@@ -639,7 +1031,7 @@ type StdioMixerAdapterConfig struct {
 	// Defaults to true.
 	EnabledField `json:",inline"`
 	// OutputAsJSON ...  Defaults to true.
-	OutputAsJSON *bool
+	OutputAsJSON *bool `json:"outputAsJson,omitempty"`
 }
 
 // Pilot component
@@ -654,6 +1046,20 @@ type PilotConfig struct {
 	// Env: { PILOT_PUSH_THROTTLE_COUNT: 100, GODEBUG: gctrace=2 }
 	DeploymentFields `json:",inline"`
 
+	// AppNamespace is a comma-separated list of the namespaces watched by
+	// Pilot, e.g. "istio-system, istio-apps".
+	AppNamespace string `json:"appNamespace,omitempty"`
+	// ConfigSource configures the sources of Pilot's configuration.
+	ConfigSource HelmValuesType `json:"configSource,omitempty"`
+	// EnableProtocolSniffingForInbound enables protocol detection for inbound
+	// traffic.  Defaults to false
+	EnableProtocolSniffingForInbound *bool `json:"enableProtocolSniffingForInbound,omitempty"`
+	// EnableProtocolSniffingForOutbound enables protocol detection for
+	// outbound traffic.  Defaults to true
+	EnableProtocolSniffingForOutbound *bool `json:"enableProtocolSniffingForOutbound,omitempty"`
+	// KeepaliveMaxServerConnectionAge limits how long a sidecar may stay
+	// connected to Pilot.  Defaults to 30m
+	KeepaliveMaxServerConnectionAge string `json:"keepaliveMaxServerConnectionAge,omitempty"`
 	// Sidecar configures an Istio proxy sidecar on the Pilot Pods.
 	// Defaults to true
 	Sidecar *bool `json:"sidecar,omitempty"`
@@ -746,6 +1152,15 @@ type SecurityConfig struct {
 	// CreateMeshPolicy specifies whether or not a MeshPolicy should be created.
 	// Defaults to true.
 	CreateMeshPolicy *bool `json:"createMeshPolicy,omitempty"`
+	// CitadelHealthCheck enables the health check of Citadel.  Defaults to
+	// false.
+	CitadelHealthCheck *bool `json:"citadelHealthCheck,omitempty"`
+	// EnableNamespacesByDefault specifies whether or not Citadel generates
+	// secrets for all namespaces, unless they are labeled otherwise.
+	// Defaults to true.
+	EnableNamespacesByDefault *bool `json:"enableNamespacesByDefault,omitempty"`
+	// WorkloadCertTTL is the TTL of the workload certificates, e.g. 2160h.
+	WorkloadCertTTL string `json:"workloadCertTtl,omitempty"`
 }
 
 // Sidecar Injector component
@@ -765,6 +1180,18 @@ type SidecarInjectorConfig struct {
 	// istio-injection=enabled for automatic injection of sidecars.
 	// Defaults to false.
 	EnableNamespacesByDefault *bool `json:"enableNamespacesByDefault,omitempty"`
+	// AlwaysInjectSelector lists label selectors of pods that are always
+	// injected, regardless of the namespace policy.
+	AlwaysInjectSelector []metav1.LabelSelector `json:"alwaysInjectSelector,omitempty"`
+	// NeverInjectSelector lists label selectors of pods that are never
+	// injected, regardless of the namespace policy.
+	NeverInjectSelector []metav1.LabelSelector `json:"neverInjectSelector,omitempty"`
+	// InjectedAnnotations are annotations added to injected pods.
+	InjectedAnnotations AnnotationsType `json:"injectedAnnotations,omitempty"`
+	// RewriteAppHTTPProbe specifies whether or not the HTTP probes of the
+	// application are rewritten to be served by the sidecar.  Defaults to
+	// false.
+	RewriteAppHTTPProbe *bool `json:"rewriteAppHTTPProbe,omitempty"`
 }
 
 // Tracing component
@@ -778,6 +1205,8 @@ type TracingConfig struct {
 	// NodeSelector is a set of key/value pairs to be used as the node selector
 	// for the Pods.  If not specified, the DefaultNodeSelector is used.
 	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// Scheduling specific configuration
+	SchedulingFields `json:",inline"`
 
 	Provider    string `json:"provider,omitempty"`
 	ContextPath string `json:"contextPath,omitempty"`
@@ -829,6 +1258,26 @@ type TracingJaegerConfig struct {
 	Hub string `json:"hub,omitempty"`
 	// Tag is the tag of the image.  Defaults to 1.9
 	Tag string `json:"tag,omitempty"`
+	// Image is the name of the image.  Defaults to all-in-one
+	Image string `json:"image,omitempty"`
+	// Template is the Jaeger template to use, e.g. all-in-one or
+	// production-elasticsearch.
+	Template string `json:"template,omitempty"`
+	// Elasticsearch configures the Elasticsearch storage used by the
+	// production-elasticsearch template.
+	Elasticsearch HelmValuesType `json:"elasticsearch,omitempty"`
+	// SpanStorageType is the storage type of the all-in-one image, e.g.
+	// badger.
+	SpanStorageType string `json:"spanStorageType,omitempty"`
+	// Persist specifies whether or not a PersistentVolumeClaim is used for
+	// storage.  Defaults to false.
+	Persist *bool `json:"persist,omitempty"`
+	// StorageClassName of the PersistentVolumeClaim.
+	StorageClassName string `json:"storageClassName,omitempty"`
+	// AccessMode of the PersistentVolumeClaim.  Defaults to ReadWriteMany.
+	AccessMode corev1.PersistentVolumeAccessMode `json:"accessMode,omitempty"`
+	// PodAnnotations to be added to the Pods.
+	PodAnnotations AnnotationsType `json:"podAnnotations,omitempty"`
 }
 
 // TracingJaegerMemoryConfig is the memory configuration for Jaeger
@@ -874,6 +1323,10 @@ type TracingZipkinConfig struct {
 	// Tag is the tag of the image
 	// Defaults to 2
 	Tag string `json:"tag,omitempty"`
+	// Image is the name of the image.  Defaults to zipkin
+	Image string `json:"image,omitempty"`
+	// PodAnnotations to be added to the Pods.
+	PodAnnotations AnnotationsType `json:"podAnnotations,omitempty"`
 }
 
 // TracingZipkinNodeConfig for CPUs
@@ -903,6 +1356,9 @@ type KialiConfig struct {
 	Dashboard   *KialiDashboardConfig `json:"dashboard,omitempty"`
 	// PrometheusAddr for prometheus service
 	PrometheusAddr string `json:"prometheusAddr,omitempty"`
+	// JaegerInClusterURL is the in cluster URL of the Jaeger query service
+	// used by Kiali.
+	JaegerInClusterURL string `json:"jaegerInClusterURL,omitempty"`
 	// CreateDemoSecret will cause a secret will be created with a default username
 	// and password. Useful for demos.
 	CreateDemoSecret *bool `json:"createDemoSecret,omitempty"`
@@ -915,6 +1371,9 @@ type KialiDashboardConfig struct {
 	PassphraseKey string `json:"passphraseKey,omitempty"`
 	User          string `json:"user,omitempty"`
 	Passphrase    string `json:"passphrase,omitempty"`
+	// ViewOnlyMode prevents the dashboard from modifying the mesh.  Defaults
+	// to false.
+	ViewOnlyMode *bool `json:"viewOnlyMode,omitempty"`
 }
 
 // Shared structs used by multiple components
@@ -973,6 +1432,40 @@ type DeploymentFields struct {
 	Env map[string]string `json:"env,omitempty"`
 	// PodAnnotations to be added to the Pods.
 	PodAnnotations AnnotationsType `json:"podAnnotations,omitempty"`
+	// RollingMaxSurge is the maxSurge of the Deployment's rolling update
+	// strategy.  Defaults to 100%
+	RollingMaxSurge *intstr.IntOrString `json:"rollingMaxSurge,omitempty"`
+	// RollingMaxUnavailable is the maxUnavailable of the Deployment's rolling
+	// update strategy.  Defaults to 25%
+	RollingMaxUnavailable *intstr.IntOrString `json:"rollingMaxUnavailable,omitempty"`
+	// Scheduling specific configuration
+	SchedulingFields `json:",inline"`
+}
+
+// SchedulingFields are fields used to configure where the Pods are scheduled
+type SchedulingFields struct {
+	// Tolerations to be added to the Pods.  If not specified, the
+	// DefaultTolerations are used.
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// PodAntiAffinityLabelSelector lists the terms of a required pod
+	// anti-affinity.
+	PodAntiAffinityLabelSelector []PodAntiAffinityTerm `json:"podAntiAffinityLabelSelector,omitempty"`
+	// PodAntiAffinityTermLabelSelector lists the terms of a preferred pod
+	// anti-affinity.
+	PodAntiAffinityTermLabelSelector []PodAntiAffinityTerm `json:"podAntiAffinityTermLabelSelector,omitempty"`
+}
+
+// PodAntiAffinityTerm is a simplified pod anti-affinity term, e.g.
+// {key: security, operator: In, values: S1,S2, topologyKey: kubernetes.io/hostname}
+type PodAntiAffinityTerm struct {
+	// Key of the label
+	Key string `json:"key,omitempty"`
+	// Operator used to match the label values, e.g. In
+	Operator string `json:"operator,omitempty"`
+	// Values is a comma-separated list of label values
+	Values string `json:"values,omitempty"`
+	// TopologyKey of the term, e.g. kubernetes.io/hostname
+	TopologyKey string `json:"topologyKey,omitempty"`
 }
 
 // HorizontalPodAutoscalerFields used by most autoscale.yaml templates
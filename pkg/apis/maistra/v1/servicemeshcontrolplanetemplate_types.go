@@ -0,0 +1,45 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func init() {
+	SchemeBuilder.Register(&ServiceMeshControlPlaneTemplate{}, &ServiceMeshControlPlaneTemplateList{})
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ServiceMeshControlPlaneTemplate is the Schema for the
+// servicemeshcontrolplanetemplates API.  A ServiceMeshControlPlane may
+// reference a template in its own namespace by name, using spec.template.
+// Templates in the namespace take precedence over the templates installed
+// with the operator.
+// +k8s:openapi-gen=true
+type ServiceMeshControlPlaneTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ControlPlaneSpec `json:"spec,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ServiceMeshControlPlaneTemplateList contains a list of ServiceMeshControlPlaneTemplate
+type ServiceMeshControlPlaneTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ServiceMeshControlPlaneTemplate `json:"items"`
+}
+
+// AppliedTemplate identifies a template that was applied to the configuration
+// of a ServiceMeshControlPlane
+type AppliedTemplate struct {
+	// Name of the template
+	Name string `json:"name"`
+	// ResourceVersion of the ServiceMeshControlPlaneTemplate resource that was
+	// applied.  Empty if the template was loaded from the templates installed
+	// with the operator.
+	// +optional
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
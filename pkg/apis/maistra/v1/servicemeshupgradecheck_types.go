@@ -0,0 +1,82 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func init() {
+	SchemeBuilder.Register(&ServiceMeshUpgradeCheck{}, &ServiceMeshUpgradeCheckList{})
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ServiceMeshUpgradeCheck is the Schema for the servicemeshupgradechecks API.
+// It periodically runs the checks performed when spec.version of a
+// ServiceMeshControlPlane in its namespace is updated, without updating the
+// control plane, and reports every object that would block the update.
+// +k8s:openapi-gen=true
+type ServiceMeshUpgradeCheck struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ServiceMeshUpgradeCheckSpec   `json:"spec,omitempty"`
+	Status ServiceMeshUpgradeCheckStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ServiceMeshUpgradeCheckList contains a list of ServiceMeshUpgradeCheck
+type ServiceMeshUpgradeCheckList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ServiceMeshUpgradeCheck `json:"items"`
+}
+
+// ServiceMeshUpgradeCheckSpec identifies the control plane and the version it
+// should be checked against
+type ServiceMeshUpgradeCheckSpec struct {
+	// ControlPlaneName is the name of the ServiceMeshControlPlane to check.
+	// The control plane must be in the same namespace as the check.
+	ControlPlaneName string `json:"controlPlaneName"`
+	// TargetVersion is the version the control plane would be updated to,
+	// e.g. v1.1
+	TargetVersion string `json:"targetVersion"`
+}
+
+// ServiceMeshUpgradeCheckStatus contains the result of the last check
+type ServiceMeshUpgradeCheckStatus struct {
+	StatusType `json:",inline"`
+
+	// CurrentVersion is the version of the control plane when it was last
+	// checked
+	// +optional
+	CurrentVersion string `json:"currentVersion,omitempty"`
+	// TargetVersion is the version the control plane was last checked against
+	// +optional
+	TargetVersion string `json:"targetVersion,omitempty"`
+	// LastCheckTime is the time of the last check
+	// +optional
+	LastCheckTime *metav1.Time `json:"lastCheckTime,omitempty"`
+	// Issues lists the objects that block the update of the control plane to
+	// the target version
+	// +optional
+	Issues []UpgradeCheckIssue `json:"issues,omitempty"`
+}
+
+// UpgradeCheckIssue describes an object that blocks the update of a control
+// plane to a different version
+type UpgradeCheckIssue struct {
+	// Kind of the object blocking the update
+	Kind string `json:"kind"`
+	// Namespace of the object blocking the update, empty for cluster scoped
+	// objects
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// Name of the object blocking the update
+	Name string `json:"name"`
+	// Message describes why the object blocks the update
+	Message string `json:"message"`
+	// Remediation describes how to resolve the issue
+	// +optional
+	Remediation string `json:"remediation,omitempty"`
+}
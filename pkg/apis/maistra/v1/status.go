@@ -76,6 +76,15 @@ const (
 	// ConditionTypeReady signifies the whether or not any Deployment, StatefulSet,
 	// etc. resources are Ready.
 	ConditionTypeReady ConditionType = "Ready"
+	// ConditionTypeRolledBack signifies whether or not the controller has
+	// rolled back a failed update to the last known good configuration.
+	ConditionTypeRolledBack ConditionType = "RolledBack"
+	// ConditionTypeUpgradeable signifies whether or not a control plane can
+	// be updated to the target version of a ServiceMeshUpgradeCheck.
+	ConditionTypeUpgradeable ConditionType = "Upgradeable"
+	// ConditionTypeUpgradeAvailable signifies whether or not the operator
+	// ships a newer version a control plane can be upgraded to.
+	ConditionTypeUpgradeAvailable ConditionType = "UpgradeAvailable"
 )
 
 // ConditionStatus represents the status of the condition
@@ -130,6 +139,20 @@ const (
 	ConditionReasonDeleting ConditionReason = "Deleting"
 	// ConditionReasonDeleted ...
 	ConditionReasonDeleted ConditionReason = "Deleted"
+	// ConditionReasonProgressDeadlineExceeded ...
+	ConditionReasonProgressDeadlineExceeded ConditionReason = "ProgressDeadlineExceeded"
+	// ConditionReasonTooManyReconcileErrors ...
+	ConditionReasonTooManyReconcileErrors ConditionReason = "TooManyReconcileErrors"
+	// ConditionReasonUpgradeCheckPassed ...
+	ConditionReasonUpgradeCheckPassed ConditionReason = "UpgradeCheckPassed"
+	// ConditionReasonUpgradeBlocked ...
+	ConditionReasonUpgradeBlocked ConditionReason = "UpgradeBlocked"
+	// ConditionReasonUpgradeCheckError ...
+	ConditionReasonUpgradeCheckError ConditionReason = "UpgradeCheckError"
+	// ConditionReasonUpgradePendingApproval ...
+	ConditionReasonUpgradePendingApproval ConditionReason = "UpgradePendingApproval"
+	// ConditionReasonUpToDate ...
+	ConditionReasonUpToDate ConditionReason = "UpToDate"
 )
 
 // Condition represents a specific condition on a resource
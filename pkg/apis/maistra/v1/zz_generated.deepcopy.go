@@ -7,7 +7,9 @@ package v1
 import (
 	corev1 "k8s.io/api/core/v1"
 	v1beta1 "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	intstr "k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -32,6 +34,22 @@ func (in AnnotationsType) DeepCopy() AnnotationsType {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppliedTemplate) DeepCopyInto(out *AppliedTemplate) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppliedTemplate.
+func (in *AppliedTemplate) DeepCopy() *AppliedTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(AppliedTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CommonComponentConfig) DeepCopyInto(out *CommonComponentConfig) {
 	*out = *in
@@ -116,9 +134,62 @@ func (in *ConfigMapVolume) DeepCopy() *ConfigMapVolume {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControlPlanePlan) DeepCopyInto(out *ControlPlanePlan) {
+	*out = *in
+	if in.Changes != nil {
+		in, out := &in.Changes, &out.Changes
+		*out = make([]PlannedChange, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlPlanePlan.
+func (in *ControlPlanePlan) DeepCopy() *ControlPlanePlan {
+	if in == nil {
+		return nil
+	}
+	out := new(ControlPlanePlan)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ControlPlaneSpec) DeepCopyInto(out *ControlPlaneSpec) {
 	*out = *in
+	if in.Templates != nil {
+		in, out := &in.Templates, &out.Templates
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RollbackPolicy != nil {
+		in, out := &in.RollbackPolicy, &out.RollbackPolicy
+		*out = new(RollbackPolicy)
+		**out = **in
+	}
+	if in.RevisionHistoryLimit != nil {
+		in, out := &in.RevisionHistoryLimit, &out.RevisionHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.RollbackTo != nil {
+		in, out := &in.RollbackTo, &out.RollbackTo
+		*out = new(RollbackConfig)
+		**out = **in
+	}
+	if in.Overlays != nil {
+		in, out := &in.Overlays, &out.Overlays
+		*out = make([]ResourceOverlay, len(*in))
+		copy(*out, *in)
+	}
+	if in.DeletionPolicy != nil {
+		in, out := &in.DeletionPolicy, &out.DeletionPolicy
+		*out = new(DeletionPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 	out.Istio = in.Istio.DeepCopy()
 	out.ThreeScale = in.ThreeScale.DeepCopy()
 	return
@@ -151,6 +222,38 @@ func (in *ControlPlaneStatus) DeepCopyInto(out *ControlPlaneStatus) {
 		}
 	}
 	in.LastAppliedConfiguration.DeepCopyInto(&out.LastAppliedConfiguration)
+	if in.AppliedTemplates != nil {
+		in, out := &in.AppliedTemplates, &out.AppliedTemplates
+		*out = make([]AppliedTemplate, len(*in))
+		copy(*out, *in)
+	}
+	if in.ValueSources != nil {
+		in, out := &in.ValueSources, &out.ValueSources
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.LastKnownGoodConfiguration != nil {
+		in, out := &in.LastKnownGoodConfiguration, &out.LastKnownGoodConfiguration
+		*out = new(ControlPlaneSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Plan != nil {
+		in, out := &in.Plan, &out.Plan
+		*out = new(ControlPlanePlan)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OverlayErrors != nil {
+		in, out := &in.OverlayErrors, &out.OverlayErrors
+		*out = make([]OverlayError, len(*in))
+		copy(*out, *in)
+	}
+	if in.Inventory != nil {
+		in, out := &in.Inventory, &out.Inventory
+		*out = make([]ResourceKey, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -164,6 +267,29 @@ func (in *ControlPlaneStatus) DeepCopy() *ControlPlaneStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeletionPolicy) DeepCopyInto(out *DeletionPolicy) {
+	*out = *in
+	if in.Kinds != nil {
+		in, out := &in.Kinds, &out.Kinds
+		*out = make(map[string]DeletionPolicyType, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeletionPolicy.
+func (in *DeletionPolicy) DeepCopy() *DeletionPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(DeletionPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DeploymentFields) DeepCopyInto(out *DeploymentFields) {
 	*out = *in
@@ -199,6 +325,17 @@ func (in *DeploymentFields) DeepCopyInto(out *DeploymentFields) {
 			(*out)[key] = val
 		}
 	}
+	if in.RollingMaxSurge != nil {
+		in, out := &in.RollingMaxSurge, &out.RollingMaxSurge
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.RollingMaxUnavailable != nil {
+		in, out := &in.RollingMaxUnavailable, &out.RollingMaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	in.SchedulingFields.DeepCopyInto(&out.SchedulingFields)
 	return
 }
 
@@ -255,6 +392,16 @@ func (in *GalleyConfig) DeepCopyInto(out *GalleyConfig) {
 	*out = *in
 	in.CommonComponentConfig.DeepCopyInto(&out.CommonComponentConfig)
 	in.DeploymentFields.DeepCopyInto(&out.DeploymentFields)
+	if in.EnableAnalysis != nil {
+		in, out := &in.EnableAnalysis, &out.EnableAnalysis
+		*out = new(bool)
+		**out = **in
+	}
+	if in.EnableServiceDiscovery != nil {
+		in, out := &in.EnableServiceDiscovery, &out.EnableServiceDiscovery
+		*out = new(bool)
+		**out = **in
+	}
 	return
 }
 
@@ -329,6 +476,11 @@ func (in *GatewayConfig) DeepCopyInto(out *GatewayConfig) {
 		*out = make([]corev1.ServicePort, len(*in))
 		copy(*out, *in)
 	}
+	if in.IOREnabled != nil {
+		in, out := &in.IOREnabled, &out.IOREnabled
+		*out = new(bool)
+		**out = **in
+	}
 	return
 }
 
@@ -376,6 +528,13 @@ func (in *GlobalConfig) DeepCopyInto(out *GlobalConfig) {
 			(*out)[key] = val
 		}
 	}
+	if in.Certificates != nil {
+		in, out := &in.Certificates, &out.Certificates
+		*out = make([]HelmValuesType, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.ConfigValidation != nil {
 		in, out := &in.ConfigValidation, &out.ConfigValidation
 		*out = new(bool)
@@ -423,11 +582,23 @@ func (in *GlobalConfig) DeepCopyInto(out *GlobalConfig) {
 		*out = new(corev1.ResourceRequirements)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.DefaultTolerations != nil {
+		in, out := &in.DefaultTolerations, &out.DefaultTolerations
+		*out = make([]corev1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.DisablePolicyChecks != nil {
 		in, out := &in.DisablePolicyChecks, &out.DisablePolicyChecks
 		*out = new(bool)
 		**out = **in
 	}
+	if in.EnableHelmTest != nil {
+		in, out := &in.EnableHelmTest, &out.EnableHelmTest
+		*out = new(bool)
+		**out = **in
+	}
 	if in.EnableTracing != nil {
 		in, out := &in.EnableTracing, &out.EnableTracing
 		*out = new(bool)
@@ -443,6 +614,12 @@ func (in *GlobalConfig) DeepCopyInto(out *GlobalConfig) {
 		*out = new(KubernetesIngressConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	out.LocalityLbSetting = in.LocalityLbSetting.DeepCopy()
+	if in.Logging != nil {
+		in, out := &in.Logging, &out.Logging
+		*out = new(LoggingConfig)
+		**out = **in
+	}
 	if in.MeshExpansion != nil {
 		in, out := &in.MeshExpansion, &out.MeshExpansion
 		*out = new(MeshExpansionConfig)
@@ -464,6 +641,7 @@ func (in *GlobalConfig) DeepCopyInto(out *GlobalConfig) {
 		*out = new(MultiClusterConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	out.OAuthProxy = in.OAuthProxy.DeepCopy()
 	if in.OmitSidecarInjectorConfigMap != nil {
 		in, out := &in.OmitSidecarInjectorConfigMap, &out.OmitSidecarInjectorConfigMap
 		*out = new(bool)
@@ -474,6 +652,11 @@ func (in *GlobalConfig) DeepCopyInto(out *GlobalConfig) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.OperatorManageWebhooks != nil {
+		in, out := &in.OperatorManageWebhooks, &out.OperatorManageWebhooks
+		*out = new(bool)
+		**out = **in
+	}
 	if in.OutboundTrafficPolicy != nil {
 		in, out := &in.OutboundTrafficPolicy, &out.OutboundTrafficPolicy
 		*out = new(OutboundTrafficPolicyConfig)
@@ -489,6 +672,11 @@ func (in *GlobalConfig) DeepCopyInto(out *GlobalConfig) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.TrustDomainAliases != nil {
+		in, out := &in.TrustDomainAliases, &out.TrustDomainAliases
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.UseMCP != nil {
 		in, out := &in.UseMCP, &out.UseMCP
 		*out = new(bool)
@@ -663,6 +851,11 @@ func (in *IstioHelmValues) DeepCopyInto(out *IstioHelmValues) {
 		*out = new(KialiConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	out.CertManager = in.CertManager.DeepCopy()
+	out.IstioCNI = in.IstioCNI.DeepCopy()
+	out.IstioCoreDNS = in.IstioCoreDNS.DeepCopy()
+	out.NodeAgent = in.NodeAgent.DeepCopy()
+	out.ServiceGraph = in.ServiceGraph.DeepCopy()
 	return
 }
 
@@ -694,7 +887,7 @@ func (in *KialiConfig) DeepCopyInto(out *KialiConfig) {
 	if in.Dashboard != nil {
 		in, out := &in.Dashboard, &out.Dashboard
 		*out = new(KialiDashboardConfig)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.CreateDemoSecret != nil {
 		in, out := &in.CreateDemoSecret, &out.CreateDemoSecret
@@ -717,6 +910,11 @@ func (in *KialiConfig) DeepCopy() *KialiConfig {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KialiDashboardConfig) DeepCopyInto(out *KialiDashboardConfig) {
 	*out = *in
+	if in.ViewOnlyMode != nil {
+		in, out := &in.ViewOnlyMode, &out.ViewOnlyMode
+		*out = new(bool)
+		**out = **in
+	}
 	return
 }
 
@@ -769,10 +967,31 @@ func (in *KubernetesIngressConfig) DeepCopy() *KubernetesIngressConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoggingConfig) DeepCopyInto(out *LoggingConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoggingConfig.
+func (in *LoggingConfig) DeepCopy() *LoggingConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(LoggingConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MTLSConfig) DeepCopyInto(out *MTLSConfig) {
 	*out = *in
 	in.EnabledField.DeepCopyInto(&out.EnabledField)
+	if in.Auto != nil {
+		in, out := &in.Auto, &out.Auto
+		*out = new(bool)
+		**out = **in
+	}
 	return
 }
 
@@ -908,7 +1127,14 @@ func (in *MixerPolicyConfig) DeepCopy() *MixerPolicyConfig {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MixerTelemetryConfig) DeepCopyInto(out *MixerTelemetryConfig) {
 	*out = *in
+	in.EnabledField.DeepCopyInto(&out.EnabledField)
 	in.DeploymentFields.DeepCopyInto(&out.DeploymentFields)
+	out.Loadshedding = in.Loadshedding.DeepCopy()
+	if in.ReportBatchMaxEntries != nil {
+		in, out := &in.ReportBatchMaxEntries, &out.ReportBatchMaxEntries
+		*out = new(int32)
+		**out = **in
+	}
 	if in.SessionAffinityEnabled != nil {
 		in, out := &in.SessionAffinityEnabled, &out.SessionAffinityEnabled
 		*out = new(bool)
@@ -976,11 +1202,38 @@ func (in *OutboundTrafficPolicyConfig) DeepCopy() *OutboundTrafficPolicyConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OverlayError) DeepCopyInto(out *OverlayError) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OverlayError.
+func (in *OverlayError) DeepCopy() *OverlayError {
+	if in == nil {
+		return nil
+	}
+	out := new(OverlayError)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PilotConfig) DeepCopyInto(out *PilotConfig) {
 	*out = *in
 	in.CommonComponentConfig.DeepCopyInto(&out.CommonComponentConfig)
 	in.DeploymentFields.DeepCopyInto(&out.DeploymentFields)
+	out.ConfigSource = in.ConfigSource.DeepCopy()
+	if in.EnableProtocolSniffingForInbound != nil {
+		in, out := &in.EnableProtocolSniffingForInbound, &out.EnableProtocolSniffingForInbound
+		*out = new(bool)
+		**out = **in
+	}
+	if in.EnableProtocolSniffingForOutbound != nil {
+		in, out := &in.EnableProtocolSniffingForOutbound, &out.EnableProtocolSniffingForOutbound
+		*out = new(bool)
+		**out = **in
+	}
 	if in.Sidecar != nil {
 		in, out := &in.Sidecar, &out.Sidecar
 		*out = new(bool)
@@ -1004,6 +1257,43 @@ func (in *PilotConfig) DeepCopy() *PilotConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlannedChange) DeepCopyInto(out *PlannedChange) {
+	*out = *in
+	if in.ImmutableFields != nil {
+		in, out := &in.ImmutableFields, &out.ImmutableFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlannedChange.
+func (in *PlannedChange) DeepCopy() *PlannedChange {
+	if in == nil {
+		return nil
+	}
+	out := new(PlannedChange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodAntiAffinityTerm) DeepCopyInto(out *PodAntiAffinityTerm) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodAntiAffinityTerm.
+func (in *PodAntiAffinityTerm) DeepCopy() *PodAntiAffinityTerm {
+	if in == nil {
+		return nil
+	}
+	out := new(PodAntiAffinityTerm)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PodDisruptionBudget) DeepCopyInto(out *PodDisruptionBudget) {
 	*out = *in
@@ -1174,6 +1464,13 @@ func (in *ProxyConfig) DeepCopyInto(out *ProxyConfig) {
 		*out = new(bool)
 		**out = **in
 	}
+	out.EnvoyAccessLogService = in.EnvoyAccessLogService.DeepCopy()
+	out.EnvoyMetricsService = in.EnvoyMetricsService.DeepCopy()
+	if in.Init != nil {
+		in, out := &in.Init, &out.Init
+		*out = new(ProxyInitContainerConfig)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Privileged != nil {
 		in, out := &in.Privileged, &out.Privileged
 		*out = new(bool)
@@ -1238,9 +1535,36 @@ func (in *ProxyInitConfig) DeepCopy() *ProxyInitConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProxyInitContainerConfig) DeepCopyInto(out *ProxyInitContainerConfig) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(corev1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxyInitContainerConfig.
+func (in *ProxyInitContainerConfig) DeepCopy() *ProxyInitContainerConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ProxyInitContainerConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProxyTracerConfig) DeepCopyInto(out *ProxyTracerConfig) {
 	*out = *in
+	if in.Datadog != nil {
+		in, out := &in.Datadog, &out.Datadog
+		*out = new(ProxyTracerDatadogConfig)
+		**out = **in
+	}
+	out.Stackdriver = in.Stackdriver.DeepCopy()
 	if in.LightStep != nil {
 		in, out := &in.LightStep, &out.LightStep
 		*out = new(ProxyTracerLightStepConfig)
@@ -1264,6 +1588,22 @@ func (in *ProxyTracerConfig) DeepCopy() *ProxyTracerConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProxyTracerDatadogConfig) DeepCopyInto(out *ProxyTracerDatadogConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxyTracerDatadogConfig.
+func (in *ProxyTracerDatadogConfig) DeepCopy() *ProxyTracerDatadogConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ProxyTracerDatadogConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProxyTracerLightStepConfig) DeepCopyInto(out *ProxyTracerLightStepConfig) {
 	*out = *in
@@ -1322,6 +1662,54 @@ func (in *ResourceMetricCPU) DeepCopy() *ResourceMetricCPU {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceOverlay) DeepCopyInto(out *ResourceOverlay) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceOverlay.
+func (in *ResourceOverlay) DeepCopy() *ResourceOverlay {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceOverlay)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RollbackConfig) DeepCopyInto(out *RollbackConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RollbackConfig.
+func (in *RollbackConfig) DeepCopy() *RollbackConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(RollbackConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RollbackPolicy) DeepCopyInto(out *RollbackPolicy) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RollbackPolicy.
+func (in *RollbackPolicy) DeepCopy() *RollbackPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(RollbackPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SDSConfig) DeepCopyInto(out *SDSConfig) {
 	*out = *in
@@ -1336,6 +1724,11 @@ func (in *SDSConfig) DeepCopyInto(out *SDSConfig) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.Token != nil {
+		in, out := &in.Token, &out.Token
+		*out = new(SDSTokenConfig)
+		**out = **in
+	}
 	return
 }
 
@@ -1353,6 +1746,11 @@ func (in *SDSConfig) DeepCopy() *SDSConfig {
 func (in *SDSContainerConfig) DeepCopyInto(out *SDSContainerConfig) {
 	*out = *in
 	in.EnabledField.DeepCopyInto(&out.EnabledField)
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(corev1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -1366,6 +1764,55 @@ func (in *SDSContainerConfig) DeepCopy() *SDSContainerConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SDSTokenConfig) DeepCopyInto(out *SDSTokenConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SDSTokenConfig.
+func (in *SDSTokenConfig) DeepCopy() *SDSTokenConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(SDSTokenConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchedulingFields) DeepCopyInto(out *SchedulingFields) {
+	*out = *in
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]corev1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PodAntiAffinityLabelSelector != nil {
+		in, out := &in.PodAntiAffinityLabelSelector, &out.PodAntiAffinityLabelSelector
+		*out = make([]PodAntiAffinityTerm, len(*in))
+		copy(*out, *in)
+	}
+	if in.PodAntiAffinityTermLabelSelector != nil {
+		in, out := &in.PodAntiAffinityTermLabelSelector, &out.PodAntiAffinityTermLabelSelector
+		*out = make([]PodAntiAffinityTerm, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchedulingFields.
+func (in *SchedulingFields) DeepCopy() *SchedulingFields {
+	if in == nil {
+		return nil
+	}
+	out := new(SchedulingFields)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecretVolume) DeepCopyInto(out *SecretVolume) {
 	*out = *in
@@ -1397,6 +1844,16 @@ func (in *SecurityConfig) DeepCopyInto(out *SecurityConfig) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.CitadelHealthCheck != nil {
+		in, out := &in.CitadelHealthCheck, &out.CitadelHealthCheck
+		*out = new(bool)
+		**out = **in
+	}
+	if in.EnableNamespacesByDefault != nil {
+		in, out := &in.EnableNamespacesByDefault, &out.EnableNamespacesByDefault
+		*out = new(bool)
+		**out = **in
+	}
 	return
 }
 
@@ -1487,6 +1944,66 @@ func (in *ServiceMeshControlPlaneRef) DeepCopy() *ServiceMeshControlPlaneRef {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceMeshControlPlaneTemplate) DeepCopyInto(out *ServiceMeshControlPlaneTemplate) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceMeshControlPlaneTemplate.
+func (in *ServiceMeshControlPlaneTemplate) DeepCopy() *ServiceMeshControlPlaneTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceMeshControlPlaneTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ServiceMeshControlPlaneTemplate) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceMeshControlPlaneTemplateList) DeepCopyInto(out *ServiceMeshControlPlaneTemplateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ServiceMeshControlPlaneTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceMeshControlPlaneTemplateList.
+func (in *ServiceMeshControlPlaneTemplateList) DeepCopy() *ServiceMeshControlPlaneTemplateList {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceMeshControlPlaneTemplateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ServiceMeshControlPlaneTemplateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServiceMeshMember) DeepCopyInto(out *ServiceMeshMember) {
 	*out = *in
@@ -1734,6 +2251,109 @@ func (in *ServiceMeshMemberStatus) DeepCopy() *ServiceMeshMemberStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceMeshUpgradeCheck) DeepCopyInto(out *ServiceMeshUpgradeCheck) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceMeshUpgradeCheck.
+func (in *ServiceMeshUpgradeCheck) DeepCopy() *ServiceMeshUpgradeCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceMeshUpgradeCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ServiceMeshUpgradeCheck) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceMeshUpgradeCheckList) DeepCopyInto(out *ServiceMeshUpgradeCheckList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ServiceMeshUpgradeCheck, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceMeshUpgradeCheckList.
+func (in *ServiceMeshUpgradeCheckList) DeepCopy() *ServiceMeshUpgradeCheckList {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceMeshUpgradeCheckList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ServiceMeshUpgradeCheckList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceMeshUpgradeCheckSpec) DeepCopyInto(out *ServiceMeshUpgradeCheckSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceMeshUpgradeCheckSpec.
+func (in *ServiceMeshUpgradeCheckSpec) DeepCopy() *ServiceMeshUpgradeCheckSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceMeshUpgradeCheckSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceMeshUpgradeCheckStatus) DeepCopyInto(out *ServiceMeshUpgradeCheckStatus) {
+	*out = *in
+	in.StatusType.DeepCopyInto(&out.StatusType)
+	if in.LastCheckTime != nil {
+		in, out := &in.LastCheckTime, &out.LastCheckTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Issues != nil {
+		in, out := &in.Issues, &out.Issues
+		*out = make([]UpgradeCheckIssue, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceMeshUpgradeCheckStatus.
+func (in *ServiceMeshUpgradeCheckStatus) DeepCopy() *ServiceMeshUpgradeCheckStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceMeshUpgradeCheckStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SidecarInjectorConfig) DeepCopyInto(out *SidecarInjectorConfig) {
 	*out = *in
@@ -1744,6 +2364,32 @@ func (in *SidecarInjectorConfig) DeepCopyInto(out *SidecarInjectorConfig) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.AlwaysInjectSelector != nil {
+		in, out := &in.AlwaysInjectSelector, &out.AlwaysInjectSelector
+		*out = make([]metav1.LabelSelector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NeverInjectSelector != nil {
+		in, out := &in.NeverInjectSelector, &out.NeverInjectSelector
+		*out = make([]metav1.LabelSelector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.InjectedAnnotations != nil {
+		in, out := &in.InjectedAnnotations, &out.InjectedAnnotations
+		*out = make(AnnotationsType, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.RewriteAppHTTPProbe != nil {
+		in, out := &in.RewriteAppHTTPProbe, &out.RewriteAppHTTPProbe
+		*out = new(bool)
+		**out = **in
+	}
 	return
 }
 
@@ -1836,6 +2482,7 @@ func (in *TracingConfig) DeepCopyInto(out *TracingConfig) {
 			(*out)[key] = val
 		}
 	}
+	in.SchedulingFields.DeepCopyInto(&out.SchedulingFields)
 	if in.Gateway != nil {
 		in, out := &in.Gateway, &out.Gateway
 		*out = new(TracingGatewayConfig)
@@ -1904,6 +2551,19 @@ func (in *TracingJaegerConfig) DeepCopyInto(out *TracingJaegerConfig) {
 		*out = new(corev1.ResourceRequirements)
 		(*in).DeepCopyInto(*out)
 	}
+	out.Elasticsearch = in.Elasticsearch.DeepCopy()
+	if in.Persist != nil {
+		in, out := &in.Persist, &out.Persist
+		*out = new(bool)
+		**out = **in
+	}
+	if in.PodAnnotations != nil {
+		in, out := &in.PodAnnotations, &out.PodAnnotations
+		*out = make(AnnotationsType, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
@@ -1984,6 +2644,13 @@ func (in *TracingZipkinConfig) DeepCopyInto(out *TracingZipkinConfig) {
 		*out = new(corev1.ResourceRequirements)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.PodAnnotations != nil {
+		in, out := &in.PodAnnotations, &out.PodAnnotations
+		*out = make(AnnotationsType, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
@@ -2012,3 +2679,19 @@ func (in *TracingZipkinNodeConfig) DeepCopy() *TracingZipkinNodeConfig {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeCheckIssue) DeepCopyInto(out *UpgradeCheckIssue) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeCheckIssue.
+func (in *UpgradeCheckIssue) DeepCopy() *UpgradeCheckIssue {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeCheckIssue)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,4 @@
+// Package v2 contains API Schema definitions for the maistra v2 API group
+// +k8s:deepcopy-gen=package,register
+// +groupName=maistra.io
+package v2
@@ -0,0 +1,247 @@
+package v2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	maistrav1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+)
+
+func init() {
+	SchemeBuilder.Register(&ServiceMeshControlPlane{}, &ServiceMeshControlPlaneList{})
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ServiceMeshControlPlane is the Schema for the controlplanes API.  Unlike
+// v1, which exposes the values of the Istio helm charts, v2 describes the
+// control plane using typed fields that do not depend on the structure of the
+// charts.  v1 is the storage version; v2 resources are converted by the
+// operator's conversion webhook.
+// +k8s:openapi-gen=true
+type ServiceMeshControlPlane struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ControlPlaneSpec `json:"spec,omitempty"`
+	// Status is shared with v1, so the status reported for the control plane
+	// is the same regardless of the version it is read with.
+	Status maistrav1.ControlPlaneStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ServiceMeshControlPlaneList contains a list of ServiceMeshControlPlane
+type ServiceMeshControlPlaneList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ServiceMeshControlPlane `json:"items"`
+}
+
+// ControlPlaneSpec represents the configuration for installing a control
+// plane.  The fields that are not specific to the Istio charts have the same
+// meaning as in v1.
+type ControlPlaneSpec struct {
+	// Template selects the template to use for default values. Defaults to
+	// "default" when neither Template nor Templates is set.
+	// +optional
+	Template string `json:"template,omitempty"`
+	// Templates lists the templates to use for default values, in the order
+	// in which they are applied, instead of Template.
+	// +optional
+	Templates []string `json:"templates,omitempty"`
+	// Version specifies what Maistra version of the control plane to install,
+	// e.g. v1.1, a patch release, e.g. v1.1.3, or a range, e.g. ~1.1.
+	// +optional
+	Version string `json:"version,omitempty"`
+	// UpgradePolicy determines whether the control plane is upgraded
+	// automatically when the operator ships a newer version.
+	// +optional
+	UpgradePolicy maistrav1.UpgradePolicyType `json:"upgradePolicy,omitempty"`
+	// RollbackPolicy enables automatic rollback of failed updates.
+	// +optional
+	RollbackPolicy *maistrav1.RollbackPolicy `json:"rollbackPolicy,omitempty"`
+	// RevisionHistoryLimit is the number of configuration revisions to keep.
+	// +optional
+	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
+	// RollbackTo selects a configuration revision to apply instead of the
+	// configuration specified by this resource.
+	// +optional
+	RollbackTo *maistrav1.RollbackConfig `json:"rollbackTo,omitempty"`
+	// Overlays lists patches that are applied to the rendered resources.
+	// +optional
+	Overlays []maistrav1.ResourceOverlay `json:"overlays,omitempty"`
+	// DeletionPolicy determines whether the resources created for the control
+	// plane are deleted or retained when the control plane is deleted.
+	// +optional
+	DeletionPolicy *maistrav1.DeletionPolicy `json:"deletionPolicy,omitempty"`
+	// NetworkType of the cluster.  Defaults to subnet.
+	// +optional
+	NetworkType maistrav1.NetworkType `json:"networkType,omitempty"`
+
+	// Security configures mutual TLS within the mesh.
+	// +optional
+	Security *SecurityConfig `json:"security,omitempty"`
+	// Tracing configures tracing of the requests in the mesh.
+	// +optional
+	Tracing *TracingConfig `json:"tracing,omitempty"`
+	// Gateways configures the default ingress and egress gateways.
+	// +optional
+	Gateways *GatewaysConfig `json:"gateways,omitempty"`
+	// Addons configures the addons installed with the control plane.
+	// +optional
+	Addons *AddonsConfig `json:"addons,omitempty"`
+	// Runtime configures the deployments of the control plane components.
+	// +optional
+	Runtime *ControlPlaneRuntimeConfig `json:"runtime,omitempty"`
+
+	// TechPreview holds the values of the Istio helm charts that are not
+	// represented by the fields above.  They are passed to the charts as they
+	// are, so they are not stable across versions.  Fields above take
+	// precedence over the same values specified here.
+	// +optional
+	TechPreview maistrav1.HelmValuesType `json:"techPreview,omitempty"`
+	// ThreeScale holds the values of the 3scale helm charts.
+	// +optional
+	ThreeScale maistrav1.HelmValuesType `json:"threeScale,omitempty"`
+}
+
+// SecurityConfig configures mutual TLS within the mesh
+type SecurityConfig struct {
+	// ControlPlane configures the communication between the control plane
+	// components.
+	// +optional
+	ControlPlane *ControlPlaneSecurityConfig `json:"controlPlane,omitempty"`
+	// DataPlane configures the communication between the proxies.
+	// +optional
+	DataPlane *DataPlaneSecurityConfig `json:"dataPlane,omitempty"`
+}
+
+// ControlPlaneSecurityConfig configures the communication between the control
+// plane components
+type ControlPlaneSecurityConfig struct {
+	// MTLS enables mutual TLS between the control plane components.
+	// +optional
+	MTLS *bool `json:"mtls,omitempty"`
+}
+
+// DataPlaneSecurityConfig configures the communication between the proxies
+type DataPlaneSecurityConfig struct {
+	// MTLS requires mutual TLS for all traffic between the proxies.
+	// +optional
+	MTLS *bool `json:"mtls,omitempty"`
+	// AutoMTLS sends mutual TLS traffic to workloads with proxies and plain
+	// text traffic to workloads without proxies.  Not supported by v1.0.
+	// +optional
+	AutoMTLS *bool `json:"autoMtls,omitempty"`
+}
+
+// TracingConfig configures tracing of the requests in the mesh
+type TracingConfig struct {
+	// Type of tracer.  Defaults to None.
+	// +optional
+	Type TracerType `json:"type,omitempty"`
+	// Sampling is the percentage of requests that are sampled, in units of
+	// 0.01%, i.e. 10000 samples all requests.
+	// +optional
+	Sampling *int32 `json:"sampling,omitempty"`
+}
+
+// TracerType is the type of tracer used by the mesh
+type TracerType string
+
+const (
+	// TracerTypeNone disables tracing
+	TracerTypeNone TracerType = "None"
+	// TracerTypeJaeger installs Jaeger with the control plane
+	TracerTypeJaeger TracerType = "Jaeger"
+)
+
+// GatewaysConfig configures the default gateways
+type GatewaysConfig struct {
+	// Enabled installs the gateways.  Defaults to true.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+	// Ingress configures the default ingress gateway.
+	// +optional
+	Ingress *GatewayConfig `json:"ingress,omitempty"`
+	// Egress configures the default egress gateway.
+	// +optional
+	Egress *GatewayConfig `json:"egress,omitempty"`
+}
+
+// GatewayConfig configures a gateway
+type GatewayConfig struct {
+	// Enabled installs the gateway.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
+// AddonsConfig configures the addons installed with the control plane
+type AddonsConfig struct {
+	// Prometheus configures the Prometheus addon.
+	// +optional
+	Prometheus *AddonConfig `json:"prometheus,omitempty"`
+	// Grafana configures the Grafana addon.
+	// +optional
+	Grafana *AddonConfig `json:"grafana,omitempty"`
+	// Kiali configures the Kiali addon.
+	// +optional
+	Kiali *AddonConfig `json:"kiali,omitempty"`
+}
+
+// AddonConfig configures an addon
+type AddonConfig struct {
+	// Enabled installs the addon.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
+// ControlPlaneRuntimeConfig configures the deployments of the control plane
+// components
+type ControlPlaneRuntimeConfig struct {
+	// Components configures the deployments of individual components, keyed
+	// by component name.
+	// +optional
+	Components map[ControlPlaneComponentName]ComponentRuntimeConfig `json:"components,omitempty"`
+}
+
+// ControlPlaneComponentName identifies a control plane component
+type ControlPlaneComponentName string
+
+const (
+	// ControlPlaneComponentNamePilot is istio-pilot
+	ControlPlaneComponentNamePilot ControlPlaneComponentName = "pilot"
+	// ControlPlaneComponentNameGalley is istio-galley
+	ControlPlaneComponentNameGalley ControlPlaneComponentName = "galley"
+	// ControlPlaneComponentNameCitadel is istio-citadel
+	ControlPlaneComponentNameCitadel ControlPlaneComponentName = "citadel"
+	// ControlPlaneComponentNameSidecarInjector is istio-sidecar-injector
+	ControlPlaneComponentNameSidecarInjector ControlPlaneComponentName = "sidecarInjector"
+	// ControlPlaneComponentNamePolicy is istio-policy
+	ControlPlaneComponentNamePolicy ControlPlaneComponentName = "policy"
+	// ControlPlaneComponentNameTelemetry is istio-telemetry
+	ControlPlaneComponentNameTelemetry ControlPlaneComponentName = "telemetry"
+)
+
+// ComponentRuntimeConfig configures the deployment of a component
+type ComponentRuntimeConfig struct {
+	// ReplicaCount is the number of replicas when autoscaling is disabled.
+	// +optional
+	ReplicaCount *int32 `json:"replicaCount,omitempty"`
+	// Autoscaling configures the horizontal pod autoscaler of the component.
+	// +optional
+	Autoscaling *AutoscalerConfig `json:"autoscaling,omitempty"`
+}
+
+// AutoscalerConfig configures a horizontal pod autoscaler
+type AutoscalerConfig struct {
+	// Enabled creates a horizontal pod autoscaler for the component.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+	// MinReplicas is the lower limit for the number of replicas.
+	// +optional
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+	// MaxReplicas is the upper limit for the number of replicas.
+	// +optional
+	MaxReplicas *int32 `json:"maxReplicas,omitempty"`
+}
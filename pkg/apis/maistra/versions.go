@@ -1,48 +1,44 @@
 package maistra
 
-import "fmt"
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
 
+// Versions are encoded as major*1000+minor, so they are ordered from oldest to
+// newest.
 const (
 	// InvalidVersion is not a valid version
-	InvalidVersion version = iota
+	InvalidVersion version = -1
 	// UndefinedVersion is...undefined
-	UndefinedVersion
+	UndefinedVersion version = 0
 	// V1_0 -> v1.0
-	V1_0
+	V1_0 version = 1000
 	// V1_1 -> v1.1
-	V1_1
+	V1_1 version = 1001
 	// V1_2 -> v1.2
-	V1_2
-	// Add new versions here, above lastKnownVersion. Remember to add a string mapping in init() below
-	lastKnownVersion version = iota - 1
+	V1_2 version = 1002
 )
 
-func init() {
-	versionToString = map[version]string{
-		InvalidVersion:   "InvalidVersion",
-		UndefinedVersion: "",
-		V1_0:             "v1.0",
-		V1_1:             "v1.1",
-		V1_2:             "v1.2",
-	}
-
-	for v, str := range versionToString {
-		if v != InvalidVersion {
-			stringToVersion[str] = v
-			if v != UndefinedVersion {
-				supportedVersions = append(supportedVersions, v)
-			}
-		}
-	}
-}
+// builtinVersions are the versions known to this build.  They are supported
+// until the operator replaces them with the versions found in its resource
+// directory, using SetSupportedVersions().
+var builtinVersions = []Version{V1_0, V1_1, V1_2}
 
 const (
-	// DefaultVersion to use for new resources which have no version specified.
-	DefaultVersion = lastKnownVersion
 	// LegacyVersion to use with existing resources which have no version specified.
 	LegacyVersion = V1_0
 )
 
+var (
+	// DefaultVersion to use for new resources which have no version specified.
+	// This is the newest supported version.
+	DefaultVersion = V1_2
+
+	supportedVersions = builtinVersions
+)
+
 // Version represents a version of a control plane, major.minor, usually
 // identified as something like v1.0.  Version objects are guaranteed to be
 // sequentually ordered from oldest to newest.
@@ -57,18 +53,52 @@ type Version interface {
 	Compare(other Version) int
 }
 
-// GetSupportedVersions returns a list of versions supported by this operator
+// GetSupportedVersions returns a list of versions supported by this operator,
+// ordered from oldest to newest
 func GetSupportedVersions() []Version {
 	return supportedVersions
 }
 
+// SetSupportedVersions replaces the versions supported by this operator, e.g.
+// with the versions shipped in its resource directory.  The newest version
+// becomes the DefaultVersion.
+func SetSupportedVersions(versions []string) error {
+	if len(versions) == 0 {
+		return fmt.Errorf("at least one version must be supported")
+	}
+	parsed := make([]Version, 0, len(versions))
+	seen := map[version]bool{}
+	for _, str := range versions {
+		v, ok := parseVersionString(str)
+		if !ok || v == UndefinedVersion {
+			return fmt.Errorf("invalid version: %s", str)
+		}
+		if seen[v] {
+			return fmt.Errorf("duplicate version: %s", str)
+		}
+		seen[v] = true
+		parsed = append(parsed, v)
+	}
+	sort.Slice(parsed, func(i, j int) bool {
+		return parsed[i].Compare(parsed[j]) < 0
+	})
+	supportedVersions = parsed
+	DefaultVersion = parsed[len(parsed)-1].Version()
+	return nil
+}
+
 type version int
 
 var _ Version = version(0)
 
 func (v version) String() string {
-	if str, ok := versionToString[v]; ok {
-		return str
+	switch {
+	case v == InvalidVersion:
+		return "InvalidVersion"
+	case v == UndefinedVersion:
+		return ""
+	case v > 0:
+		return fmt.Sprintf("v%d.%d", v/1000, v%1000)
 	}
 	panic(fmt.Sprintf("invalid version: %d", v))
 }
@@ -81,14 +111,40 @@ func (v version) Version() version {
 	return v
 }
 
-// ParseVersion returns a version for the specified string
+// ParseVersion returns a version for the specified string.  A patch-level
+// version, e.g. v1.1.3, returns the version it belongs to, i.e. v1.1.  An
+// error is returned if the version is not supported by this operator.
 func ParseVersion(str string) (Version, error) {
-	if v, ok := stringToVersion[str]; ok {
-		return v.Version(), nil
+	if v, ok := parseVersionString(str); ok && (v == UndefinedVersion || isSupported(v)) {
+		return v, nil
 	}
 	return InvalidVersion, fmt.Errorf("invalid version: %s", str)
 }
 
-var versionToString = make(map[version]string)
-var stringToVersion = make(map[string]version)
-var supportedVersions []Version
+var versionRegexp = regexp.MustCompile(`^v(0|[1-9][0-9]{0,2})\.(0|[1-9][0-9]{0,2})(\.(0|[1-9][0-9]*))?$`)
+
+func parseVersionString(str string) (version, bool) {
+	if str == "" {
+		return UndefinedVersion, true
+	}
+	matches := versionRegexp.FindStringSubmatch(str)
+	if matches == nil {
+		return InvalidVersion, false
+	}
+	var major, minor int
+	fmt.Sscan(matches[1], &major)
+	fmt.Sscan(matches[2], &minor)
+	if major == 0 && minor == 0 {
+		return InvalidVersion, false
+	}
+	return version(major*1000 + minor), true
+}
+
+func isSupported(v version) bool {
+	for _, supported := range supportedVersions {
+		if supported.Version() == v {
+			return true
+		}
+	}
+	return false
+}
@@ -5,17 +5,62 @@ import (
 )
 
 func TestStringsDefinedForAllVersions(t *testing.T) {
-	// This test verifies that string mappings have been provided for all defined versions
-	for vint := 0; vint <= int(lastKnownVersion); vint++ {
-		if _, ok := versionToString[version(vint)]; !ok {
-			t.Errorf("no version string defined for version: %d", vint)
+	// This test verifies that all supported versions can be parsed from their string representation
+	for _, v := range GetSupportedVersions() {
+		parsed, err := ParseVersion(v.String())
+		if err != nil {
+			t.Errorf("unexpected error parsing version %s: %v", v.String(), err)
+		} else if parsed != v {
+			t.Errorf("version %s parsed as %s", v.String(), parsed.String())
 		}
 	}
 }
 
 func TestBadVersionString(t *testing.T) {
 	// This test verifies that the parser returns an error for invalid versions
-	if _, err := ParseVersion("InvalidVersion"); err == nil {
-		t.Errorf("ParseVersion() should have returned an error for version InvalidVersion")
+	for _, str := range []string{"InvalidVersion", "v1", "1.0", "v1.01", "v0.0", "v9.9", "v1.1.01", "v1.1.x", "v9.9.1"} {
+		if _, err := ParseVersion(str); err == nil {
+			t.Errorf("ParseVersion() should have returned an error for version %s", str)
+		}
+	}
+}
+
+func TestPatchVersionString(t *testing.T) {
+	// This test verifies that patch-level versions are parsed as the version they belong to
+	for str, expected := range map[string]Version{"v1.0.0": V1_0, "v1.1.3": V1_1, "v1.2.10": V1_2} {
+		parsed, err := ParseVersion(str)
+		if err != nil {
+			t.Errorf("unexpected error parsing version %s: %v", str, err)
+		} else if parsed != expected {
+			t.Errorf("version %s parsed as %s, expected %s", str, parsed.String(), expected.String())
+		}
+	}
+}
+
+func TestSetSupportedVersions(t *testing.T) {
+	defer func() {
+		supportedVersions = builtinVersions
+		DefaultVersion = V1_2
+	}()
+
+	if err := SetSupportedVersions([]string{"v2.0", "v1.1", "v1.10"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var versions []string
+	for _, v := range GetSupportedVersions() {
+		versions = append(versions, v.String())
+	}
+	if len(versions) != 3 || versions[0] != "v1.1" || versions[1] != "v1.10" || versions[2] != "v2.0" {
+		t.Errorf("unexpected supported versions: %v", versions)
+	}
+	if DefaultVersion.String() != "v2.0" {
+		t.Errorf("expected the newest version to be the default, got %s", DefaultVersion.String())
+	}
+	if _, err := ParseVersion("v1.0"); err == nil {
+		t.Errorf("ParseVersion() should have returned an error for version v1.0, which is no longer supported")
+	}
+
+	if err := SetSupportedVersions([]string{"v1.1", "v1.1"}); err == nil {
+		t.Errorf("SetSupportedVersions() should have returned an error for duplicate versions")
 	}
 }
@@ -28,13 +28,14 @@ func internalInstallCNI(ctx context.Context, cl client.Client, config common.CNI
 
 	values := make(map[string]interface{})
 	values["enabled"] = config.Enabled
-	values["image_v1_0"] = config.ImageV1_0
-	values["image_v1_1"] = config.ImageV1_1
+	for value, image := range config.Images {
+		values[value] = image
+	}
 	values["imagePullSecrets"] = config.ImagePullSecrets
 	// TODO: imagePullPolicy, resources
 
 	// always install the latest version of the CNI image
-	renderings, _, err := common.RenderHelmChart(path.Join(common.Options.GetChartsDir(maistra.DefaultVersion.String()), "istio_cni"), operatorNamespace, values)
+	renderings, err := common.RenderHelmChart(path.Join(common.Options.GetChartsDir(maistra.DefaultVersion.String()), "istio_cni"), operatorNamespace, values)
 	if err != nil {
 		return err
 	}
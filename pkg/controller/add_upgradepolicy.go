@@ -0,0 +1,10 @@
+package controller
+
+import (
+	"github.com/maistra/istio-operator/pkg/controller/servicemesh/upgradepolicy"
+)
+
+func init() {
+	// AddToManagerFuncs is a list of functions to create controllers and add them to a manager.
+	AddToManagerFuncs = append(AddToManagerFuncs, upgradepolicy.Add)
+}
@@ -0,0 +1,144 @@
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+// ApplyStrategy determines how existing resources are updated to match the
+// rendered manifests.
+type ApplyStrategy string
+
+const (
+	// ApplyStrategyThreeWayMerge patches the resource with a three-way merge
+	// patch computed from the last applied configuration, which is recorded
+	// in the kubectl.kubernetes.io/last-applied-configuration annotation.
+	ApplyStrategyThreeWayMerge ApplyStrategy = "three-way-merge"
+	// ApplyStrategyServerSide applies the resource using server-side apply,
+	// with FieldManager as the field manager.
+	ApplyStrategyServerSide ApplyStrategy = "server-side"
+	// ApplyStrategyReplace replaces the resource with the rendered resource.
+	ApplyStrategyReplace ApplyStrategy = "replace"
+)
+
+// FieldManager is the name of the field manager used for server-side apply
+const FieldManager = "maistra-istio-operator"
+
+// applyPatchType is the content type of server-side apply patches
+const applyPatchType = types.PatchType("application/apply-patch+yaml")
+
+// ParseApplyStrategy returns the ApplyStrategy with the specified name
+func ParseApplyStrategy(name string) (ApplyStrategy, error) {
+	switch strategy := ApplyStrategy(name); strategy {
+	case ApplyStrategyThreeWayMerge, ApplyStrategyServerSide, ApplyStrategyReplace:
+		return strategy, nil
+	}
+	return "", fmt.Errorf("unknown apply strategy %q, must be one of %q, %q or %q", name, ApplyStrategyThreeWayMerge, ApplyStrategyServerSide, ApplyStrategyReplace)
+}
+
+// GetApplyStrategy returns the strategy specified by the object's
+// maistra.io/apply-strategy annotation, or the strategy configured for the
+// operator, if the object does not specify one.
+func GetApplyStrategy(obj metav1.Object) (ApplyStrategy, error) {
+	if name, ok := GetAnnotation(obj, ApplyStrategyKey); ok {
+		return ParseApplyStrategy(name)
+	}
+	if len(Options.ApplyStrategy) == 0 {
+		return ApplyStrategyThreeWayMerge, nil
+	}
+	return ParseApplyStrategy(Options.ApplyStrategy)
+}
+
+// AllowsRecreate returns true if the object's maistra.io/allow-recreate
+// annotation permits it to be deleted and recreated when an update is
+// rejected, e.g. because immutable fields have changed.
+func AllowsRecreate(obj metav1.Object) bool {
+	allow, ok := GetAnnotation(obj, AllowRecreateKey)
+	return ok && allow == "true"
+}
+
+// serverSideApplier applies objects using server-side apply.  The vendored
+// clients do not support server-side apply, so requests are made using a raw
+// REST client.
+type serverSideApplier struct {
+	client rest.Interface
+	mapper meta.RESTMapper
+}
+
+func newServerSideApplier(config *rest.Config, mapper meta.RESTMapper) (*serverSideApplier, error) {
+	config = rest.CopyConfig(config)
+	// the paths are absolute, so the group version is only used for
+	// serializing parameters
+	config.GroupVersion = &schema.GroupVersion{}
+	config.APIPath = "/"
+	config.AcceptContentTypes = runtime.ContentTypeJSON
+	config.ContentType = runtime.ContentTypeJSON
+	config.NegotiatedSerializer = serializer.DirectCodecFactory{CodecFactory: scheme.Codecs}
+	if config.UserAgent == "" {
+		config.UserAgent = rest.DefaultKubernetesUserAgent()
+	}
+	restClient, err := rest.RESTClientFor(config)
+	if err != nil {
+		return nil, err
+	}
+	return &serverSideApplier{client: restClient, mapper: mapper}, nil
+}
+
+// apply applies obj, taking ownership of any conflicting fields, and returns
+// the resulting object.  The object is created if it does not exist.
+func (a *serverSideApplier) apply(ctx context.Context, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	gvk := obj.GroupVersionKind()
+	mapping, err := a.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, err
+	}
+	segments := []string{"api"}
+	if len(mapping.Resource.Group) > 0 {
+		segments = []string{"apis", mapping.Resource.Group}
+	}
+	segments = append(segments, mapping.Resource.Version)
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		segments = append(segments, "namespaces", obj.GetNamespace())
+	}
+	segments = append(segments, mapping.Resource.Resource, obj.GetName())
+
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	raw, err := a.client.Patch(applyPatchType).
+		Context(ctx).
+		AbsPath(segments...).
+		Param("fieldManager", FieldManager).
+		Param("force", "true").
+		Body(data).
+		Do().
+		Raw()
+	if err != nil {
+		return nil, err
+	}
+	applied := &unstructured.Unstructured{}
+	if err := applied.UnmarshalJSON(raw); err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+type serverSideApplyPatch struct {
+	applier *serverSideApplier
+	obj     *unstructured.Unstructured
+}
+
+func (p *serverSideApplyPatch) Apply(ctx context.Context) (*unstructured.Unstructured, error) {
+	return p.applier.apply(ctx, p.obj)
+}
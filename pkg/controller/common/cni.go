@@ -8,38 +8,25 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
-
-	"github.com/maistra/istio-operator/pkg/apis/maistra"
 )
 
 type CNIConfig struct {
 	// Enabled tells whether this cluster supports CNI or not
 	Enabled bool
 
-	// ImageV1_0 is the full image name that should be deployed through the Istio
-	// CNI DaemonSet for v1.0
-	ImageV1_0 string
-
-	// ImageV1_1 is the full image name that should be deployed through the Istio
-	// CNI DaemonSet for v1.1
-	ImageV1_1 string
+	// Images are the full image names that should be deployed through the
+	// Istio CNI DaemonSet, keyed by the chart value specified in the version
+	// descriptors, e.g. image_v1_1
+	Images map[string]string
 
 	// ImagePullSecrets is the list of image pull secret names for the Istio CNI DaemonSet
 	ImagePullSecrets []string
 }
 
-// networkNameMap is a map of the CNI network name used by each version
-var networkNameMap = map[maistra.Version]string{
-	maistra.UndefinedVersion: "istio-cni",
-	maistra.V1_0:             "istio-cni",
-	maistra.V1_1:             "v1-1-istio-cni",
-	maistra.V1_2:             "v1-1-istio-cni", // TODO: update once v1.2 is ready
-}
-
 // GetCNINetworkName returns the name of the CNI network used to configure routing rules for the mesh
 func GetCNINetworkName(maistraVersion string) (name string, ok bool) {
-	if v, err := maistra.ParseVersion(maistraVersion); err == nil {
-		name, ok = networkNameMap[v.Version()]
+	if descriptor, found := GetVersionDescriptor(maistraVersion); found {
+		name, ok = descriptor.CNI.NetworkName, true
 	}
 	return
 }
@@ -57,12 +44,20 @@ func InitCNIConfig(m manager.Manager) (CNIConfig, error) {
 	if err == nil {
 		config.Enabled = true
 
-		var ok bool
-		if config.ImageV1_0, ok = os.LookupEnv("ISTIO_CNI_IMAGE_V1_0"); !ok {
-			return config, fmt.Errorf("ISTIO_CNI_IMAGE_V1_0 environment variable not set")
+		config.Images = map[string]string{}
+		descriptors, err := GetVersionDescriptors()
+		if err != nil {
+			return config, err
 		}
-		if config.ImageV1_1, ok = os.LookupEnv("ISTIO_CNI_IMAGE_V1_1"); !ok {
-			return config, fmt.Errorf("ISTIO_CNI_IMAGE_V1_1 environment variable not set")
+		for _, descriptor := range descriptors {
+			if descriptor.CNI.ImageEnv == "" {
+				continue
+			}
+			image, ok := os.LookupEnv(descriptor.CNI.ImageEnv)
+			if !ok {
+				return config, fmt.Errorf("%s environment variable not set", descriptor.CNI.ImageEnv)
+			}
+			config.Images[descriptor.CNI.ImageValue] = image
 		}
 
 		secret, _ := os.LookupEnv("ISTIO_CNI_IMAGE_PULL_SECRET")
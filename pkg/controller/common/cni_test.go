@@ -7,9 +7,13 @@ import (
 )
 
 func TestNetworkNameMap(t *testing.T) {
-    for _, v := range maistra.GetSupportedVersions() {
-        if _, ok := networkNameMap[v]; !ok {
-            t.Errorf("missing network name for control plane version %s", v.String())
-        }
-    }
+	useRepositoryVersionDescriptors(t)
+	for _, v := range maistra.GetSupportedVersions() {
+		if _, ok := GetCNINetworkName(v.String()); !ok {
+			t.Errorf("missing network name for control plane version %s", v.String())
+		}
+	}
+	if name, _ := GetCNINetworkName(""); name != "istio-cni" {
+		t.Errorf("expected network name of legacy version for control planes without version, got %q", name)
+	}
 }
@@ -0,0 +1,118 @@
+package common
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	v1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+)
+
+// SetContentHash records a hash of the desired content of obj, as applied
+// using the strategy, in its maistra.io/content-hash annotation.  Any hash
+// already recorded on obj is ignored when computing the hash, as is the mesh
+// generation, which changes with every generation of the control plane, so an
+// object whose content is unchanged is not updated merely to record the new
+// generation.
+func SetContentHash(obj *unstructured.Unstructured, strategy ApplyStrategy) (string, error) {
+	DeleteAnnotation(obj, ContentHashKey)
+	content := obj
+	if _, ok := GetAnnotation(obj, MeshGenerationKey); ok {
+		content = obj.DeepCopy()
+		DeleteAnnotation(content, MeshGenerationKey)
+	}
+	data, err := json.Marshal(content.Object)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	hash.Write([]byte(strategy))
+	hash.Write([]byte{0})
+	hash.Write(data)
+	contentHash := hex.EncodeToString(hash.Sum(nil))
+	SetAnnotation(obj, ContentHashKey, contentHash)
+	return contentHash, nil
+}
+
+// appliedObjects records the content hash and resource version of the objects
+// applied by the operator, so objects that have neither been changed by the
+// operator nor by anybody else since they were last applied can be skipped.
+// Records are removed when the objects are deleted, or when the control plane
+// owning them is deleted.
+type appliedObjects struct {
+	mu      sync.Mutex
+	records map[v1.ResourceKey]appliedObject
+}
+
+type appliedObject struct {
+	contentHash     string
+	resourceVersion string
+	// owner is the mesh namespace of the control plane owning the object
+	owner string
+}
+
+func newAppliedObjects() *appliedObjects {
+	return &appliedObjects{records: map[v1.ResourceKey]appliedObject{}}
+}
+
+// record records the content hash and resource version of obj, which has just
+// been applied.  Objects without a content hash are forgotten.
+func (a *appliedObjects) record(obj *unstructured.Unstructured) {
+	if a == nil || obj == nil {
+		return
+	}
+	key := v1.NewResourceKey(obj, obj)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	contentHash, ok := GetAnnotation(obj, ContentHashKey)
+	if !ok || obj.GetResourceVersion() == "" {
+		delete(a.records, key)
+		return
+	}
+	owner, _ := GetLabel(obj, OwnerKey)
+	a.records[key] = appliedObject{contentHash: contentHash, resourceVersion: obj.GetResourceVersion(), owner: owner}
+}
+
+// forget removes the record of the object identified by key, e.g. because the
+// object has been deleted.
+func (a *appliedObjects) forget(key v1.ResourceKey) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.records, key)
+}
+
+// forgetOwnedBy removes the records of the objects owned by the control planes
+// in the mesh namespace.
+func (a *appliedObjects) forgetOwnedBy(owner string) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for key, record := range a.records {
+		if record.owner == owner {
+			delete(a.records, key)
+		}
+	}
+}
+
+// upToDate returns true if current has not been modified since it was last
+// applied with the specified content hash.
+func (a *appliedObjects) upToDate(current *unstructured.Unstructured, contentHash string) bool {
+	if a == nil {
+		return false
+	}
+	if currentHash, ok := GetAnnotation(current, ContentHashKey); !ok || currentHash != contentHash {
+		return false
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	record, ok := a.records[v1.NewResourceKey(current, current)]
+	return ok && record.contentHash == contentHash && record.resourceVersion == current.GetResourceVersion()
+}
@@ -0,0 +1,73 @@
+package common
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/maistra/istio-operator/pkg/controller/common/test/assert"
+)
+
+func TestContentHashIgnoresMeshGeneration(t *testing.T) {
+	first := newHashedConfigMap("istio", "istio-system", "1")
+	SetAnnotation(first, MeshGenerationKey, "1.1.0-1")
+	second := newHashedConfigMap("istio", "istio-system", "1")
+	SetAnnotation(second, MeshGenerationKey, "1.1.0-2")
+
+	firstHash, err := SetContentHash(first, ApplyStrategyThreeWayMerge)
+	if err != nil {
+		t.Fatalf("unexpected error computing content hash: %v", err)
+	}
+	secondHash, err := SetContentHash(second, ApplyStrategyThreeWayMerge)
+	if err != nil {
+		t.Fatalf("unexpected error computing content hash: %v", err)
+	}
+	assert.Equals(secondHash, firstHash, "Expected the mesh generation not to affect the content hash", t)
+	generation, _ := GetAnnotation(second, MeshGenerationKey)
+	assert.Equals(generation, "1.1.0-2", "Expected the mesh generation to be retained", t)
+}
+
+func TestAppliedObjectsAreForgotten(t *testing.T) {
+	factory := NewPatchFactory(nil)
+	pilot := newHashedConfigMap("pilot", "istio-system", "1")
+	galley := newHashedConfigMap("galley", "istio-system", "1")
+	other := newHashedConfigMap("pilot", "other-system", "1")
+	for _, obj := range []*unstructured.Unstructured{pilot, galley, other} {
+		factory.RecordApplied(obj)
+	}
+	isUpToDate := func(obj *unstructured.Unstructured) bool {
+		contentHash, _ := GetAnnotation(obj, ContentHashKey)
+		return factory.IsUpToDate(obj, contentHash)
+	}
+
+	factory.ForgetApplied(pilot)
+	assert.False(isUpToDate(pilot), "Expected the deleted object to be forgotten", t)
+	assert.True(isUpToDate(galley), "Expected other objects to be remembered", t)
+
+	factory.ForgetAppliedOwnedBy("istio-system")
+	assert.False(isUpToDate(galley), "Expected the objects of the deleted control plane to be forgotten", t)
+	assert.True(isUpToDate(other), "Expected the objects of other control planes to be remembered", t)
+	assert.Equals(len(factory.applied.records), 1, "Unexpected number of records", t)
+}
+
+func newHashedConfigMap(name, owner, resourceVersion string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name":            name,
+				"namespace":       owner,
+				"resourceVersion": resourceVersion,
+				"labels": map[string]interface{}{
+					OwnerKey: owner,
+				},
+			},
+			"data": map[string]interface{}{
+				"value": "a",
+			},
+		},
+	}
+	SetContentHash(obj, ApplyStrategyThreeWayMerge)
+	return obj
+}
@@ -0,0 +1,89 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+)
+
+// sidecarStatusAnnotation is added to pods by the sidecar injector
+const sidecarStatusAnnotation = "sidecar.istio.io/status"
+
+// HasDeletionProtection returns true if the control plane's
+// maistra.io/deletion-protection annotation prevents it from being deleted
+// while the mesh has members.
+func HasDeletionProtection(smcp *v1.ServiceMeshControlPlane) bool {
+	protected, ok := GetAnnotation(smcp, DeletionProtectionKey)
+	return ok && protected == "true"
+}
+
+// FindDeletionBlockers returns descriptions of the members that must be
+// removed from the mesh before the control plane can be deleted: the
+// namespaces configured as members of the mesh and the namespaces still
+// labeled as members of the mesh in which pods with injected sidecars are
+// running.
+func FindDeletionBlockers(ctx context.Context, cl client.Client, smcp *v1.ServiceMeshControlPlane) ([]string, error) {
+	members := []string{}
+	configured := sets.NewString()
+	memberRoll := &v1.ServiceMeshMemberRoll{}
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: smcp.Namespace, Name: MemberRollName}, memberRoll); err == nil {
+		members = append(members, memberRoll.Status.ConfiguredMembers...)
+		configured.Insert(memberRoll.Status.ConfiguredMembers...)
+	} else if !errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	namespaces := &corev1.NamespaceList{}
+	if err := cl.List(ctx, client.MatchingLabels(map[string]string{MemberOfKey: smcp.Namespace}), namespaces); err != nil {
+		return nil, err
+	}
+	for _, namespace := range namespaces.Items {
+		if !configured.Has(namespace.Name) {
+			members = append(members, namespace.Name)
+		}
+	}
+
+	blockers := []string{}
+	for _, member := range members {
+		sidecars, err := countSidecars(ctx, cl, member)
+		if err != nil {
+			return nil, err
+		}
+		if sidecars > 0 {
+			blockers = append(blockers, fmt.Sprintf("namespace %s (%d pods with injected sidecars)", member, sidecars))
+		} else if configured.Has(member) {
+			blockers = append(blockers, fmt.Sprintf("namespace %s", member))
+		}
+	}
+	return blockers, nil
+}
+
+// countSidecars returns the number of pods with injected sidecars that are
+// running in the namespace
+func countSidecars(ctx context.Context, cl client.Client, namespace string) (int, error) {
+	pods := &corev1.PodList{}
+	if err := cl.List(ctx, client.InNamespace(namespace), pods); err != nil {
+		return 0, err
+	}
+	sidecars := 0
+	for _, pod := range pods.Items {
+		if pod.Annotations[sidecarStatusAnnotation] != "" && pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed {
+			sidecars++
+		}
+	}
+	return sidecars, nil
+}
+
+// DeletionBlockedMessage returns the message explaining why the control plane
+// cannot be deleted
+func DeletionBlockedMessage(smcp *v1.ServiceMeshControlPlane, blockers []string) string {
+	return fmt.Sprintf("ServiceMeshControlPlane %s/%s is protected from deletion while the mesh has members; remove the following members from ServiceMeshMemberRoll %s/%s and their sidecars first, or remove the %s annotation: %s",
+		smcp.Namespace, smcp.Name, smcp.Namespace, MemberRollName, DeletionProtectionKey, strings.Join(blockers, ", "))
+}
@@ -0,0 +1,219 @@
+package common
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	v1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+)
+
+// DiffAction describes how a resource differs between two renderings
+type DiffAction string
+
+const (
+	// DiffActionAdded means the resource is only part of the new rendering
+	DiffActionAdded DiffAction = "Added"
+	// DiffActionRemoved means the resource is only part of the old rendering
+	DiffActionRemoved DiffAction = "Removed"
+	// DiffActionChanged means the resource differs between the renderings
+	DiffActionChanged DiffAction = "Changed"
+)
+
+// ResourceDiff describes the difference of a resource between two renderings.
+// Fields lists the changed fields of the resource types compared field by
+// field: Deployments, ConfigMaps and webhook configurations.
+type ResourceDiff struct {
+	Resource v1.ResourceKey
+	Action   DiffAction
+	Fields   []FieldDiff
+}
+
+// FieldDiff describes a changed field.  Old is nil if the field has been
+// added and New is nil if the field has been removed.
+type FieldDiff struct {
+	Path string
+	Old  interface{}
+	New  interface{}
+}
+
+// fieldDiffKinds lists the types whose changes are reported field by field
+var fieldDiffKinds = map[schema.GroupKind]bool{
+	schema.GroupKind{Group: "apps", Kind: "Deployment"}:                                             true,
+	schema.GroupKind{Group: "extensions", Kind: "Deployment"}:                                       true,
+	schema.GroupKind{Group: "", Kind: "ConfigMap"}:                                                  true,
+	schema.GroupKind{Group: "admissionregistration.k8s.io", Kind: "MutatingWebhookConfiguration"}:   true,
+	schema.GroupKind{Group: "admissionregistration.k8s.io", Kind: "ValidatingWebhookConfiguration"}: true,
+}
+
+// DiffResources compares the resources of two renderings and returns the
+// resources that have been added, removed or changed, sorted by resource key.
+// The content hash annotation, which changes whenever anything else changes,
+// is ignored.
+func DiffResources(oldObjects, newObjects []*unstructured.Unstructured) []ResourceDiff {
+	oldByKey := objectsByKey(oldObjects)
+	newByKey := objectsByKey(newObjects)
+
+	diffs := []ResourceDiff{}
+	for key, oldObj := range oldByKey {
+		newObj, ok := newByKey[key]
+		if !ok {
+			diffs = append(diffs, ResourceDiff{Resource: key, Action: DiffActionRemoved})
+			continue
+		}
+		oldContent := withoutContentHash(oldObj)
+		newContent := withoutContentHash(newObj)
+		if reflect.DeepEqual(oldContent, newContent) {
+			continue
+		}
+		diff := ResourceDiff{Resource: key, Action: DiffActionChanged}
+		if fieldDiffKinds[oldObj.GroupVersionKind().GroupKind()] {
+			if oldObj.GetKind() == "ConfigMap" {
+				parseConfigMapData(oldContent)
+				parseConfigMapData(newContent)
+			}
+			diffValues("", oldContent, newContent, &diff.Fields)
+		}
+		diffs = append(diffs, diff)
+	}
+	for key := range newByKey {
+		if _, ok := oldByKey[key]; !ok {
+			diffs = append(diffs, ResourceDiff{Resource: key, Action: DiffActionAdded})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Resource < diffs[j].Resource
+	})
+	return diffs
+}
+
+func objectsByKey(objects []*unstructured.Unstructured) map[v1.ResourceKey]*unstructured.Unstructured {
+	byKey := make(map[v1.ResourceKey]*unstructured.Unstructured, len(objects))
+	for _, obj := range objects {
+		byKey[v1.NewResourceKey(obj, obj)] = obj
+	}
+	return byKey
+}
+
+func withoutContentHash(obj *unstructured.Unstructured) map[string]interface{} {
+	copied := obj.DeepCopy()
+	DeleteAnnotation(copied, ContentHashKey)
+	return copied.UnstructuredContent()
+}
+
+// parseConfigMapData replaces the values in the data of a ConfigMap that hold
+// YAML or JSON documents, e.g. the mesh configuration, with the parsed
+// documents, so changes are reported for the individual settings.
+func parseConfigMapData(content map[string]interface{}) {
+	data, ok, _ := unstructured.NestedMap(content, "data")
+	if !ok {
+		return
+	}
+	for key, value := range data {
+		text, ok := value.(string)
+		if !ok {
+			continue
+		}
+		parsed := map[string]interface{}{}
+		if err := yaml.Unmarshal([]byte(text), &parsed); err == nil && len(parsed) > 0 {
+			data[key] = parsed
+		}
+	}
+	content["data"] = data
+}
+
+// diffValues records the differences between the old and new values in
+// diffs.  Maps are compared key by key and lists of named items, e.g.
+// containers or webhooks, are compared item by item.
+func diffValues(path string, oldValue, newValue interface{}, diffs *[]FieldDiff) {
+	if reflect.DeepEqual(oldValue, newValue) {
+		return
+	}
+	switch oldTyped := oldValue.(type) {
+	case map[string]interface{}:
+		if newTyped, ok := newValue.(map[string]interface{}); ok {
+			keys := make([]string, 0, len(oldTyped)+len(newTyped))
+			for key := range oldTyped {
+				keys = append(keys, key)
+			}
+			for key := range newTyped {
+				if _, ok := oldTyped[key]; !ok {
+					keys = append(keys, key)
+				}
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				diffValues(fieldPath(path, key), oldTyped[key], newTyped[key], diffs)
+			}
+			return
+		}
+	case []interface{}:
+		if newTyped, ok := newValue.([]interface{}); ok {
+			oldNamed, oldOK := namedItems(oldTyped)
+			newNamed, newOK := namedItems(newTyped)
+			if oldOK && newOK {
+				names := []string{}
+				for _, item := range oldTyped {
+					names = append(names, itemName(item))
+				}
+				for _, item := range newTyped {
+					if _, ok := oldNamed[itemName(item)]; !ok {
+						names = append(names, itemName(item))
+					}
+				}
+				for _, name := range names {
+					diffValues(fmt.Sprintf("%s[name=%s]", path, name), oldNamed[name], newNamed[name], diffs)
+				}
+				return
+			} else if len(oldTyped) == len(newTyped) {
+				for index := range oldTyped {
+					diffValues(fmt.Sprintf("%s[%d]", path, index), oldTyped[index], newTyped[index], diffs)
+				}
+				return
+			}
+		}
+	}
+	*diffs = append(*diffs, FieldDiff{Path: path, Old: oldValue, New: newValue})
+}
+
+// namedItems returns the items of the list by name, if all of the items have
+// a unique name
+func namedItems(items []interface{}) (map[string]interface{}, bool) {
+	named := make(map[string]interface{}, len(items))
+	for _, item := range items {
+		name := itemName(item)
+		if name == "" {
+			return nil, false
+		}
+		if _, ok := named[name]; ok {
+			return nil, false
+		}
+		named[name] = item
+	}
+	return named, true
+}
+
+func itemName(item interface{}) string {
+	if itemMap, ok := item.(map[string]interface{}); ok {
+		if name, ok := itemMap["name"].(string); ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// fieldPath appends the key to the path, quoting keys that contain
+// separators, e.g. label names
+func fieldPath(path, key string) string {
+	if strings.ContainsAny(key, "./[]") {
+		return fmt.Sprintf("%s[%s]", path, key)
+	} else if path == "" {
+		return key
+	}
+	return path + "." + key
+}
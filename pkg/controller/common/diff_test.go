@@ -0,0 +1,130 @@
+package common
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/maistra/istio-operator/pkg/controller/common/test/assert"
+)
+
+func TestDiffResources(t *testing.T) {
+	testCases := []struct {
+		name     string
+		old      []*unstructured.Unstructured
+		new      []*unstructured.Unstructured
+		expected []ResourceDiff
+	}{
+		{
+			name:     "unchanged",
+			old:      []*unstructured.Unstructured{newDiffDeployment("istio-pilot", "pilot:1.1", "abc")},
+			new:      []*unstructured.Unstructured{newDiffDeployment("istio-pilot", "pilot:1.1", "def")},
+			expected: []ResourceDiff{},
+		},
+		{
+			name: "added-and-removed",
+			old:  []*unstructured.Unstructured{newDiffDeployment("istio-pilot", "pilot:1.1", "")},
+			new:  []*unstructured.Unstructured{newDiffDeployment("istiod", "pilot:1.1", "")},
+			expected: []ResourceDiff{
+				{Resource: "istio-system/istio-pilot=apps/v1,Kind=Deployment", Action: DiffActionRemoved},
+				{Resource: "istio-system/istiod=apps/v1,Kind=Deployment", Action: DiffActionAdded},
+			},
+		},
+		{
+			name: "deployment-container",
+			old:  []*unstructured.Unstructured{newDiffDeployment("istio-pilot", "pilot:1.1", "")},
+			new:  []*unstructured.Unstructured{newDiffDeployment("istio-pilot", "pilot:1.2", "")},
+			expected: []ResourceDiff{
+				{
+					Resource: "istio-system/istio-pilot=apps/v1,Kind=Deployment",
+					Action:   DiffActionChanged,
+					Fields: []FieldDiff{
+						{Path: "spec.template.spec.containers[name=discovery].image", Old: "pilot:1.1", New: "pilot:1.2"},
+					},
+				},
+			},
+		},
+		{
+			name: "configmap-data",
+			old:  []*unstructured.Unstructured{newDiffConfigMap("istio", "disablePolicyChecks: true\nenableTracing: true\n")},
+			new:  []*unstructured.Unstructured{newDiffConfigMap("istio", "disablePolicyChecks: false\nenableTracing: true\n")},
+			expected: []ResourceDiff{
+				{
+					Resource: "istio-system/istio=v1,Kind=ConfigMap",
+					Action:   DiffActionChanged,
+					Fields: []FieldDiff{
+						{Path: "data.mesh.disablePolicyChecks", Old: true, New: false},
+					},
+				},
+			},
+		},
+		{
+			name: "other-kind",
+			old:  []*unstructured.Unstructured{newDiffService("istio-pilot", int64(8080))},
+			new:  []*unstructured.Unstructured{newDiffService("istio-pilot", int64(15010))},
+			expected: []ResourceDiff{
+				{Resource: "istio-system/istio-pilot=v1,Kind=Service", Action: DiffActionChanged},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.DeepEquals(DiffResources(tc.old, tc.new), tc.expected, "Unexpected differences", t)
+		})
+	}
+}
+
+func newDiffDeployment(name, image, contentHash string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": "istio-system",
+		},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "discovery", "image": image},
+						map[string]interface{}{"name": "istio-proxy", "image": "proxy:1.1"},
+					},
+				},
+			},
+		},
+	}}
+	if contentHash != "" {
+		SetAnnotation(obj, ContentHashKey, contentHash)
+	}
+	return obj
+}
+
+func newDiffConfigMap(name, mesh string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": "istio-system",
+		},
+		"data": map[string]interface{}{
+			"mesh": mesh,
+		},
+	}}
+}
+
+func newDiffService(name string, port int64) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": "istio-system",
+		},
+		"spec": map[string]interface{}{
+			"ports": []interface{}{
+				map[string]interface{}{"port": port},
+			},
+		},
+	}}
+}
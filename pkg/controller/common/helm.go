@@ -1,16 +1,15 @@
 package common
 
 import (
-	"encoding/json"
 	"fmt"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/ghodss/yaml"
 	"k8s.io/helm/pkg/chartutil"
 	"k8s.io/helm/pkg/manifest"
 	"k8s.io/helm/pkg/proto/hapi/chart"
-	"k8s.io/helm/pkg/proto/hapi/release"
 	"k8s.io/helm/pkg/renderutil"
 	"k8s.io/helm/pkg/tiller"
 	"k8s.io/helm/pkg/timeconv"
@@ -45,19 +44,43 @@ type options struct {
 
 	// Then maximum rate of API requests when throttling is active
 	QPS float32
+
+	// The ApplyStrategy used for resources that do not specify one
+	ApplyStrategy string
+
+	// The maximum number of rendered charts kept in the render cache.  Zero
+	// disables the cache.
+	RenderCacheSize int
+	// The directory in which rendered charts are persisted, so they survive
+	// restarts of the operator's process.  Renderings are only kept in memory
+	// if empty.
+	RenderCacheDir string
+
+	// The interval at which resources belonging to deleted control planes are
+	// swept.  Zero disables sweeping.
+	OrphanSweepInterval time.Duration
+	// Orphaned resources are only reported if true, which is the default;
+	// they are only deleted if false
+	OrphanSweepDryRun bool
+
+	// The interval at which ServiceMeshUpgradeChecks are repeated and
+	// available upgrades of control planes are checked
+	UpgradeCheckInterval time.Duration
 }
 
 var Options = &options{}
 
 // GetChartsDir returns the location of the Helm charts. Similar layout to istio.io/istio/install/kubernetes/helm.
+// The version is resolved to the release shipped with the operator, see ResolveVersion().
 func (o *options) GetChartsDir(maistraVersion string) string {
-	if len(maistraVersion) == 0 {
-		maistraVersion = maistra.LegacyVersion.String()
-	}
+	return path.Join(o.getChartsRoot(), versionDir(maistraVersion))
+}
+
+func (o *options) getChartsRoot() string {
 	if len(o.ChartsDir) == 0 {
-		return path.Join(o.ResourceDir, "helm", maistraVersion)
+		return path.Join(o.ResourceDir, "helm")
 	}
-	return path.Join(o.ChartsDir, maistraVersion)
+	return o.ChartsDir
 }
 
 // GetTemplatesDir returns the location of the Operator templates files
@@ -68,28 +91,51 @@ func (o *options) GetUserTemplatesDir() string {
 	return o.UserTemplatesDir
 }
 
-// GetDefaultTemplatesDir returns the location of the Default Operator templates files
+// GetDefaultTemplatesDir returns the location of the Default Operator templates files.
+// The version is resolved to the release shipped with the operator, see ResolveVersion().
 func (o *options) GetDefaultTemplatesDir(maistraVersion string) string {
-	if len(maistraVersion) == 0 {
-		maistraVersion = maistra.LegacyVersion.String()
-	}
+	return path.Join(o.getDefaultTemplatesRoot(), versionDir(maistraVersion))
+}
+
+func (o *options) getDefaultTemplatesRoot() string {
 	if len(o.DefaultTemplatesDir) == 0 {
-		return path.Join(o.ResourceDir, "default-templates", maistraVersion)
+		return path.Join(o.ResourceDir, "default-templates")
+	}
+	return o.DefaultTemplatesDir
+}
+
+// versionDir returns the name of the directory containing the resources of
+// the version.  Versions that cannot be resolved are used as is, so a missing
+// directory is reported when the resources are read.
+func versionDir(maistraVersion string) string {
+	if release, err := ResolveVersion(maistraVersion); err == nil {
+		return release
 	}
-	return path.Join(o.DefaultTemplatesDir, maistraVersion)
+	if len(maistraVersion) == 0 {
+		return maistra.LegacyVersion.String()
+	}
+	return maistraVersion
 }
 
 // RenderHelmChart renders the helm charts, returning a map of rendered templates.
 // key names represent the chart from which the template was processed.  Subcharts
 // will be keyed as <root-name>/charts/<subchart-name>, e.g. istio/charts/galley.
-// The root chart would be simply, istio.
-func RenderHelmChart(chartPath string, namespace string, values interface{}) (map[string][]manifest.Manifest, map[string]interface{}, error) {
+// The root chart would be simply, istio.  Renderings are reused from the render
+// cache if neither the chart nor the values have changed.
+func RenderHelmChart(chartPath string, namespace string, values interface{}) (map[string][]manifest.Manifest, error) {
+	return getRenderCache().Render(chartPath, namespace, values)
+}
+
+func renderHelmChart(chartPath string, namespace string, values interface{}) (map[string][]manifest.Manifest, error) {
 	rawVals, err := yaml.Marshal(values)
+	if err != nil {
+		return map[string][]manifest.Manifest{}, err
+	}
 	config := &chart.Config{Raw: string(rawVals), Values: map[string]*chart.Value{}}
 
 	c, err := chartutil.Load(chartPath)
 	if err != nil {
-		return map[string][]manifest.Manifest{}, nil, err
+		return map[string][]manifest.Manifest{}, err
 	}
 
 	renderOpts := renderutil.Options{
@@ -106,22 +152,10 @@ func RenderHelmChart(chartPath string, namespace string, values interface{}) (ma
 	}
 	renderedTemplates, err := renderutil.Render(c, config, renderOpts)
 	if err != nil {
-		return map[string][]manifest.Manifest{}, nil, err
+		return map[string][]manifest.Manifest{}, err
 	}
 
-	rel := &release.Release{
-		Name:      renderOpts.ReleaseOptions.Name,
-		Chart:     c,
-		Config:    config,
-		Namespace: namespace,
-		Info:      &release.Info{LastDeployed: renderOpts.ReleaseOptions.Time},
-	}
-	rawRel := map[string]interface{}{}
-	data, err := json.Marshal(rel)
-	if err == nil {
-		err = json.Unmarshal(data, &rawRel)
-	}
-	return sortManifestsByChart(manifest.SplitManifests(renderedTemplates)), rawRel, err
+	return sortManifestsByChart(manifest.SplitManifests(renderedTemplates)), nil
 }
 
 // sortManifestsByChart returns a map of chart->[]manifest.  names for subcharts
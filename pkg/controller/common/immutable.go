@@ -0,0 +1,66 @@
+package common
+
+import (
+	"reflect"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// immutableFields lists the fields, by type, that cannot be modified once a
+// resource has been created.  Changing any of these requires the resource to be
+// deleted and recreated.
+var immutableFields = map[schema.GroupKind][][]string{
+	schema.GroupKind{Group: "", Kind: "Service"}: {
+		{"spec", "clusterIP"},
+	},
+	schema.GroupKind{Group: "", Kind: "Secret"}: {
+		{"type"},
+	},
+	schema.GroupKind{Group: "", Kind: "PersistentVolumeClaim"}: {
+		{"spec", "accessModes"},
+		{"spec", "storageClassName"},
+		{"spec", "volumeName"},
+	},
+	schema.GroupKind{Group: "apps", Kind: "Deployment"}: {
+		{"spec", "selector"},
+	},
+	schema.GroupKind{Group: "apps", Kind: "DaemonSet"}: {
+		{"spec", "selector"},
+	},
+	schema.GroupKind{Group: "apps", Kind: "StatefulSet"}: {
+		{"spec", "selector"},
+		{"spec", "serviceName"},
+		{"spec", "podManagementPolicy"},
+		{"spec", "volumeClaimTemplates"},
+	},
+	schema.GroupKind{Group: "batch", Kind: "Job"}: {
+		{"spec", "selector"},
+		{"spec", "template"},
+	},
+	schema.GroupKind{Group: "rbac.authorization.k8s.io", Kind: "RoleBinding"}: {
+		{"roleRef"},
+	},
+	schema.GroupKind{Group: "rbac.authorization.k8s.io", Kind: "ClusterRoleBinding"}: {
+		{"roleRef"},
+	},
+}
+
+// ImmutableFieldsChanged returns the paths of any immutable fields that differ
+// between the current and updated versions of an object, e.g. spec.selector.
+func ImmutableFieldsChanged(current, updated *unstructured.Unstructured) []string {
+	fields, ok := immutableFields[current.GroupVersionKind().GroupKind()]
+	if !ok {
+		return nil
+	}
+	changed := []string{}
+	for _, field := range fields {
+		currentValue, currentFound, _ := unstructured.NestedFieldNoCopy(current.UnstructuredContent(), field...)
+		updatedValue, updatedFound, _ := unstructured.NestedFieldNoCopy(updated.UnstructuredContent(), field...)
+		if currentFound != updatedFound || !reflect.DeepEqual(currentValue, updatedValue) {
+			changed = append(changed, strings.Join(field, "."))
+		}
+	}
+	return changed
+}
@@ -0,0 +1,94 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestImmutableFieldsChanged(t *testing.T) {
+	testCases := []struct {
+		name     string
+		current  map[string]interface{}
+		updated  map[string]interface{}
+		expected []string
+	}{
+		{
+			name: "mutable field changed",
+			current: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"spec": map[string]interface{}{
+					"replicas": int64(1),
+					"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "pilot"}},
+				},
+			},
+			updated: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"spec": map[string]interface{}{
+					"replicas": int64(2),
+					"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "pilot"}},
+				},
+			},
+			expected: []string{},
+		},
+		{
+			name: "selector changed",
+			current: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"spec": map[string]interface{}{
+					"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "pilot"}},
+				},
+			},
+			updated: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"spec": map[string]interface{}{
+					"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "istio-pilot"}},
+				},
+			},
+			expected: []string{"spec.selector"},
+		},
+		{
+			name: "clusterIP removed",
+			current: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Service",
+				"spec": map[string]interface{}{
+					"clusterIP": "10.0.0.1",
+				},
+			},
+			updated: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Service",
+				"spec":       map[string]interface{}{},
+			},
+			expected: []string{"spec.clusterIP"},
+		},
+		{
+			name: "unknown type",
+			current: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"data":       map[string]interface{}{"a": "b"},
+			},
+			updated: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"data":       map[string]interface{}{"a": "c"},
+			},
+			expected: nil,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			changed := ImmutableFieldsChanged(&unstructured.Unstructured{Object: tc.current}, &unstructured.Unstructured{Object: tc.updated})
+			if !reflect.DeepEqual(changed, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, changed)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package common
+
+import (
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	v1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+)
+
+// Inventory records the keys of the resources processed by a
+// ManifestProcessor, in the order in which they were processed.
+type Inventory struct {
+	keys []v1.ResourceKey
+	seen sets.String
+}
+
+// NewInventory returns a new, empty Inventory
+func NewInventory() *Inventory {
+	return &Inventory{seen: sets.NewString()}
+}
+
+// Add records the resource identified by key.  Keys that have already been
+// recorded retain their position.
+func (i *Inventory) Add(key v1.ResourceKey) {
+	if i == nil || i.seen.Has(string(key)) {
+		return
+	}
+	i.seen.Insert(string(key))
+	i.keys = append(i.keys, key)
+}
+
+// Has returns true if the resource identified by key has been recorded
+func (i *Inventory) Has(key v1.ResourceKey) bool {
+	return i != nil && i.seen.Has(string(key))
+}
+
+// Keys returns the recorded keys, in the order in which they were added
+func (i *Inventory) Keys() []v1.ResourceKey {
+	if i == nil {
+		return nil
+	}
+	return append([]v1.ResourceKey{}, i.keys...)
+}
+
+// Removed returns the keys in previous that have not been recorded, in the
+// reverse order of previous, i.e. resources that were applied last are
+// returned first.
+func (i *Inventory) Removed(previous []v1.ResourceKey) []v1.ResourceKey {
+	removed := []v1.ResourceKey{}
+	for index := len(previous) - 1; index >= 0; index-- {
+		if !i.Has(previous[index]) {
+			removed = append(removed, previous[index])
+		}
+	}
+	return removed
+}
+
+// MergeInventory returns previous with the keys recorded by inventory that
+// are not already part of it appended to it.
+func MergeInventory(previous []v1.ResourceKey, inventory *Inventory) []v1.ResourceKey {
+	existing := sets.NewString()
+	for _, key := range previous {
+		existing.Insert(string(key))
+	}
+	merged := append([]v1.ResourceKey{}, previous...)
+	for _, key := range inventory.Keys() {
+		if !existing.Has(string(key)) {
+			merged = append(merged, key)
+		}
+	}
+	return merged
+}
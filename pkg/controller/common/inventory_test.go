@@ -0,0 +1,31 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+)
+
+func TestInventory(t *testing.T) {
+	inventory := NewInventory()
+	inventory.Add("istio-system/b=v1,Kind=Service")
+	inventory.Add("istio-system/a=v1,Kind=ConfigMap")
+	inventory.Add("istio-system/b=v1,Kind=Service")
+
+	expectedKeys := []v1.ResourceKey{"istio-system/b=v1,Kind=Service", "istio-system/a=v1,Kind=ConfigMap"}
+	if keys := inventory.Keys(); !reflect.DeepEqual(keys, expectedKeys) {
+		t.Errorf("unexpected keys: expected %v, got %v", expectedKeys, keys)
+	}
+
+	previous := []v1.ResourceKey{"istio-system/c=v1,Kind=Secret", "istio-system/a=v1,Kind=ConfigMap", "istio-system/d=v1,Kind=Secret"}
+	expectedRemoved := []v1.ResourceKey{"istio-system/d=v1,Kind=Secret", "istio-system/c=v1,Kind=Secret"}
+	if removed := inventory.Removed(previous); !reflect.DeepEqual(removed, expectedRemoved) {
+		t.Errorf("unexpected removed keys: expected %v, got %v", expectedRemoved, removed)
+	}
+
+	expectedMerged := []v1.ResourceKey{"istio-system/c=v1,Kind=Secret", "istio-system/a=v1,Kind=ConfigMap", "istio-system/d=v1,Kind=Secret", "istio-system/b=v1,Kind=Service"}
+	if merged := MergeInventory(previous, inventory); !reflect.DeepEqual(merged, expectedMerged) {
+		t.Errorf("unexpected merged keys: expected %v, got %v", expectedMerged, merged)
+	}
+}
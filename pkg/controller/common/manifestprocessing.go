@@ -2,12 +2,15 @@ package common
 
 import (
 	"context"
+	"fmt"
 	"strings"
 
 	"github.com/ghodss/yaml"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/helm/pkg/releaseutil"
 
@@ -25,8 +28,23 @@ type ManifestProcessor struct {
 	processNewObject func(ctx context.Context, obj *unstructured.Unstructured) error
 
 	appInstance, appVersion, owner string
+
+	// plan is non-nil when running in dry-run mode
+	plan *ChangePlan
+
+	// render is non-nil when only rendering the objects, e.g. offline
+	render func(obj *unstructured.Unstructured) error
+
+	overlays  *Overlays
+	inventory *Inventory
+
+	// eventObject is the object on which events are recorded.  Events are
+	// recorded on the affected resource if it is nil.
+	eventObject runtime.Object
 }
 
+const eventReasonRecreating = "RecreatingResource"
+
 func NewManifestProcessor(controllerResources ControllerResources, appInstance, appVersion, owner string, preprocessObjectFunc, postProcessObjectFunc func(ctx context.Context, obj *unstructured.Unstructured) error) *ManifestProcessor {
 	return &ManifestProcessor{
 		ControllerResources: controllerResources,
@@ -38,6 +56,36 @@ func NewManifestProcessor(controllerResources ControllerResources, appInstance,
 	}
 }
 
+// EnableDryRun configures the processor to record the changes it would make in
+// plan, instead of applying them to the cluster.
+func (p *ManifestProcessor) EnableDryRun(plan *ChangePlan) {
+	p.plan = plan
+}
+
+// EnableRenderOnly configures the processor to pass the fully processed
+// objects to render, instead of applying them to the cluster.
+func (p *ManifestProcessor) EnableRenderOnly(render func(obj *unstructured.Unstructured) error) {
+	p.render = render
+}
+
+// SetOverlays configures the processor to apply the overlays to the rendered
+// objects before they are created or updated.
+func (p *ManifestProcessor) SetOverlays(overlays *Overlays) {
+	p.overlays = overlays
+}
+
+// SetEventObject configures the processor to record events about the
+// processed objects on obj, e.g. the ServiceMeshControlPlane.
+func (p *ManifestProcessor) SetEventObject(obj runtime.Object) {
+	p.eventObject = obj
+}
+
+// SetInventory configures the processor to record the keys of the objects it
+// processes in inventory.
+func (p *ManifestProcessor) SetInventory(inventory *Inventory) {
+	p.inventory = inventory
+}
+
 func (p *ManifestProcessor) ProcessManifests(ctx context.Context, manifests []manifest.Manifest, component string) error {
 	log := LogFromContext(ctx)
 
@@ -115,9 +163,39 @@ func (p *ManifestProcessor) processObject(ctx context.Context, obj *unstructured
 		return err
 	}
 
-	err = kubectl.CreateApplyAnnotation(obj, unstructured.UnstructuredJSONScheme)
+	err = p.overlays.Apply(obj)
 	if err != nil {
-		log.Error(err, "error adding apply annotation to object")
+		log.Error(err, "error applying overlays to object")
+		return err
+	}
+
+	p.inventory.Add(v1.NewResourceKey(obj, obj))
+
+	strategy, err := p.applyStrategy(ctx, obj)
+	if err != nil {
+		log.Error(err, "error determining apply strategy for object")
+		return err
+	}
+
+	contentHash, err := SetContentHash(obj, strategy)
+	if err != nil {
+		log.Error(err, "error computing content hash for object")
+		return err
+	}
+
+	if p.render != nil {
+		return p.render(obj)
+	}
+
+	if p.plan != nil {
+		return p.planObject(ctx, obj, strategy)
+	}
+
+	if strategy == ApplyStrategyThreeWayMerge {
+		err = kubectl.CreateApplyAnnotation(obj, unstructured.UnstructuredJSONScheme)
+		if err != nil {
+			log.Error(err, "error adding apply annotation to object")
+		}
 	}
 
 	receiver := v1.NewResourceKey(obj, obj).ToUnstructured()
@@ -135,8 +213,10 @@ func (p *ManifestProcessor) processObject(ctx context.Context, obj *unstructured
 	if err != nil {
 		if errors.IsNotFound(err) {
 			log.Info("creating resource")
-			err = p.Client.Create(ctx, obj)
+			err = p.createObject(ctx, obj, strategy)
 			if err == nil {
+				RecordResourceOperation(obj.GetKind(), ResourceOperationCreate)
+				p.PatchFactory.RecordApplied(obj)
 				// special handling
 				if err := p.processNewObject(ctx, obj); err != nil {
 					// just log for now
@@ -146,25 +226,29 @@ func (p *ManifestProcessor) processObject(ctx context.Context, obj *unstructured
 				log.Error(err, "error during creation of new resource")
 			}
 		}
-	} else if patch, err = p.PatchFactory.CreatePatch(receiver, obj); err == nil && patch != nil {
-		log.Info("updating existing resource")
-		_, err = patch.Apply(ctx)
-		if errors.IsInvalid(err) {
-			// patch was invalid, try delete/create
-			log.Info("patch failed.  attempting to delete and recreate the resource")
-			if deleteErr := p.Client.Delete(ctx, obj, client.PropagationPolicy(metav1.DeletePropagationBackground)); deleteErr == nil {
-				// we need to remove the resource version, which was updated by the patching process
-				obj.SetResourceVersion("")
-				if createErr := p.Client.Create(ctx, obj); createErr == nil {
-					log.Info("successfully recreated resource after patch failure")
-					err = nil
-				} else {
-					log.Error(createErr, "error trying to recreate resource after patch failure")
+	} else if p.PatchFactory.IsUpToDate(receiver, contentHash) {
+		// neither the rendered resource nor the live resource have changed
+		// since the resource was last applied
+		log.V(2).Info("resource is up to date")
+	} else if patch, err = p.createPatch(receiver, obj, strategy); err == nil {
+		var applied *unstructured.Unstructured
+		if patch == nil {
+			applied = receiver
+		} else {
+			log.Info("updating existing resource", "strategy", strategy)
+			applied, err = patch.Apply(ctx)
+			if err == nil {
+				RecordResourceOperation(obj.GetKind(), ResourceOperationPatch)
+			} else if errors.IsInvalid(err) {
+				if err = p.recreateObject(ctx, receiver, obj, strategy, err); err == nil {
+					RecordResourceOperation(obj.GetKind(), ResourceOperationRecreate)
+					applied = obj
 				}
-			} else {
-				log.Error(deleteErr, "error deleting resource for recreation")
 			}
 		}
+		if err == nil {
+			p.PatchFactory.RecordApplied(applied)
+		}
 	}
 	log.V(2).Info("resource reconciliation complete")
 	if err != nil {
@@ -173,6 +257,183 @@ func (p *ManifestProcessor) processObject(ctx context.Context, obj *unstructured
 	return err
 }
 
+// applyStrategy returns the strategy used to apply obj.  Server-side apply
+// falls back to a three-way merge if it has not been enabled.
+func (p *ManifestProcessor) applyStrategy(ctx context.Context, obj *unstructured.Unstructured) (ApplyStrategy, error) {
+	strategy, err := GetApplyStrategy(obj)
+	if err != nil {
+		return strategy, err
+	}
+	if strategy == ApplyStrategyServerSide && (p.PatchFactory == nil || !p.PatchFactory.ServerSideApplyEnabled()) {
+		LogFromContext(ctx).V(2).Info("server-side apply is not enabled, using three-way merge")
+		return ApplyStrategyThreeWayMerge, nil
+	}
+	return strategy, nil
+}
+
+func (p *ManifestProcessor) createObject(ctx context.Context, obj *unstructured.Unstructured, strategy ApplyStrategy) error {
+	if strategy != ApplyStrategyServerSide {
+		return p.Client.Create(ctx, obj)
+	}
+	patch, err := p.PatchFactory.CreateServerSideApplyPatch(obj)
+	if err != nil {
+		return err
+	}
+	created, err := patch.Apply(ctx)
+	if err == nil {
+		obj.Object = created.Object
+	}
+	return err
+}
+
+func (p *ManifestProcessor) createPatch(current, obj *unstructured.Unstructured, strategy ApplyStrategy) (Patch, error) {
+	switch strategy {
+	case ApplyStrategyServerSide:
+		return p.PatchFactory.CreateServerSideApplyPatch(obj)
+	case ApplyStrategyReplace:
+		return p.PatchFactory.CreateReplacePatch(current, obj)
+	}
+	return p.PatchFactory.CreatePatch(current, obj)
+}
+
+// recreateObject deletes and recreates an object whose update was rejected,
+// if the object allows it.  updateErr is returned if it does not.
+func (p *ManifestProcessor) recreateObject(ctx context.Context, current, obj *unstructured.Unstructured, strategy ApplyStrategy, updateErr error) error {
+	log := LogFromContext(ctx)
+
+	immutableFields := changedImmutableFields(current, obj, strategy)
+	if !AllowsRecreate(obj) {
+		if len(immutableFields) > 0 {
+			return immutableFieldsError(obj, immutableFields)
+		}
+		return updateErr
+	}
+
+	var message string
+	if len(immutableFields) > 0 {
+		message = fmt.Sprintf("Recreating %s %s/%s, immutable fields changed: %s", obj.GetKind(), obj.GetNamespace(), obj.GetName(), strings.Join(immutableFields, ", "))
+	} else {
+		message = fmt.Sprintf("Recreating %s %s/%s, update was rejected: %s", obj.GetKind(), obj.GetNamespace(), obj.GetName(), updateErr)
+	}
+	log.Info(message)
+	if p.EventRecorder != nil {
+		eventObject := p.eventObject
+		if eventObject == nil {
+			eventObject = current
+		}
+		p.EventRecorder.Event(eventObject, corev1.EventTypeWarning, eventReasonRecreating, message)
+	}
+
+	if err := p.Client.Delete(ctx, current, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil {
+		log.Error(err, "error deleting resource for recreation")
+		return err
+	}
+	// we need to remove the resource version, which was updated by the patching process
+	obj.SetResourceVersion("")
+	if err := p.createObject(ctx, obj, strategy); err != nil {
+		log.Error(err, "error trying to recreate resource after update failure")
+		return err
+	}
+	log.Info("successfully recreated resource after update failure")
+	return nil
+}
+
+func immutableFieldsError(obj *unstructured.Unstructured, immutableFields []string) error {
+	return fmt.Errorf("cannot update %s %s/%s, immutable fields changed: %s; annotate the resource with %s=true to allow it to be recreated",
+		obj.GetKind(), obj.GetNamespace(), obj.GetName(), strings.Join(immutableFields, ", "), AllowRecreateKey)
+}
+
+// changedImmutableFields returns the immutable fields of current that would be
+// modified by applying obj using the strategy.  Only replacing an object
+// modifies fields that are not specified by obj.
+func changedImmutableFields(current, obj *unstructured.Unstructured, strategy ApplyStrategy) []string {
+	fields := ImmutableFieldsChanged(current, obj)
+	if strategy == ApplyStrategyReplace {
+		return fields
+	}
+	changed := []string{}
+	for _, field := range fields {
+		if _, found, _ := unstructured.NestedFieldNoCopy(obj.UnstructuredContent(), strings.Split(field, ".")...); found {
+			changed = append(changed, field)
+		}
+	}
+	return changed
+}
+
+func (p *ManifestProcessor) planObject(ctx context.Context, obj *unstructured.Unstructured, strategy ApplyStrategy) error {
+	log := LogFromContext(ctx)
+
+	key := v1.NewResourceKey(obj, obj)
+	p.plan.AddRendered(key)
+
+	receiver := key.ToUnstructured()
+	objectKey, err := client.ObjectKeyFromObject(receiver)
+	if err != nil {
+		log.Error(err, "client.ObjectKeyFromObject() failed for resource")
+		return err
+	}
+
+	err = p.Client.Get(ctx, objectKey, receiver)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			log.V(2).Info("resource would be created")
+			p.plan.AddChange(key, v1.PlannedActionCreate, nil)
+			return nil
+		}
+		log.Error(err, "error retrieving resource")
+		return err
+	}
+
+	// the generation bookkeeping changes with every update.  we don't want
+	// that to show up as a change to every resource.
+	if generation, ok := GetAnnotation(receiver, MeshGenerationKey); ok {
+		SetAnnotation(obj, MeshGenerationKey, generation)
+	}
+	if version, ok := GetLabel(receiver, KubernetesAppVersionKey); ok {
+		SetLabel(obj, KubernetesAppVersionKey, version)
+	}
+	if _, err := SetContentHash(obj, strategy); err != nil {
+		log.Error(err, "error computing content hash for object")
+		return err
+	}
+
+	err = kubectl.CreateApplyAnnotation(obj, unstructured.UnstructuredJSONScheme)
+	if err != nil {
+		log.Error(err, "error adding apply annotation to object")
+	}
+
+	patched, err := GetPatchedObject(receiver, obj)
+	if err != nil {
+		log.Error(err, "error computing patch for resource")
+		return err
+	} else if patched == nil {
+		log.V(2).Info("resource is up to date")
+		return nil
+	}
+
+	patchedUnstructured, ok := patched.(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("could not decode unstructured object:\n%v", patched)
+	}
+	// the changes are estimated using a three-way merge, regardless of the
+	// strategy, except that replacing an object clears unspecified fields
+	immutableFields := ImmutableFieldsChanged(receiver, patchedUnstructured)
+	if strategy == ApplyStrategyReplace {
+		immutableFields = changedImmutableFields(receiver, obj, strategy)
+	}
+	if len(immutableFields) > 0 {
+		if !AllowsRecreate(obj) {
+			return immutableFieldsError(obj, immutableFields)
+		}
+		log.V(2).Info("resource would be recreated", "fields", immutableFields)
+		p.plan.AddChange(key, v1.PlannedActionRecreate, immutableFields)
+	} else {
+		log.V(2).Info("resource would be updated")
+		p.plan.AddChange(key, v1.PlannedActionUpdate, nil)
+	}
+	return nil
+}
+
 func (p *ManifestProcessor) addMetadata(obj *unstructured.Unstructured, component string) {
 	labels := map[string]string{
 		// add app labels
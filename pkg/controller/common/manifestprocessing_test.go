@@ -0,0 +1,304 @@
+package common
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+
+	"github.com/maistra/istio-operator/pkg/controller/common/test"
+)
+
+func TestProcessObjectRecreation(t *testing.T) {
+	testCases := []struct {
+		name              string
+		annotations       map[string]string
+		expectErr         bool
+		expectRecreated   bool
+		expectedFieldsMsg string
+	}{
+		{
+			name:              "recreation not allowed",
+			expectErr:         true,
+			expectedFieldsMsg: "immutable fields changed: spec.selector",
+		},
+		{
+			name:              "recreation allowed",
+			annotations:       map[string]string{AllowRecreateKey: "true"},
+			expectRecreated:   true,
+			expectedFieldsMsg: "immutable fields changed: spec.selector",
+		},
+		{
+			name:              "recreation allowed with replace strategy",
+			annotations:       map[string]string{AllowRecreateKey: "true", ApplyStrategyKey: string(ApplyStrategyReplace)},
+			expectRecreated:   true,
+			expectedFieldsMsg: "immutable fields changed: spec.selector",
+		},
+		{
+			name:        "unknown strategy",
+			annotations: map[string]string{ApplyStrategyKey: "merge"},
+			expectErr:   true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			existing := &appsv1.Deployment{
+				TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "istio-pilot",
+					Namespace:       "istio-system",
+					ResourceVersion: "1",
+				},
+				Spec: appsv1.DeploymentSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "pilot"}},
+				},
+			}
+			cl, tracker := test.CreateClient(existing)
+			tracker.AddReactor("update", "deployments", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				return true, nil, apierrors.NewInvalid(schema.GroupKind{Group: "apps", Kind: "Deployment"}, "istio-pilot",
+					field.ErrorList{field.Invalid(field.NewPath("spec", "selector"), nil, "field is immutable")})
+			})
+			recorder := record.NewFakeRecorder(10)
+			processor := NewManifestProcessor(
+				ControllerResources{Client: cl, EventRecorder: recorder, PatchFactory: NewPatchFactory(cl)},
+				"istio-system", "1.1.0", "istio-system", noopProcessing, noopProcessing)
+
+			obj := &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": "apps/v1",
+					"kind":       "Deployment",
+					"metadata": map[string]interface{}{
+						"name":      "istio-pilot",
+						"namespace": "istio-system",
+					},
+					"spec": map[string]interface{}{
+						"selector": map[string]interface{}{
+							"matchLabels": map[string]interface{}{"app": "pilot", "istio": "pilot"},
+						},
+					},
+				},
+			}
+			obj.SetAnnotations(tc.annotations)
+
+			recreations := counterValue(t, resourceOperations.WithLabelValues("Deployment", ResourceOperationRecreate))
+			ctx := NewContextWithLog(context.Background(), logf.Log)
+			err := processor.processObject(ctx, obj, "pilot")
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected error processing object")
+				} else if !strings.Contains(err.Error(), tc.expectedFieldsMsg) {
+					t.Errorf("expected error to contain %q: %v", tc.expectedFieldsMsg, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error processing object: %v", err)
+			}
+
+			recreated := false
+			for _, action := range tracker.Actions() {
+				if action.Matches("delete", "deployments") {
+					recreated = true
+				}
+			}
+			if recreated != tc.expectRecreated {
+				t.Errorf("expected recreated to be %t", tc.expectRecreated)
+			}
+			if recreated = counterValue(t, resourceOperations.WithLabelValues("Deployment", ResourceOperationRecreate)) > recreations; recreated != tc.expectRecreated {
+				t.Errorf("expected recreation to be counted: %t", tc.expectRecreated)
+			}
+			if tc.expectRecreated {
+				select {
+				case event := <-recorder.Events:
+					if !strings.Contains(event, eventReasonRecreating) || !strings.Contains(event, tc.expectedFieldsMsg) {
+						t.Errorf("unexpected event: %s", event)
+					}
+				default:
+					t.Errorf("expected event to be recorded for recreation")
+				}
+				deployment := &appsv1.Deployment{}
+				if err := cl.Get(ctx, client.ObjectKey{Namespace: "istio-system", Name: "istio-pilot"}, deployment); err != nil {
+					t.Fatalf("unexpected error retrieving recreated deployment: %v", err)
+				}
+				if deployment.Spec.Selector.MatchLabels["istio"] != "pilot" {
+					t.Errorf("expected deployment to be recreated with new selector: %v", deployment.Spec.Selector)
+				}
+			}
+		})
+	}
+}
+
+func TestParseApplyStrategy(t *testing.T) {
+	for _, name := range []string{"three-way-merge", "server-side", "replace"} {
+		if strategy, err := ParseApplyStrategy(name); err != nil || string(strategy) != name {
+			t.Errorf("unexpected result parsing %s: %s, %v", name, strategy, err)
+		}
+	}
+	if _, err := ParseApplyStrategy("merge"); err == nil {
+		t.Errorf("expected error parsing unknown strategy")
+	}
+	obj := &corev1.ConfigMap{}
+	if strategy, err := GetApplyStrategy(obj); err != nil || strategy != ApplyStrategyThreeWayMerge {
+		t.Errorf("expected three-way merge to be the default strategy: %s, %v", strategy, err)
+	}
+}
+
+func TestProcessObjectSkipsUpToDateObjects(t *testing.T) {
+	cl, tracker := test.CreateClient()
+	// the fake client does not maintain resource versions
+	resourceVersion := 0
+	bumpResourceVersion := func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if accessor, err := meta.Accessor(action.(clienttesting.CreateAction).GetObject()); err == nil {
+			resourceVersion++
+			accessor.SetResourceVersion(strconv.Itoa(resourceVersion))
+		}
+		return clienttesting.ObjectReaction(tracker)(action)
+	}
+	tracker.AddReactor("create", "configmaps", bumpResourceVersion)
+	tracker.AddReactor("update", "configmaps", bumpResourceVersion)
+
+	processor := NewManifestProcessor(
+		ControllerResources{Client: cl, PatchFactory: NewPatchFactory(cl)},
+		"istio-system", "1.1.0", "istio-system", noopProcessing, noopProcessing)
+	ctx := NewContextWithLog(context.Background(), logf.Log)
+	objectKey := client.ObjectKey{Namespace: "istio-system", Name: "istio"}
+
+	steps := []struct {
+		name         string
+		value        string
+		generation   string
+		modify       func(cm *corev1.ConfigMap)
+		forget       bool
+		expectUpdate bool
+	}{
+		{
+			name:  "create",
+			value: "a",
+		},
+		{
+			name:  "unchanged",
+			value: "a",
+		},
+		{
+			name:         "rendered content changed",
+			value:        "b",
+			expectUpdate: true,
+		},
+		{
+			name:  "live object modified",
+			value: "b",
+			modify: func(cm *corev1.ConfigMap) {
+				cm.Data["value"] = "modified"
+			},
+			expectUpdate: true,
+		},
+		{
+			name:  "unchanged after modification was reverted",
+			value: "b",
+		},
+		{
+			name:       "mesh generation changed",
+			value:      "b",
+			generation: "1.1.0-2",
+		},
+		{
+			name:         "record forgotten",
+			value:        "b",
+			generation:   "1.1.0-2",
+			forget:       true,
+			expectUpdate: true,
+		},
+	}
+	for _, step := range steps {
+		if step.modify != nil {
+			cm := &corev1.ConfigMap{}
+			if err := cl.Get(ctx, objectKey, cm); err != nil {
+				t.Fatalf("%s: unexpected error retrieving ConfigMap: %v", step.name, err)
+			}
+			step.modify(cm)
+			if err := cl.Update(ctx, cm); err != nil {
+				t.Fatalf("%s: unexpected error updating ConfigMap: %v", step.name, err)
+			}
+		}
+		if step.forget {
+			processor.PatchFactory.ForgetApplied(&unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": "v1",
+					"kind":       "ConfigMap",
+					"metadata": map[string]interface{}{
+						"name":      "istio",
+						"namespace": "istio-system",
+					},
+				},
+			})
+		}
+		tracker.ClearActions()
+
+		obj := &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata": map[string]interface{}{
+					"name":      "istio",
+					"namespace": "istio-system",
+				},
+				"data": map[string]interface{}{
+					"value": step.value,
+				},
+			},
+		}
+		if step.generation == "" {
+			step.generation = "1.1.0-1"
+		}
+		SetAnnotation(obj, MeshGenerationKey, step.generation)
+		if err := processor.processObject(ctx, obj, "pilot"); err != nil {
+			t.Fatalf("%s: unexpected error processing object: %v", step.name, err)
+		}
+
+		updated := false
+		for _, action := range tracker.Actions() {
+			if action.Matches("update", "configmaps") {
+				updated = true
+			}
+		}
+		if updated != step.expectUpdate {
+			t.Errorf("%s: expected updated to be %t", step.name, step.expectUpdate)
+		}
+		cm := &corev1.ConfigMap{}
+		if err := cl.Get(ctx, objectKey, cm); err != nil {
+			t.Fatalf("%s: unexpected error retrieving ConfigMap: %v", step.name, err)
+		}
+		if cm.Data["value"] != step.value {
+			t.Errorf("%s: unexpected ConfigMap data: %v", step.name, cm.Data)
+		}
+		contentHash, ok := GetAnnotation(cm, ContentHashKey)
+		if !ok {
+			t.Errorf("%s: expected ConfigMap to be annotated with content hash", step.name)
+		}
+		live := &unstructured.Unstructured{}
+		live.SetAPIVersion("v1")
+		live.SetKind("ConfigMap")
+		if err := cl.Get(ctx, objectKey, live); err != nil {
+			t.Fatalf("%s: unexpected error retrieving ConfigMap: %v", step.name, err)
+		}
+		if !processor.PatchFactory.IsUpToDate(live, contentHash) {
+			t.Errorf("%s: expected ConfigMap to be recorded as up to date", step.name)
+		}
+	}
+}
+
+func noopProcessing(ctx context.Context, obj *unstructured.Unstructured) error {
+	return nil
+}
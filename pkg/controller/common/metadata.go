@@ -21,6 +21,9 @@ const (
 	// MemberOfKey represents the mesh (namespace) to which the resource relates
 	MemberOfKey = MetadataNamespace + "/member-of"
 
+	// ControlPlaneKey represents the name of the control plane to which the resource relates
+	ControlPlaneKey = MetadataNamespace + "/control-plane"
+
 	// GenerationKey represents the generation to which the resource was last reconciled
 	GenerationKey = MetadataNamespace + "/generation"
 
@@ -30,6 +33,24 @@ const (
 	// InternalKey is used to identify the resource as being internal to the mesh itself (i.e. should not be applied to members)
 	InternalKey = MetadataNamespace + "/internal"
 
+	// DryRunKey is used in annotations on a ServiceMeshControlPlane to request that changes be planned, but not applied
+	DryRunKey = MetadataNamespace + "/dry-run"
+
+	// DeletionProtectionKey is used in annotations on a ServiceMeshControlPlane to prevent it from being deleted while the mesh has members
+	DeletionProtectionKey = MetadataNamespace + "/deletion-protection"
+
+	// ApproveUpgradeKey is used in annotations on a ServiceMeshControlPlane to approve the upgrade to the version specified as its value
+	ApproveUpgradeKey = MetadataNamespace + "/approve-upgrade"
+
+	// ApplyStrategyKey is used in annotations on rendered resources to select the ApplyStrategy used to update them
+	ApplyStrategyKey = MetadataNamespace + "/apply-strategy"
+
+	// AllowRecreateKey is used in annotations on rendered resources to allow them to be deleted and recreated when an update is rejected
+	AllowRecreateKey = MetadataNamespace + "/allow-recreate"
+
+	// ContentHashKey is used in annotations on rendered resources to record a hash of the content last applied to them
+	ContentHashKey = MetadataNamespace + "/content-hash"
+
 	// FinalizerName is the finalizer name the controllers add to any resources that need to be finalized during deletion
 	FinalizerName = MetadataNamespace + "/istio-operator"
 
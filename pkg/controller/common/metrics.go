@@ -0,0 +1,216 @@
+package common
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	v1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+)
+
+// Operations recorded by maistra_operator_resource_operations_total
+const (
+	ResourceOperationCreate   = "create"
+	ResourceOperationPatch    = "patch"
+	ResourceOperationDelete   = "delete"
+	ResourceOperationRecreate = "recreate"
+)
+
+// Values of the state label of maistra_operator_memberroll_members
+const (
+	memberStateConfigured = "configured"
+	memberStateRequired   = "required"
+)
+
+var (
+	controlPlaneReconcileDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "maistra_operator_controlplane_reconcile_duration_seconds",
+			Help:    "Duration of the reconciliations of a ServiceMeshControlPlane",
+			Buckets: []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+		},
+		[]string{"namespace", "name"},
+	)
+	componentReconcileDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "maistra_operator_component_reconcile_duration_seconds",
+			Help:    "Duration of the reconciliations of the resources of a control plane component",
+			Buckets: []float64{0.05, 0.1, 0.5, 1, 2.5, 5, 10, 30},
+		},
+		[]string{"namespace", "name", "component"},
+	)
+	reconcilePauseDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "maistra_operator_reconcile_pause_duration_seconds",
+			Help:    "Time the reconciliation of a ServiceMeshControlPlane was paused waiting for a component to become ready",
+			Buckets: []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800},
+		},
+		[]string{"namespace", "name", "component"},
+	)
+	resourceOperations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "maistra_operator_resource_operations_total",
+			Help: "Number of operations performed on the resources of control planes, partitioned by kind and operation: create, patch, delete or recreate",
+		},
+		[]string{"kind", "operation"},
+	)
+	controlPlaneConditions = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "maistra_operator_controlplane_condition",
+			Help: "The conditions of a ServiceMeshControlPlane; 1 for the current status of the condition, 0 for the others",
+		},
+		[]string{"namespace", "name", "type", "status"},
+	)
+	memberRollConditions = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "maistra_operator_memberroll_condition",
+			Help: "The conditions of a ServiceMeshMemberRoll; 1 for the current status of the condition, 0 for the others",
+		},
+		[]string{"namespace", "name", "type", "status"},
+	)
+	memberConditions = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "maistra_operator_member_condition",
+			Help: "The conditions of a ServiceMeshMember; 1 for the current status of the condition, 0 for the others",
+		},
+		[]string{"namespace", "name", "type", "status"},
+	)
+	memberRollMembers = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "maistra_operator_memberroll_members",
+			Help: "Number of namespaces listed by a ServiceMeshMemberRoll (required) and number of those that have been configured (configured)",
+		},
+		[]string{"namespace", "name", "state"},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		controlPlaneReconcileDuration,
+		componentReconcileDuration,
+		reconcilePauseDuration,
+		resourceOperations,
+		controlPlaneConditions,
+		memberRollConditions,
+		memberConditions,
+		memberRollMembers,
+	)
+}
+
+// conditionStatuses are the values of the status label of the condition
+// metrics.  Each is reported, so a condition changing its status does not
+// leave the previous status behind.
+var conditionStatuses = []string{
+	string(v1.ConditionStatusTrue),
+	string(v1.ConditionStatusFalse),
+	string(v1.ConditionStatusUnknown),
+}
+
+// condition types reported for each resource, used to remove the metrics of
+// deleted resources
+var (
+	controlPlaneConditionTypes = []string{
+		string(v1.ConditionTypeInstalled),
+		string(v1.ConditionTypeReconciled),
+		string(v1.ConditionTypeReady),
+		string(v1.ConditionTypeRolledBack),
+		string(v1.ConditionTypeUpgradeable),
+		string(v1.ConditionTypeUpgradeAvailable),
+	}
+	memberRollConditionTypes = []string{
+		string(v1.ConditionTypeMemberRollReady),
+	}
+	memberConditionTypes = []string{
+		string(v1.ConditionTypeMemberReconciled),
+		string(v1.ConditionTypeMemberReady),
+	}
+)
+
+// ObserveControlPlaneReconcileDuration records the duration of a
+// reconciliation of the ServiceMeshControlPlane
+func ObserveControlPlaneReconcileDuration(namespace, name string, duration time.Duration) {
+	controlPlaneReconcileDuration.WithLabelValues(namespace, name).Observe(duration.Seconds())
+}
+
+// ObserveComponentReconcileDuration records the duration of a reconciliation
+// of the resources of a component of the ServiceMeshControlPlane
+func ObserveComponentReconcileDuration(namespace, name, component string, duration time.Duration) {
+	componentReconcileDuration.WithLabelValues(namespace, name, component).Observe(duration.Seconds())
+}
+
+// ObserveReconcilePauseDuration records the time the reconciliation of the
+// ServiceMeshControlPlane was paused waiting for the component to become ready
+func ObserveReconcilePauseDuration(namespace, name, component string, duration time.Duration) {
+	reconcilePauseDuration.WithLabelValues(namespace, name, component).Observe(duration.Seconds())
+}
+
+// RecordResourceOperation counts an operation performed on a resource of the
+// specified kind
+func RecordResourceOperation(kind, operation string) {
+	resourceOperations.WithLabelValues(kind, operation).Inc()
+}
+
+// RecordControlPlaneMetrics reports the conditions of the
+// ServiceMeshControlPlane
+func RecordControlPlaneMetrics(smcp *v1.ServiceMeshControlPlane) {
+	for _, condition := range smcp.Status.Conditions {
+		setConditionMetric(controlPlaneConditions, smcp.Namespace, smcp.Name, string(condition.Type), string(condition.Status))
+	}
+}
+
+// DeleteControlPlaneMetrics removes the conditions reported for a deleted
+// ServiceMeshControlPlane.  Histograms and counters are retained.
+func DeleteControlPlaneMetrics(namespace, name string) {
+	deleteConditionMetrics(controlPlaneConditions, namespace, name, controlPlaneConditionTypes)
+}
+
+// RecordMemberRollMetrics reports the conditions of the ServiceMeshMemberRoll
+// and the number of its members that have been configured
+func RecordMemberRollMetrics(smmr *v1.ServiceMeshMemberRoll) {
+	for _, condition := range smmr.Status.Conditions {
+		setConditionMetric(memberRollConditions, smmr.Namespace, smmr.Name, string(condition.Type), string(condition.Status))
+	}
+	memberRollMembers.WithLabelValues(smmr.Namespace, smmr.Name, memberStateRequired).Set(float64(sets.NewString(smmr.Spec.Members...).Len()))
+	memberRollMembers.WithLabelValues(smmr.Namespace, smmr.Name, memberStateConfigured).Set(float64(len(smmr.Status.ConfiguredMembers)))
+}
+
+// DeleteMemberRollMetrics removes the metrics reported for a deleted
+// ServiceMeshMemberRoll
+func DeleteMemberRollMetrics(namespace, name string) {
+	deleteConditionMetrics(memberRollConditions, namespace, name, memberRollConditionTypes)
+	memberRollMembers.DeleteLabelValues(namespace, name, memberStateRequired)
+	memberRollMembers.DeleteLabelValues(namespace, name, memberStateConfigured)
+}
+
+// RecordMemberMetrics reports the conditions of the ServiceMeshMember
+func RecordMemberMetrics(smm *v1.ServiceMeshMember) {
+	for _, condition := range smm.Status.Conditions {
+		setConditionMetric(memberConditions, smm.Namespace, smm.Name, string(condition.Type), string(condition.Status))
+	}
+}
+
+// DeleteMemberMetrics removes the conditions reported for a deleted
+// ServiceMeshMember
+func DeleteMemberMetrics(namespace, name string) {
+	deleteConditionMetrics(memberConditions, namespace, name, memberConditionTypes)
+}
+
+func setConditionMetric(gauge *prometheus.GaugeVec, namespace, name, conditionType, status string) {
+	for _, conditionStatus := range conditionStatuses {
+		value := 0.0
+		if conditionStatus == status {
+			value = 1
+		}
+		gauge.WithLabelValues(namespace, name, conditionType, conditionStatus).Set(value)
+	}
+}
+
+func deleteConditionMetrics(gauge *prometheus.GaugeVec, namespace, name string, conditionTypes []string) {
+	for _, conditionType := range conditionTypes {
+		for _, conditionStatus := range conditionStatuses {
+			gauge.DeleteLabelValues(namespace, name, conditionType, conditionStatus)
+		}
+	}
+}
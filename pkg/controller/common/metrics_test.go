@@ -0,0 +1,87 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+)
+
+func TestControlPlaneMetrics(t *testing.T) {
+	smcp := &v1.ServiceMeshControlPlane{ObjectMeta: metav1.ObjectMeta{Name: "basic-install", Namespace: "istio-system"}}
+	smcp.Status.SetCondition(v1.Condition{Type: v1.ConditionTypeReconciled, Status: v1.ConditionStatusFalse})
+	RecordControlPlaneMetrics(smcp)
+	assertConditionMetric(t, controlPlaneConditions, "istio-system", "basic-install", "Reconciled", "False")
+
+	smcp.Status.SetCondition(v1.Condition{Type: v1.ConditionTypeReconciled, Status: v1.ConditionStatusTrue})
+	RecordControlPlaneMetrics(smcp)
+	assertConditionMetric(t, controlPlaneConditions, "istio-system", "basic-install", "Reconciled", "True")
+
+	DeleteControlPlaneMetrics("istio-system", "basic-install")
+	if count := testCollected(t, controlPlaneConditions); count != 0 {
+		t.Errorf("expected the conditions of deleted control planes to be removed, found %d series", count)
+	}
+}
+
+func TestMemberRollMetrics(t *testing.T) {
+	smmr := &v1.ServiceMeshMemberRoll{
+		ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "istio-system"},
+		Spec:       v1.ServiceMeshMemberRollSpec{Members: []string{"bookinfo", "foo", "bookinfo"}},
+	}
+	smmr.Status.ConfiguredMembers = []string{"bookinfo"}
+	smmr.Status.SetCondition(v1.ServiceMeshMemberRollCondition{Type: v1.ConditionTypeMemberRollReady, Status: corev1.ConditionFalse})
+	RecordMemberRollMetrics(smmr)
+	assertConditionMetric(t, memberRollConditions, "istio-system", "default", "Ready", "False")
+	if value := gaugeValue(t, memberRollMembers.WithLabelValues("istio-system", "default", memberStateRequired)); value != 2 {
+		t.Errorf("expected 2 required members, got %v", value)
+	}
+	if value := gaugeValue(t, memberRollMembers.WithLabelValues("istio-system", "default", memberStateConfigured)); value != 1 {
+		t.Errorf("expected 1 configured member, got %v", value)
+	}
+
+	DeleteMemberRollMetrics("istio-system", "default")
+	if count := testCollected(t, memberRollConditions) + testCollected(t, memberRollMembers); count != 0 {
+		t.Errorf("expected the metrics of deleted member rolls to be removed, found %d series", count)
+	}
+}
+
+// assertConditionMetric verifies that the metric reports the status of the
+// condition and no other status
+func assertConditionMetric(t *testing.T, gauge *prometheus.GaugeVec, namespace, name, conditionType, status string) {
+	t.Helper()
+	for _, conditionStatus := range conditionStatuses {
+		expected := 0.0
+		if conditionStatus == status {
+			expected = 1
+		}
+		if value := gaugeValue(t, gauge.WithLabelValues(namespace, name, conditionType, conditionStatus)); value != expected {
+			t.Errorf("expected %s=%s to be %v, got %v", conditionType, conditionStatus, expected, value)
+		}
+	}
+}
+
+func gaugeValue(t *testing.T, gauge prometheus.Gauge) float64 {
+	t.Helper()
+	metric := &dto.Metric{}
+	if err := gauge.Write(metric); err != nil {
+		t.Fatalf("unexpected error reading metric: %v", err)
+	}
+	return metric.GetGauge().GetValue()
+}
+
+// testCollected returns the number of series collected from the collector
+func testCollected(t *testing.T, collector prometheus.Collector) int {
+	t.Helper()
+	ch := make(chan prometheus.Metric, 100)
+	collector.Collect(ch)
+	close(ch)
+	count := 0
+	for range ch {
+		count++
+	}
+	return count
+}
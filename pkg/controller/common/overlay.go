@@ -0,0 +1,154 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/ghodss/yaml"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	v1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+)
+
+// Overlays applies the overlays specified by a ServiceMeshControlPlane to the
+// rendered resources and keeps track of the overlays that have been applied.
+// The same Overlays should be used for all the resources rendered for a
+// control plane, so overlays that do not match any resource can be
+// identified.
+type Overlays struct {
+	overlays []v1.ResourceOverlay
+	matched  map[int]bool
+}
+
+// NewOverlays returns a new Overlays for the specified overlays
+func NewOverlays(overlays []v1.ResourceOverlay) *Overlays {
+	return &Overlays{
+		overlays: overlays,
+		matched:  map[int]bool{},
+	}
+}
+
+// Apply applies the overlays targeting obj, in order.  An error is returned
+// if any of the overlays cannot be applied, in which case obj is not
+// modified.
+func (o *Overlays) Apply(obj *unstructured.Unstructured) error {
+	if o == nil || len(o.overlays) == 0 {
+		return nil
+	}
+
+	var patched []byte
+	for index, overlay := range o.overlays {
+		if !overlayMatches(overlay, obj) {
+			continue
+		}
+		o.matched[index] = true
+		if patched == nil {
+			var err error
+			if patched, err = json.Marshal(obj.Object); err != nil {
+				return err
+			}
+		}
+		result, err := applyOverlay(overlay, obj, patched)
+		if err != nil {
+			return fmt.Errorf("error applying overlay %d to %s %s: %v", index, overlay.Kind, overlay.Name, err)
+		}
+		patched = result
+	}
+	if patched == nil {
+		return nil
+	}
+
+	newObj := &unstructured.Unstructured{}
+	if err := newObj.UnmarshalJSON(patched); err != nil {
+		return fmt.Errorf("error decoding %s %s after applying overlays: %v", obj.GetKind(), obj.GetName(), err)
+	}
+	if newObj.GetKind() != obj.GetKind() || newObj.GetName() != obj.GetName() || newObj.GetNamespace() != obj.GetNamespace() {
+		return fmt.Errorf("overlays cannot change the kind, name or namespace of %s %s", obj.GetKind(), obj.GetName())
+	}
+	obj.Object = newObj.Object
+	return nil
+}
+
+// Unmatched returns the overlays that have not matched any of the resources
+// passed to Apply, ordered by their index.
+func (o *Overlays) Unmatched() []v1.OverlayError {
+	if o == nil {
+		return nil
+	}
+	var overlayErrors []v1.OverlayError
+	for index, overlay := range o.overlays {
+		if o.matched[index] {
+			continue
+		}
+		overlayErrors = append(overlayErrors, v1.OverlayError{
+			Index:   index,
+			Kind:    overlay.Kind,
+			Name:    overlay.Name,
+			Message: "no rendered resource matches the overlay",
+		})
+	}
+	return overlayErrors
+}
+
+// ValidateOverlay verifies that the overlay specifies a target and a patch of
+// a supported type.  Whether the patch can be applied to the target can only
+// be determined when the resources are rendered.
+func ValidateOverlay(overlay v1.ResourceOverlay) error {
+	if overlay.Kind == "" || overlay.Name == "" {
+		return fmt.Errorf("kind and name must be specified")
+	}
+	patch, err := yaml.YAMLToJSON([]byte(overlay.Patch))
+	if err != nil {
+		return fmt.Errorf("error parsing patch: %v", err)
+	}
+	switch overlay.Type {
+	case v1.OverlayPatchTypeJSON:
+		if _, err := jsonpatch.DecodePatch(patch); err != nil {
+			return fmt.Errorf("error parsing JSON patch: %v", err)
+		}
+	case v1.OverlayPatchTypeStrategic, "":
+		if err := json.Unmarshal(patch, &map[string]interface{}{}); err != nil {
+			return fmt.Errorf("strategic merge patch must be an object: %v", err)
+		}
+	default:
+		return fmt.Errorf("unknown patch type %q, must be one of %q or %q", overlay.Type, v1.OverlayPatchTypeStrategic, v1.OverlayPatchTypeJSON)
+	}
+	return nil
+}
+
+func overlayMatches(overlay v1.ResourceOverlay, obj *unstructured.Unstructured) bool {
+	if overlay.Kind != obj.GetKind() || overlay.Name != obj.GetName() {
+		return false
+	}
+	return overlay.APIVersion == "" || overlay.APIVersion == obj.GetAPIVersion()
+}
+
+// applyOverlay applies the overlay's patch to the json representation of obj
+func applyOverlay(overlay v1.ResourceOverlay, obj *unstructured.Unstructured, original []byte) ([]byte, error) {
+	patch, err := yaml.YAMLToJSON([]byte(overlay.Patch))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing patch: %v", err)
+	}
+	switch overlay.Type {
+	case v1.OverlayPatchTypeJSON:
+		jsonPatch, err := jsonpatch.DecodePatch(patch)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing JSON patch: %v", err)
+		}
+		return jsonPatch.Apply(original)
+	case v1.OverlayPatchTypeStrategic, "":
+		// if we can get a versioned object from the scheme, we can use the
+		// strategic patching mechanism.  otherwise, fall back to json merge
+		// patch.
+		versionedObject, err := scheme.Scheme.New(obj.GroupVersionKind())
+		if err != nil {
+			return jsonpatch.MergePatch(original, patch)
+		}
+		return strategicpatch.StrategicMergePatch(original, patch, versionedObject)
+	}
+	return nil, fmt.Errorf("unknown patch type %q", overlay.Type)
+}
@@ -0,0 +1,218 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	v1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+)
+
+func TestOverlays(t *testing.T) {
+	testCases := []struct {
+		name              string
+		overlays          []v1.ResourceOverlay
+		expectedSpec      map[string]interface{}
+		expectErr         bool
+		expectedUnmatched []int
+	}{
+		{
+			name: "strategic merge patch",
+			overlays: []v1.ResourceOverlay{
+				{
+					Kind: "Deployment",
+					Name: "istio-pilot",
+					Patch: `
+spec:
+  template:
+    spec:
+      containers:
+      - name: discovery
+        args: ["--verbose"]`,
+				},
+			},
+			expectedSpec: map[string]interface{}{
+				"replicas": int64(1),
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"containers": []interface{}{
+							map[string]interface{}{"name": "discovery", "image": "pilot", "args": []interface{}{"--verbose"}},
+							map[string]interface{}{"name": "istio-proxy", "image": "proxyv2"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "json patch",
+			overlays: []v1.ResourceOverlay{
+				{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+					Name:       "istio-pilot",
+					Type:       v1.OverlayPatchTypeJSON,
+					Patch:      `[{"op": "remove", "path": "/spec/template/spec/containers/1"}, {"op": "replace", "path": "/spec/replicas", "value": 2}]`,
+				},
+			},
+			expectedSpec: map[string]interface{}{
+				"replicas": int64(2),
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"containers": []interface{}{
+							map[string]interface{}{"name": "discovery", "image": "pilot"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "overlays applied in order",
+			overlays: []v1.ResourceOverlay{
+				{Kind: "Deployment", Name: "istio-pilot", Patch: `{"spec": {"replicas": 2}}`},
+				{Kind: "Deployment", Name: "istio-pilot", Type: v1.OverlayPatchTypeJSON, Patch: `[{"op": "test", "path": "/spec/replicas", "value": 2}, {"op": "replace", "path": "/spec/replicas", "value": 3}]`},
+			},
+			expectedSpec: map[string]interface{}{
+				"replicas": int64(3),
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"containers": []interface{}{
+							map[string]interface{}{"name": "discovery", "image": "pilot"},
+							map[string]interface{}{"name": "istio-proxy", "image": "proxyv2"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "unmatched overlays",
+			overlays: []v1.ResourceOverlay{
+				{Kind: "Deployment", Name: "istio-galley", Patch: `{"spec": {"replicas": 2}}`},
+				{APIVersion: "extensions/v1beta1", Kind: "Deployment", Name: "istio-pilot", Patch: `{"spec": {"replicas": 2}}`},
+				{Kind: "Service", Name: "istio-pilot", Patch: `{"spec": {"type": "NodePort"}}`},
+			},
+			expectedUnmatched: []int{0, 1, 2},
+		},
+		{
+			name: "failing json patch",
+			overlays: []v1.ResourceOverlay{
+				{Kind: "Deployment", Name: "istio-pilot", Patch: `{"spec": {"replicas": 2}}`},
+				{Kind: "Deployment", Name: "istio-pilot", Type: v1.OverlayPatchTypeJSON, Patch: `[{"op": "remove", "path": "/spec/missing"}]`},
+			},
+			expectErr: true,
+		},
+		{
+			name: "name cannot be changed",
+			overlays: []v1.ResourceOverlay{
+				{Kind: "Deployment", Name: "istio-pilot", Patch: `{"metadata": {"name": "other"}}`},
+			},
+			expectErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			obj := newPilotDeployment()
+			original := obj.DeepCopy()
+			overlays := NewOverlays(tc.overlays)
+			err := overlays.Apply(obj)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected error applying overlays")
+				}
+				if !reflect.DeepEqual(obj, original) {
+					t.Errorf("expected object not to be modified when overlays fail:\n%v", obj.Object)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error applying overlays: %v", err)
+			}
+
+			if tc.expectedSpec != nil {
+				spec, _, _ := unstructured.NestedFieldNoCopy(obj.Object, "spec")
+				if !reflect.DeepEqual(spec, tc.expectedSpec) {
+					t.Errorf("unexpected spec after applying overlays:\nexpected: %v\nactual:   %v", tc.expectedSpec, spec)
+				}
+			}
+
+			unmatched := []int{}
+			for _, overlayErr := range overlays.Unmatched() {
+				unmatched = append(unmatched, overlayErr.Index)
+			}
+			if tc.expectedUnmatched == nil {
+				tc.expectedUnmatched = []int{}
+			}
+			if !reflect.DeepEqual(unmatched, tc.expectedUnmatched) {
+				t.Errorf("unexpected unmatched overlays: expected %v, got %v", tc.expectedUnmatched, unmatched)
+			}
+		})
+	}
+}
+
+func TestValidateOverlay(t *testing.T) {
+	testCases := []struct {
+		name    string
+		overlay v1.ResourceOverlay
+		valid   bool
+	}{
+		{
+			name:    "strategic merge patch",
+			overlay: v1.ResourceOverlay{Kind: "Deployment", Name: "istio-pilot", Patch: "spec:\n  replicas: 2"},
+			valid:   true,
+		},
+		{
+			name:    "json patch",
+			overlay: v1.ResourceOverlay{Kind: "Deployment", Name: "istio-pilot", Type: v1.OverlayPatchTypeJSON, Patch: `[{"op": "remove", "path": "/spec/replicas"}]`},
+			valid:   true,
+		},
+		{
+			name:    "missing name",
+			overlay: v1.ResourceOverlay{Kind: "Deployment", Patch: "spec:\n  replicas: 2"},
+		},
+		{
+			name:    "list as strategic merge patch",
+			overlay: v1.ResourceOverlay{Kind: "Deployment", Name: "istio-pilot", Patch: `[{"op": "remove", "path": "/spec/replicas"}]`},
+		},
+		{
+			name:    "object as json patch",
+			overlay: v1.ResourceOverlay{Kind: "Deployment", Name: "istio-pilot", Type: v1.OverlayPatchTypeJSON, Patch: "spec:\n  replicas: 2"},
+		},
+		{
+			name:    "unknown type",
+			overlay: v1.ResourceOverlay{Kind: "Deployment", Name: "istio-pilot", Type: "merge", Patch: "spec:\n  replicas: 2"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateOverlay(tc.overlay)
+			if tc.valid && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			} else if !tc.valid && err == nil {
+				t.Errorf("expected overlay to be invalid")
+			}
+		})
+	}
+}
+
+func newPilotDeployment() *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{
+				"name":      "istio-pilot",
+				"namespace": "istio-system",
+			},
+			"spec": map[string]interface{}{
+				"replicas": int64(1),
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"containers": []interface{}{
+							map[string]interface{}{"name": "discovery", "image": "pilot"},
+							map[string]interface{}{"name": "istio-proxy", "image": "proxyv2"},
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -17,14 +17,20 @@ import (
 	"k8s.io/apimachinery/pkg/util/mergepatch"
 	"k8s.io/apimachinery/pkg/util/strategicpatch"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
 	"k8s.io/kubernetes/pkg/kubectl"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
 )
 
 // PatchFactory wraps the objects needed to create Patch objects.
 type PatchFactory struct {
 	client client.Client
+	// applier is nil unless server-side apply has been enabled
+	applier *serverSideApplier
+	applied *appliedObjects
 }
 
 // Patch represents a "patch" for an object
@@ -34,7 +40,78 @@ type Patch interface {
 
 // NewPatchFactory creates a new PatchFactory
 func NewPatchFactory(k8sClient client.Client) *PatchFactory {
-	return &PatchFactory{client: k8sClient}
+	return &PatchFactory{client: k8sClient, applied: newAppliedObjects()}
+}
+
+// RecordApplied records the content hash and resource version of obj, which
+// has just been applied.
+func (p *PatchFactory) RecordApplied(obj *unstructured.Unstructured) {
+	p.applied.record(obj)
+}
+
+// ForgetApplied removes the record of obj, which has been deleted or is no
+// longer managed by the factory's owner.
+func (p *PatchFactory) ForgetApplied(obj *unstructured.Unstructured) {
+	if p != nil && obj != nil {
+		p.applied.forget(v1.NewResourceKey(obj, obj))
+	}
+}
+
+// ForgetAppliedOwnedBy removes the records of the objects owned by the control
+// plane in the mesh namespace, which has been deleted.
+func (p *PatchFactory) ForgetAppliedOwnedBy(meshNamespace string) {
+	if p != nil {
+		p.applied.forgetOwnedBy(meshNamespace)
+	}
+}
+
+// IsUpToDate returns true if current was last applied by the factory's owner
+// with the specified content hash and has not been modified since, in which
+// case there is no need to compute a patch for it.
+func (p *PatchFactory) IsUpToDate(current *unstructured.Unstructured, contentHash string) bool {
+	return p != nil && p.applied.upToDate(current, contentHash)
+}
+
+// EnableServerSideApply allows the factory to create patches that use
+// server-side apply.
+func (p *PatchFactory) EnableServerSideApply(config *rest.Config, mapper meta.RESTMapper) error {
+	applier, err := newServerSideApplier(config, mapper)
+	if err != nil {
+		return err
+	}
+	p.applier = applier
+	return nil
+}
+
+// ServerSideApplyEnabled returns true if the factory can create patches that
+// use server-side apply.
+func (p *PatchFactory) ServerSideApplyEnabled() bool {
+	return p.applier != nil
+}
+
+// CreateServerSideApplyPatch creates a patch that applies new using
+// server-side apply.  The patch creates the object if it does not exist.
+func (p *PatchFactory) CreateServerSideApplyPatch(new *unstructured.Unstructured) (Patch, error) {
+	if p.applier == nil {
+		return nil, fmt.Errorf("server-side apply is not enabled")
+	}
+	return &serverSideApplyPatch{applier: p.applier, obj: new}, nil
+}
+
+// CreateReplacePatch creates a patch that replaces the current version of an
+// object with the new version.
+func (p *PatchFactory) CreateReplacePatch(current, new runtime.Object) (Patch, error) {
+	currentAccessor, err := meta.Accessor(current)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("cannot create object accessor for current object:\n%v", current))
+	}
+	newObj := new.DeepCopyObject()
+	newAccessor, err := meta.Accessor(newObj)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("cannot create object accessor for new object:\n%v", new))
+	}
+	newAccessor.SetResourceVersion(currentAccessor.GetResourceVersion())
+	return &basicPatch{client: p.client, newObj: newObj}, nil
 }
 
 // CreatePatch creates a patch based on the current and new versions of an object
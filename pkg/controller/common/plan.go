@@ -0,0 +1,57 @@
+package common
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	v1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+)
+
+// ChangePlan collects the changes a ManifestProcessor would make to the
+// cluster when it is running in dry-run mode.
+type ChangePlan struct {
+	changes  map[v1.ResourceKey]v1.PlannedChange
+	rendered sets.String
+}
+
+// NewChangePlan returns a new, empty ChangePlan
+func NewChangePlan() *ChangePlan {
+	return &ChangePlan{
+		changes:  map[v1.ResourceKey]v1.PlannedChange{},
+		rendered: sets.NewString(),
+	}
+}
+
+// AddChange records a change to the resource identified by key
+func (p *ChangePlan) AddChange(key v1.ResourceKey, action v1.PlannedAction, immutableFields []string) {
+	p.changes[key] = v1.PlannedChange{
+		Resource:        string(key),
+		Action:          action,
+		ImmutableFields: immutableFields,
+	}
+}
+
+// AddRendered records that the resource identified by key is part of the
+// rendered manifests, regardless of whether or not it would be changed.
+func (p *ChangePlan) AddRendered(key v1.ResourceKey) {
+	p.rendered.Insert(string(key))
+}
+
+// IsRendered returns true if the resource identified by key is part of the
+// rendered manifests.
+func (p *ChangePlan) IsRendered(key v1.ResourceKey) bool {
+	return p.rendered.Has(string(key))
+}
+
+// Changes returns the recorded changes, sorted by resource key
+func (p *ChangePlan) Changes() []v1.PlannedChange {
+	changes := make([]v1.PlannedChange, 0, len(p.changes))
+	for _, change := range p.changes {
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Resource < changes[j].Resource
+	})
+	return changes
+}
@@ -0,0 +1,300 @@
+package common
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/helm/pkg/manifest"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	renderCacheHits = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "maistra_operator_render_cache_hits_total",
+			Help: "Number of helm chart renderings served from the render cache, partitioned by the cache tier that served them",
+		},
+		[]string{"tier"},
+	)
+	renderCacheMisses = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "maistra_operator_render_cache_misses_total",
+			Help: "Number of helm chart renderings that were not found in the render cache",
+		},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(renderCacheHits, renderCacheMisses)
+}
+
+const (
+	renderCacheTierMemory = "memory"
+	renderCacheTierDisk   = "disk"
+)
+
+var (
+	defaultRenderCache     *RenderCache
+	defaultRenderCacheOnce sync.Once
+)
+
+// getRenderCache returns the RenderCache configured by Options.  The cache is
+// created the first time it is used, i.e. after the options have been parsed.
+func getRenderCache() *RenderCache {
+	defaultRenderCacheOnce.Do(func() {
+		defaultRenderCache = NewRenderCache(Options.RenderCacheSize, Options.RenderCacheDir)
+	})
+	return defaultRenderCache
+}
+
+// RenderCache caches rendered helm charts.  Entries are keyed by a checksum of
+// the chart directory and a hash of the values used to render the chart, so
+// an entry is only reused if neither the chart nor the values have changed.
+// The most recently used entries are kept in memory and, if a directory is
+// configured, persisted to disk, so renderings can be reused after the
+// operator's process has been restarted.  The persisted renderings include the
+// values of the control planes, so they are only readable by the operator.  The charts are part of the operator's image,
+// so the checksum of a chart directory is only computed the first time the
+// chart is rendered.
+type RenderCache struct {
+	mu        sync.Mutex
+	size      int
+	dir       string
+	entries   map[string]*list.Element
+	lru       *list.List
+	checksums map[string]string
+}
+
+type renderCacheEntry struct {
+	key        string
+	renderings map[string][]manifest.Manifest
+}
+
+// NewRenderCache returns a new RenderCache holding at most size renderings.
+// Renderings are persisted in dir, unless it is empty.  A size of zero
+// disables the cache.
+func NewRenderCache(size int, dir string) *RenderCache {
+	return &RenderCache{
+		size:      size,
+		dir:       dir,
+		entries:   map[string]*list.Element{},
+		lru:       list.New(),
+		checksums: map[string]string{},
+	}
+}
+
+// Render returns the rendered chart from the cache, rendering it if the cache
+// does not contain a rendering for the current chart and values.
+func (c *RenderCache) Render(chartPath string, namespace string, values interface{}) (map[string][]manifest.Manifest, error) {
+	if c == nil || c.size <= 0 {
+		return renderHelmChart(chartPath, namespace, values)
+	}
+
+	checksum, err := c.chartChecksum(chartPath)
+	if err != nil {
+		return renderHelmChart(chartPath, namespace, values)
+	}
+	key, err := renderCacheKey(checksum, namespace, values)
+	if err != nil {
+		return renderHelmChart(chartPath, namespace, values)
+	}
+	if renderings, ok := c.get(key); ok {
+		return copyRenderings(renderings), nil
+	}
+
+	renderCacheMisses.Inc()
+	renderings, err := renderHelmChart(chartPath, namespace, values)
+	if err != nil {
+		return renderings, err
+	}
+	c.put(key, renderings)
+	return copyRenderings(renderings), nil
+}
+
+func (c *RenderCache) get(key string) (map[string][]manifest.Manifest, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(element)
+		renderCacheHits.WithLabelValues(renderCacheTierMemory).Inc()
+		return element.Value.(*renderCacheEntry).renderings, true
+	}
+	if renderings, ok := c.load(key); ok {
+		c.add(key, renderings)
+		renderCacheHits.WithLabelValues(renderCacheTierDisk).Inc()
+		return renderings, true
+	}
+	return nil, false
+}
+
+func (c *RenderCache) put(key string, renderings map[string][]manifest.Manifest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.add(key, renderings)
+	c.store(key, renderings)
+}
+
+// add inserts the renderings into the in-memory cache, evicting the least
+// recently used entries if the cache is full.  c.mu must be held.
+func (c *RenderCache) add(key string, renderings map[string][]manifest.Manifest) {
+	if element, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&renderCacheEntry{key: key, renderings: renderings})
+	for c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*renderCacheEntry).key)
+	}
+}
+
+// load reads the renderings persisted for key.  Unreadable entries are
+// treated as missing.  c.mu must be held.
+func (c *RenderCache) load(key string) (map[string][]manifest.Manifest, bool) {
+	if c.dir == "" {
+		return nil, false
+	}
+	data, err := ioutil.ReadFile(c.entryPath(key))
+	if err != nil {
+		return nil, false
+	}
+	renderings := map[string][]manifest.Manifest{}
+	if err := json.Unmarshal(data, &renderings); err != nil {
+		return nil, false
+	}
+	// mark the entry as recently used, so it is not the first to be removed
+	now := time.Now()
+	os.Chtimes(c.entryPath(key), now, now)
+	return renderings, true
+}
+
+// store persists the renderings, removing the oldest persisted entries if the
+// directory holds more entries than the cache.  The cache is only an
+// optimization, so errors are ignored.  c.mu must be held.
+func (c *RenderCache) store(key string, renderings map[string][]manifest.Manifest) {
+	if c.dir == "" {
+		return
+	}
+	data, err := json.Marshal(renderings)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return
+	}
+	tmpFile, err := ioutil.TempFile(c.dir, ".tmp-")
+	if err != nil {
+		return
+	}
+	_, err = tmpFile.Write(data)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpFile.Name(), c.entryPath(key))
+	}
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return
+	}
+
+	files, err := filepath.Glob(filepath.Join(c.dir, "*.json"))
+	if err != nil || len(files) <= c.size {
+		return
+	}
+	modTimes := map[string]int64{}
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil {
+			modTimes[file] = info.ModTime().UnixNano()
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return modTimes[files[i]] < modTimes[files[j]] })
+	for _, file := range files[:len(files)-c.size] {
+		os.Remove(file)
+	}
+}
+
+func (c *RenderCache) entryPath(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// chartChecksum returns the checksum of the chart directory, computing it the
+// first time it is requested
+func (c *RenderCache) chartChecksum(chartPath string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if checksum, ok := c.checksums[chartPath]; ok {
+		return checksum, nil
+	}
+	checksum, err := chartChecksum(chartPath)
+	if err != nil {
+		return "", err
+	}
+	c.checksums[chartPath] = checksum
+	return checksum, nil
+}
+
+// renderCacheKey returns the key identifying the rendering of the chart with
+// the specified checksum, with the specified namespace and values.
+func renderCacheKey(checksum string, namespace string, values interface{}) (string, error) {
+	rawValues, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\x00%s\x00", checksum, namespace)
+	hash.Write(rawValues)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// chartChecksum returns a checksum of the names and contents of the files in
+// the chart directory.
+func chartChecksum(chartPath string) (string, error) {
+	hash := sha256.New()
+	err := filepath.Walk(chartPath, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		relPath, err := filepath.Rel(chartPath, filePath)
+		if err != nil {
+			return err
+		}
+		file, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		fmt.Fprintf(hash, "%s\x00%d\x00", filepath.ToSlash(relPath), info.Size())
+		_, err = io.Copy(hash, file)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// copyRenderings returns a copy of renderings, so callers cannot modify the
+// cached renderings.
+func copyRenderings(renderings map[string][]manifest.Manifest) map[string][]manifest.Manifest {
+	copied := make(map[string][]manifest.Manifest, len(renderings))
+	for chartName, manifests := range renderings {
+		copied[chartName] = append([]manifest.Manifest{}, manifests...)
+	}
+	return copied
+}
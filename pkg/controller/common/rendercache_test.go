@@ -0,0 +1,203 @@
+package common
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+const testChartTemplate = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test
+  namespace: {{ .Release.Namespace }}
+data:
+  value: {{ .Values.value }}
+`
+
+func TestRenderCache(t *testing.T) {
+	chartDir, cacheDir := newTestChart(t), tempDir(t)
+	defer os.RemoveAll(chartDir)
+	defer os.RemoveAll(cacheDir)
+
+	steps := []struct {
+		name           string
+		cache          *RenderCache
+		values         map[string]interface{}
+		modifyChart    bool
+		expectedValue  string
+		expectedMemory float64
+		expectedDisk   float64
+		expectedMisses float64
+	}{
+		{
+			name:           "initial rendering",
+			values:         map[string]interface{}{"value": "a"},
+			expectedValue:  "a",
+			expectedMisses: 1,
+		},
+		{
+			name:           "unchanged values",
+			values:         map[string]interface{}{"value": "a"},
+			expectedValue:  "a",
+			expectedMemory: 1,
+		},
+		{
+			name:           "changed values",
+			values:         map[string]interface{}{"value": "b"},
+			expectedValue:  "b",
+			expectedMisses: 1,
+		},
+		{
+			name:           "changed chart without restart",
+			values:         map[string]interface{}{"value": "b"},
+			modifyChart:    true,
+			expectedValue:  "b",
+			expectedMemory: 1,
+		},
+		{
+			name:           "operator restarted with changed chart",
+			cache:          NewRenderCache(2, cacheDir),
+			values:         map[string]interface{}{"value": "b"},
+			expectedValue:  "b-modified",
+			expectedMisses: 1,
+		},
+		{
+			name:          "restarted operator",
+			cache:         NewRenderCache(2, cacheDir),
+			values:        map[string]interface{}{"value": "b"},
+			expectedValue: "b-modified",
+			expectedDisk:  1,
+		},
+		{
+			name:           "values not rendered with changed chart",
+			values:         map[string]interface{}{"value": "a"},
+			expectedValue:  "a-modified",
+			expectedMisses: 1,
+		},
+	}
+
+	cache := NewRenderCache(2, cacheDir)
+	for _, step := range steps {
+		if step.cache != nil {
+			cache = step.cache
+		}
+		if step.modifyChart {
+			writeChartTemplate(t, chartDir, strings.Replace(testChartTemplate, "{{ .Values.value }}", "{{ .Values.value }}-modified", 1))
+		}
+		memory, disk, misses := renderCacheCounts(t)
+		renderings, err := cache.Render(chartDir, "istio-system", step.values)
+		if err != nil {
+			t.Fatalf("%s: unexpected error rendering chart: %v", step.name, err)
+		}
+		if len(renderings["test"]) != 1 || !strings.Contains(renderings["test"][0].Content, "value: "+step.expectedValue) {
+			t.Errorf("%s: unexpected renderings: %v", step.name, renderings)
+		}
+		newMemory, newDisk, newMisses := renderCacheCounts(t)
+		if newMemory-memory != step.expectedMemory || newDisk-disk != step.expectedDisk || newMisses-misses != step.expectedMisses {
+			t.Errorf("%s: unexpected cache hits (memory: %v, disk: %v) and misses (%v)", step.name, newMemory-memory, newDisk-disk, newMisses-misses)
+		}
+		// renderings returned by the cache must not affect cached renderings
+		renderings["test"][0].Content = ""
+	}
+
+	files, _ := filepath.Glob(filepath.Join(cacheDir, "*.json"))
+	if len(files) != 2 {
+		t.Errorf("expected persisted renderings to be limited to the size of the cache: %v", files)
+	}
+}
+
+func TestRenderCacheDisabled(t *testing.T) {
+	chartDir := newTestChart(t)
+	defer os.RemoveAll(chartDir)
+
+	cache := NewRenderCache(0, "")
+	memory, disk, misses := renderCacheCounts(t)
+	for i := 0; i < 2; i++ {
+		if _, err := cache.Render(chartDir, "istio-system", map[string]interface{}{"value": "a"}); err != nil {
+			t.Fatalf("unexpected error rendering chart: %v", err)
+		}
+	}
+	newMemory, newDisk, newMisses := renderCacheCounts(t)
+	if newMemory != memory || newDisk != disk || newMisses != misses {
+		t.Errorf("expected disabled cache not to record hits or misses")
+	}
+}
+
+func TestRenderCacheIsOnlyReadableByOperator(t *testing.T) {
+	chartDir, cacheDir := newTestChart(t), tempDir(t)
+	defer os.RemoveAll(chartDir)
+	defer os.RemoveAll(cacheDir)
+
+	renderingsDir := filepath.Join(cacheDir, "renderings")
+	cache := NewRenderCache(2, renderingsDir)
+	if _, err := cache.Render(chartDir, "istio-system", map[string]interface{}{"value": "a"}); err != nil {
+		t.Fatalf("unexpected error rendering chart: %v", err)
+	}
+	info, err := os.Stat(renderingsDir)
+	if err != nil {
+		t.Fatalf("unexpected error reading cache directory: %v", err)
+	}
+	if info.Mode().Perm() != 0700 {
+		t.Errorf("unexpected permissions of cache directory: %v", info.Mode().Perm())
+	}
+	files, _ := filepath.Glob(filepath.Join(renderingsDir, "*.json"))
+	if len(files) != 1 {
+		t.Fatalf("expected the rendering to be persisted: %v", files)
+	}
+	if info, err := os.Stat(files[0]); err != nil {
+		t.Errorf("unexpected error reading persisted rendering: %v", err)
+	} else if info.Mode().Perm() != 0600 {
+		t.Errorf("unexpected permissions of persisted rendering: %v", info.Mode().Perm())
+	}
+}
+
+func newTestChart(t *testing.T) string {
+	t.Helper()
+	chartDir := tempDir(t)
+	if err := ioutil.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("name: test\nversion: 1.0.0\n"), 0644); err != nil {
+		t.Fatalf("unexpected error writing chart: %v", err)
+	}
+	writeChartTemplate(t, chartDir, testChartTemplate)
+	return chartDir
+}
+
+func writeChartTemplate(t *testing.T, chartDir, template string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(chartDir, "templates"), 0755); err != nil {
+		t.Fatalf("unexpected error writing chart: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(chartDir, "templates", "configmap.yaml"), []byte(template), 0644); err != nil {
+		t.Fatalf("unexpected error writing chart: %v", err)
+	}
+}
+
+func tempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "render-cache")
+	if err != nil {
+		t.Fatalf("unexpected error creating temporary directory: %v", err)
+	}
+	return dir
+}
+
+func renderCacheCounts(t *testing.T) (memory, disk, misses float64) {
+	t.Helper()
+	return counterValue(t, renderCacheHits.WithLabelValues(renderCacheTierMemory)),
+		counterValue(t, renderCacheHits.WithLabelValues(renderCacheTierDisk)),
+		counterValue(t, renderCacheMisses)
+}
+
+func counterValue(t *testing.T, counter prometheus.Counter) float64 {
+	t.Helper()
+	metric := &dto.Metric{}
+	if err := counter.Write(metric); err != nil {
+		t.Fatalf("unexpected error reading metric: %v", err)
+	}
+	return metric.GetCounter().GetValue()
+}
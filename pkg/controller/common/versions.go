@@ -0,0 +1,401 @@
+package common
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Masterminds/semver"
+	"github.com/ghodss/yaml"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/maistra/istio-operator/pkg/apis/maistra"
+)
+
+// VersionDescriptorFile is the name of the file describing a version, located
+// in the charts directory of the version, e.g. helm/v1.1/version.yaml
+const VersionDescriptorFile = "version.yaml"
+
+var (
+	// releaseRegexp matches the names of the charts directories, i.e. the
+	// releases shipped with the operator, e.g. v1.1 or v1.1.3
+	releaseRegexp = regexp.MustCompile(`^v[0-9]+\.[0-9]+(\.[0-9]+)?$`)
+	// minorVersionRegexp matches versions without a patch level, e.g. v1.1
+	minorVersionRegexp = regexp.MustCompile(`^v[0-9]+\.[0-9]+$`)
+)
+
+// VersionDescriptor describes the settings specific to a control plane
+// version shipped with the operator
+type VersionDescriptor struct {
+	// Version is the version described, e.g. v1.1 or v1.1.3.  It must match
+	// the name of the charts directory.
+	Version string `json:"version"`
+	// CNI describes the Istio CNI plugin used by the version
+	CNI CNIDescriptor `json:"cni"`
+	// UpgradeFrom lists the older versions that a control plane may be
+	// upgraded from to this version, e.g. v1.0, including all of their patch
+	// releases
+	UpgradeFrom []string `json:"upgradeFrom,omitempty"`
+	// Deprecations lists the values of spec.istio that this version does not
+	// support.  They are rejected when a control plane is updated to this
+	// version.
+	Deprecations []DeprecationRule `json:"deprecations,omitempty"`
+	// Validations lists the checks run when a control plane of this version
+	// is created or updated.  The checks are implemented by the validating
+	// webhook, which rejects unknown checks at startup.
+	Validations []string `json:"validations,omitempty"`
+	// UpgradeChecks lists the checks run when a control plane is upgraded
+	// from this version to a newer version
+	UpgradeChecks []string `json:"upgradeChecks,omitempty"`
+	// DowngradeChecks lists the checks run when a control plane is downgraded
+	// from this version to an older version
+	DowngradeChecks []string `json:"downgradeChecks,omitempty"`
+}
+
+// CNIDescriptor describes the Istio CNI plugin used by a version
+type CNIDescriptor struct {
+	// NetworkName is the name of the CNI network used to configure routing
+	// rules for meshes of this version
+	NetworkName string `json:"networkName"`
+	// ImageEnv is the environment variable specifying the CNI image for this
+	// version.  Versions sharing the CNI plugin of an older version omit it.
+	ImageEnv string `json:"imageEnv,omitempty"`
+	// ImageValue is the value of the istio_cni chart the image is passed in
+	ImageValue string `json:"imageValue,omitempty"`
+}
+
+// DeprecationRule identifies a value of spec.istio that is not supported
+type DeprecationRule struct {
+	// Path of the value, e.g. global.proxy.envoyAccessLogService.enabled
+	Path string `json:"path"`
+	// Value that is not supported.  Any value is unsupported if empty.
+	Value string `json:"value,omitempty"`
+	// When lists the values other settings must have for the rule to apply,
+	// keyed by path
+	When map[string]string `json:"when,omitempty"`
+}
+
+// Matches returns true if the values contain the unsupported value
+func (r DeprecationRule) Matches(values map[string]interface{}) bool {
+	for path, value := range r.When {
+		if !hasValue(values, path, value) {
+			return false
+		}
+	}
+	return hasValue(values, r.Path, r.Value)
+}
+
+// Message describes the unsupported value
+func (r DeprecationRule) Message(version string) string {
+	if r.Value == "" {
+		return fmt.Sprintf("%s is not supported in version %s", r.Path, version)
+	}
+	return fmt.Sprintf("%s=%s is not supported in version %s", r.Path, r.Value, version)
+}
+
+// hasValue returns true if the value at path equals value, which is compared
+// case insensitively to allow booleans specified as strings.  If value is
+// empty, any value other than an empty string matches.
+func hasValue(values map[string]interface{}, path, value string) bool {
+	actual, ok, _ := unstructured.NestedFieldNoCopy(values, strings.Split(path, ".")...)
+	if !ok || actual == nil {
+		return false
+	}
+	actualString := fmt.Sprintf("%v", actual)
+	if value == "" {
+		return actualString != ""
+	}
+	return strings.EqualFold(actualString, value)
+}
+
+// CanUpgradeFrom returns true if a control plane may be upgraded from the
+// specified version to this version
+func (d *VersionDescriptor) CanUpgradeFrom(version string) bool {
+	for _, from := range d.UpgradeFrom {
+		if minorVersion(from) == minorVersion(version) {
+			return true
+		}
+	}
+	return false
+}
+
+// minorVersion returns the version without its patch level, e.g. v1.1 for
+// v1.1.3.  Strings that are not versions are returned as is.
+func minorVersion(version string) string {
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return version
+	}
+	return fmt.Sprintf("v%d.%d", v.Major(), v.Minor())
+}
+
+var (
+	versionDescriptorsMu sync.Mutex
+	versionDescriptors   map[string]*VersionDescriptor
+)
+
+// LoadVersionDescriptors discovers the versions shipped with the operator,
+// i.e. the charts directories containing a version descriptor, validates them
+// and makes them the supported versions.  Patch releases of the same version,
+// e.g. v1.1.2 and v1.1.3, are supported as that version, i.e. v1.1.
+func LoadVersionDescriptors() error {
+	descriptors, err := readVersionDescriptors(Options.getChartsRoot())
+	if err != nil {
+		return err
+	}
+	if err := validateVersionDescriptors(descriptors); err != nil {
+		return err
+	}
+
+	versions := []string{}
+	seen := map[string]bool{}
+	for release := range descriptors {
+		if version := minorVersion(release); !seen[version] {
+			seen[version] = true
+			versions = append(versions, version)
+		}
+	}
+	if err := maistra.SetSupportedVersions(versions); err != nil {
+		return err
+	}
+
+	versionDescriptorsMu.Lock()
+	defer versionDescriptorsMu.Unlock()
+	versionDescriptors = descriptors
+	return nil
+}
+
+// GetVersionDescriptor returns the descriptor for the release the specified
+// version resolves to, see ResolveVersion().  The descriptors are loaded on
+// first use, if LoadVersionDescriptors() has not been called.  False is
+// returned if the version cannot be resolved, e.g. because the descriptors
+// cannot be loaded.
+func GetVersionDescriptor(version string) (*VersionDescriptor, bool) {
+	release, err := ResolveVersion(version)
+	if err != nil {
+		return nil, false
+	}
+	// the descriptors have been loaded by ResolveVersion()
+	descriptors, _ := getVersionDescriptors()
+	descriptor, ok := descriptors[release]
+	return descriptor, ok
+}
+
+// GetVersionDescriptors returns the descriptors of all releases, ordered from
+// oldest to newest
+func GetVersionDescriptors() ([]*VersionDescriptor, error) {
+	descriptors, err := getVersionDescriptors()
+	if err != nil {
+		return nil, err
+	}
+	ordered := make([]*VersionDescriptor, 0, len(descriptors))
+	for _, descriptor := range descriptors {
+		ordered = append(ordered, descriptor)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		return semver.MustParse(ordered[i].Version).LessThan(semver.MustParse(ordered[j].Version))
+	})
+	return ordered, nil
+}
+
+// ResolveVersion resolves the version specified in spec.version of a control
+// plane to the release shipped with the operator, i.e. the name of its charts
+// and templates directories.  The version may be:
+//   - empty, which refers to the legacy version
+//   - a version, e.g. v1.1, which resolves to its newest patch release
+//   - a patch release, e.g. v1.1.3
+//   - a range, e.g. ~1.1 or ">=1.1, <1.3", which resolves to the newest
+//     release in the range
+func ResolveVersion(version string) (string, error) {
+	if version == "" {
+		version = maistra.LegacyVersion.String()
+	}
+	descriptors, err := getVersionDescriptors()
+	if err != nil {
+		return "", err
+	}
+	constraint := version
+	if minorVersionRegexp.MatchString(version) {
+		constraint = "~" + version
+	} else if _, ok := descriptors[version]; ok {
+		return version, nil
+	}
+	constraints, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return "", fmt.Errorf("invalid version %s: %v", version, err)
+	}
+
+	var resolved string
+	var newest *semver.Version
+	for release := range descriptors {
+		// release names have been validated when loading the descriptors
+		v := semver.MustParse(release)
+		if constraints.Check(v) && (newest == nil || v.GreaterThan(newest)) {
+			resolved = release
+			newest = v
+		}
+	}
+	if resolved == "" {
+		return "", fmt.Errorf("version %s does not match any supported version; supported versions are: %v", version, maistra.GetSupportedVersions())
+	}
+	return resolved, nil
+}
+
+// IsVersionRange returns true if the version specified in spec.version of a
+// control plane is a range, e.g. ~1.1, rather than a version or a patch
+// release
+func IsVersionRange(version string) bool {
+	return version != "" && !releaseRegexp.MatchString(version)
+}
+
+// RangeContains returns true if the release shipped with the operator, e.g.
+// v1.1.3, is within the range specified in spec.version of a control plane
+func RangeContains(versionRange, release string) bool {
+	constraints, err := semver.NewConstraint(versionRange)
+	if err != nil {
+		return false
+	}
+	v, err := semver.NewVersion(release)
+	if err != nil {
+		return false
+	}
+	return constraints.Check(v)
+}
+
+// AvailableUpgrades returns the newest patch release of the same version and
+// the newest newer version a control plane specifying the version can be
+// upgraded to.  Either is empty if no such upgrade is available.  Upgrades of
+// control planes specifying a patch release, e.g. v1.1.2, are patch releases,
+// e.g. v1.1.3 or v1.2.1, while control planes specifying a version, e.g.
+// v1.1, already use its newest patch release and are upgraded to newer
+// versions, e.g. v1.2.  Control planes specifying a range are never upgraded.
+func AvailableUpgrades(version string) (patch string, minor string, err error) {
+	if IsVersionRange(version) {
+		return "", "", nil
+	}
+	current, err := ResolveVersion(version)
+	if err != nil {
+		return "", "", err
+	}
+	descriptors, err := GetVersionDescriptors()
+	if err != nil {
+		return "", "", err
+	}
+	currentRelease := semver.MustParse(current)
+	pinned := version != "" && !minorVersionRegexp.MatchString(version)
+	// ordered from oldest to newest, so the newest upgrades are kept
+	for _, descriptor := range descriptors {
+		release := semver.MustParse(descriptor.Version)
+		if !release.GreaterThan(currentRelease) {
+			continue
+		}
+		if minorVersion(descriptor.Version) == minorVersion(current) {
+			if pinned {
+				patch = descriptor.Version
+			}
+		} else if descriptor.CanUpgradeFrom(current) {
+			if pinned {
+				minor = descriptor.Version
+			} else {
+				minor = minorVersion(descriptor.Version)
+			}
+		}
+	}
+	return patch, minor, nil
+}
+
+// getVersionDescriptors returns the descriptors loaded by
+// LoadVersionDescriptors(), loading them on first use.  The error loading them
+// is returned until they have been loaded successfully, e.g. after the
+// resource directory has been configured.
+func getVersionDescriptors() (map[string]*VersionDescriptor, error) {
+	versionDescriptorsMu.Lock()
+	descriptors := versionDescriptors
+	versionDescriptorsMu.Unlock()
+	if descriptors != nil {
+		return descriptors, nil
+	}
+	if err := LoadVersionDescriptors(); err != nil {
+		return nil, fmt.Errorf("error loading version descriptors: %v", err)
+	}
+	versionDescriptorsMu.Lock()
+	defer versionDescriptorsMu.Unlock()
+	return versionDescriptors, nil
+}
+
+func readVersionDescriptors(chartsRoot string) (map[string]*VersionDescriptor, error) {
+	entries, err := ioutil.ReadDir(chartsRoot)
+	if err != nil {
+		return nil, fmt.Errorf("error reading charts directory %s: %v", chartsRoot, err)
+	}
+	descriptors := map[string]*VersionDescriptor{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		descriptorFile := path.Join(chartsRoot, entry.Name(), VersionDescriptorFile)
+		data, err := ioutil.ReadFile(descriptorFile)
+		if err != nil {
+			if os.IsNotExist(err) {
+				// not a version, e.g. overlays
+				continue
+			}
+			return nil, err
+		}
+		if !releaseRegexp.MatchString(entry.Name()) {
+			return nil, fmt.Errorf("charts directory %s containing a version descriptor is not named after a version, e.g. v1.1 or v1.1.3", path.Join(chartsRoot, entry.Name()))
+		}
+		descriptor := &VersionDescriptor{}
+		if err := yaml.Unmarshal(data, descriptor); err != nil {
+			return nil, fmt.Errorf("error parsing version descriptor %s: %v", descriptorFile, err)
+		}
+		if descriptor.Version != entry.Name() {
+			return nil, fmt.Errorf("version descriptor %s describes version %q, expected %q", descriptorFile, descriptor.Version, entry.Name())
+		}
+		descriptors[descriptor.Version] = descriptor
+	}
+	if len(descriptors) == 0 {
+		return nil, fmt.Errorf("no version descriptors found in charts directory %s", chartsRoot)
+	}
+	return descriptors, nil
+}
+
+func validateVersionDescriptors(descriptors map[string]*VersionDescriptor) error {
+	var allErrors []error
+	versions := make([]string, 0, len(descriptors))
+	minorVersions := map[string]bool{}
+	for version := range descriptors {
+		versions = append(versions, version)
+		minorVersions[minorVersion(version)] = true
+	}
+	sort.Strings(versions)
+	for _, version := range versions {
+		descriptor := descriptors[version]
+		if _, err := os.Stat(path.Join(Options.getDefaultTemplatesRoot(), version)); err != nil {
+			allErrors = append(allErrors, fmt.Errorf("version %s: missing default templates: %v", version, err))
+		}
+		if descriptor.CNI.NetworkName == "" {
+			allErrors = append(allErrors, fmt.Errorf("version %s: cni.networkName must be set", version))
+		}
+		if (descriptor.CNI.ImageEnv == "") != (descriptor.CNI.ImageValue == "") {
+			allErrors = append(allErrors, fmt.Errorf("version %s: cni.imageEnv and cni.imageValue must be set together", version))
+		}
+		for _, from := range descriptor.UpgradeFrom {
+			if !minorVersions[minorVersion(from)] {
+				allErrors = append(allErrors, fmt.Errorf("version %s: unknown version %s in upgradeFrom", version, from))
+			}
+		}
+		for index, rule := range descriptor.Deprecations {
+			if rule.Path == "" {
+				allErrors = append(allErrors, fmt.Errorf("version %s: deprecations[%d].path must be set", version, index))
+			}
+		}
+	}
+	return utilerrors.NewAggregate(allErrors)
+}
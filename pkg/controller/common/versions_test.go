@@ -0,0 +1,299 @@
+package common
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/maistra/istio-operator/pkg/apis/maistra"
+	"github.com/maistra/istio-operator/pkg/controller/common/test/assert"
+)
+
+// useRepositoryVersionDescriptors loads the version descriptors of the charts
+// in the repository
+func useRepositoryVersionDescriptors(t *testing.T) {
+	Options.ChartsDir = "../../../resources/helm"
+	Options.DefaultTemplatesDir = "../../../resources/smcp-templates"
+	if err := LoadVersionDescriptors(); err != nil {
+		t.Fatalf("unexpected error loading version descriptors: %v", err)
+	}
+}
+
+func TestLoadVersionDescriptors(t *testing.T) {
+	useRepositoryVersionDescriptors(t)
+
+	descriptors, err := GetVersionDescriptors()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	versions := []string{}
+	for _, descriptor := range descriptors {
+		versions = append(versions, descriptor.Version)
+	}
+	assert.DeepEquals(versions, []string{"v1.0", "v1.1", "v1.2"}, "Unexpected versions", t)
+	assert.Equals(maistra.DefaultVersion.String(), "v1.2", "Expected newest version to be the default version", t)
+
+	descriptor, ok := GetVersionDescriptor("v1.2")
+	assert.True(ok, "Expected descriptor for version v1.2", t)
+	assert.True(descriptor.CanUpgradeFrom("v1.1"), "Expected upgrade from v1.1 to v1.2 to be supported", t)
+	assert.False(descriptor.CanUpgradeFrom("v1.2"), "Expected upgrade from v1.2 to v1.2 not to be supported", t)
+}
+
+// createVersionDescriptors creates charts and templates directories containing
+// the specified version descriptors, keyed by release, and configures them as
+// the resource directories.  The caller must remove the returned directory.
+func createVersionDescriptors(t *testing.T, descriptors map[string]string) string {
+	dir, err := ioutil.TempDir("", "versions")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for release, descriptor := range descriptors {
+		for _, subdir := range []string{path.Join("helm", release), path.Join("templates", release)} {
+			if err := os.MkdirAll(path.Join(dir, subdir), 0755); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		if err := ioutil.WriteFile(path.Join(dir, "helm", release, VersionDescriptorFile), []byte(descriptor), 0644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	Options.ChartsDir = path.Join(dir, "helm")
+	Options.DefaultTemplatesDir = path.Join(dir, "templates")
+	return dir
+}
+
+func TestResolveVersion(t *testing.T) {
+	descriptors := map[string]string{}
+	for _, release := range []string{"v1.0", "v1.1", "v1.1.2", "v1.1.10", "v1.2"} {
+		descriptors[release] = "version: " + release + "\ncni:\n  networkName: istio-cni\n"
+	}
+	dir := createVersionDescriptors(t, descriptors)
+	defer os.RemoveAll(dir)
+	defer useRepositoryVersionDescriptors(t)
+	if err := LoadVersionDescriptors(); err != nil {
+		t.Fatalf("unexpected error loading version descriptors: %v", err)
+	}
+
+	versions := []string{}
+	for _, version := range maistra.GetSupportedVersions() {
+		versions = append(versions, version.String())
+	}
+	assert.DeepEquals(versions, []string{"v1.0", "v1.1", "v1.2"}, "Expected patch releases to be supported as their version", t)
+
+	testCases := []struct {
+		version  string
+		expected string
+	}{
+		{version: "", expected: "v1.0"},
+		{version: "v1.0", expected: "v1.0"},
+		{version: "v1.1", expected: "v1.1.10"},
+		{version: "v1.1.2", expected: "v1.1.2"},
+		{version: "v1.1.0", expected: "v1.1"},
+		{version: "~1.1", expected: "v1.1.10"},
+		{version: "v1.1.x", expected: "v1.1.10"},
+		{version: ">=1.1, <1.1.5", expected: "v1.1.2"},
+		{version: "^1.0", expected: "v1.2"},
+		{version: "v1.1.3"},
+		{version: "v2.x"},
+		{version: "latest"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.version, func(t *testing.T) {
+			resolved, err := ResolveVersion(tc.version)
+			if tc.expected == "" {
+				assert.True(err != nil, "Expected version not to be resolved", t)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			assert.Equals(resolved, tc.expected, "Unexpected resolved version", t)
+			assert.Equals(Options.GetChartsDir(tc.version), path.Join(dir, "helm", tc.expected), "Unexpected charts directory", t)
+			assert.Equals(Options.GetDefaultTemplatesDir(tc.version), path.Join(dir, "templates", tc.expected), "Unexpected templates directory", t)
+		})
+	}
+}
+
+func TestAvailableUpgrades(t *testing.T) {
+	dir := createVersionDescriptors(t, map[string]string{
+		"v1.0":    "version: v1.0\ncni:\n  networkName: istio-cni\n",
+		"v1.1":    "version: v1.1\ncni:\n  networkName: istio-cni\nupgradeFrom:\n- v1.0\n",
+		"v1.1.2":  "version: v1.1.2\ncni:\n  networkName: istio-cni\nupgradeFrom:\n- v1.0\n",
+		"v1.1.10": "version: v1.1.10\ncni:\n  networkName: istio-cni\nupgradeFrom:\n- v1.0\n",
+		"v1.2":    "version: v1.2\ncni:\n  networkName: istio-cni\nupgradeFrom:\n- v1.1\n",
+	})
+	defer os.RemoveAll(dir)
+	defer useRepositoryVersionDescriptors(t)
+	if err := LoadVersionDescriptors(); err != nil {
+		t.Fatalf("unexpected error loading version descriptors: %v", err)
+	}
+
+	testCases := []struct {
+		name          string
+		version       string
+		expectedPatch string
+		expectedMinor string
+		expectedError bool
+	}{
+		{name: "legacy", version: "", expectedMinor: "v1.1"},
+		{name: "version", version: "v1.1", expectedMinor: "v1.2"},
+		{name: "patch-release", version: "v1.1.2", expectedPatch: "v1.1.10", expectedMinor: "v1.2"},
+		{name: "newest-patch-release", version: "v1.1.10", expectedMinor: "v1.2"},
+		{name: "newest-version", version: "v1.2"},
+		{name: "range", version: "~1.1"},
+		{name: "unsupported", version: "v1.3", expectedError: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			patch, minor, err := AvailableUpgrades(tc.version)
+			if tc.expectedError {
+				assert.True(err != nil, "Expected an error for an unsupported version", t)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			assert.Equals(patch, tc.expectedPatch, "Unexpected patch upgrade", t)
+			assert.Equals(minor, tc.expectedMinor, "Unexpected minor upgrade", t)
+		})
+	}
+}
+
+func TestRangeContains(t *testing.T) {
+	testCases := []struct {
+		versionRange string
+		release      string
+		expected     bool
+	}{
+		{versionRange: "~1.1", release: "v1.1", expected: true},
+		{versionRange: "~1.1", release: "v1.1.10", expected: true},
+		{versionRange: "~1.1", release: "v1.2"},
+		{versionRange: ">=1.1", release: "v1.3", expected: true},
+		{versionRange: ">=1.1", release: "v1.0"},
+		{versionRange: "latest", release: "v1.1"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.versionRange+"/"+tc.release, func(t *testing.T) {
+			assert.Equals(RangeContains(tc.versionRange, tc.release), tc.expected, "Unexpected result", t)
+		})
+	}
+}
+
+func TestInvalidVersionDescriptors(t *testing.T) {
+	testCases := []struct {
+		name       string
+		release    string
+		descriptor string
+	}{
+		{
+			name:       "version-mismatch",
+			descriptor: "version: v1.1\ncni:\n  networkName: istio-cni\n",
+		},
+		{
+			name:       "missing-network-name",
+			descriptor: "version: v9.0\n",
+		},
+		{
+			name:       "unknown-upgrade-from",
+			descriptor: "version: v9.0\ncni:\n  networkName: istio-cni\nupgradeFrom:\n- v8.0\n",
+		},
+		{
+			name:       "image-env-without-value",
+			descriptor: "version: v9.0\ncni:\n  networkName: istio-cni\n  imageEnv: ISTIO_CNI_IMAGE_V9_0\n",
+		},
+		{
+			name:       "invalid-release-name",
+			release:    "v9.0-1",
+			descriptor: "version: v9.0-1\ncni:\n  networkName: istio-cni\n",
+		},
+		{
+			name:       "deprecation-without-path",
+			descriptor: "version: v9.0\ncni:\n  networkName: istio-cni\ndeprecations:\n- value: \"true\"\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			release := tc.release
+			if release == "" {
+				release = "v9.0"
+			}
+			dir := createVersionDescriptors(t, map[string]string{release: tc.descriptor})
+			defer os.RemoveAll(dir)
+			defer useRepositoryVersionDescriptors(t)
+			assert.True(LoadVersionDescriptors() != nil, "Expected invalid version descriptor to be rejected", t)
+		})
+	}
+}
+
+func TestVersionDescriptorLoadErrorIsReported(t *testing.T) {
+	defer useRepositoryVersionDescriptors(t)
+	versionDescriptorsMu.Lock()
+	versionDescriptors = nil
+	versionDescriptorsMu.Unlock()
+	Options.ChartsDir = "does-not-exist"
+
+	_, err := ResolveVersion("v1.1")
+	assert.True(err != nil, "Expected error loading version descriptors to be returned", t)
+	_, err = GetVersionDescriptors()
+	assert.True(err != nil, "Expected error loading version descriptors to be returned", t)
+	_, _, err = AvailableUpgrades("v1.1")
+	assert.True(err != nil, "Expected error loading version descriptors to be returned", t)
+}
+
+func TestDeprecationRuleMatches(t *testing.T) {
+	testCases := []struct {
+		name     string
+		rule     DeprecationRule
+		values   map[string]interface{}
+		expected bool
+	}{
+		{
+			name:     "bool-value",
+			rule:     DeprecationRule{Path: "global.proxy.envoyAccessLogService.enabled", Value: "true"},
+			values:   map[string]interface{}{"global": map[string]interface{}{"proxy": map[string]interface{}{"envoyAccessLogService": map[string]interface{}{"enabled": true}}}},
+			expected: true,
+		},
+		{
+			name:     "string-value",
+			rule:     DeprecationRule{Path: "telemetry.enabled", Value: "true"},
+			values:   map[string]interface{}{"telemetry": map[string]interface{}{"enabled": "True"}},
+			expected: true,
+		},
+		{
+			name:     "other-value",
+			rule:     DeprecationRule{Path: "telemetry.enabled", Value: "true"},
+			values:   map[string]interface{}{"telemetry": map[string]interface{}{"enabled": false}},
+			expected: false,
+		},
+		{
+			name:     "any-value",
+			rule:     DeprecationRule{Path: "kiali.jaegerInClusterURL"},
+			values:   map[string]interface{}{"kiali": map[string]interface{}{"jaegerInClusterURL": "jaeger-query"}},
+			expected: true,
+		},
+		{
+			name:     "missing-value",
+			rule:     DeprecationRule{Path: "kiali.jaegerInClusterURL"},
+			values:   map[string]interface{}{},
+			expected: false,
+		},
+		{
+			name:     "when-not-matched",
+			rule:     DeprecationRule{Path: "kiali.jaegerInClusterURL", When: map[string]string{"kiali.enabled": "true"}},
+			values:   map[string]interface{}{"kiali": map[string]interface{}{"enabled": false, "jaegerInClusterURL": "jaeger-query"}},
+			expected: false,
+		},
+		{
+			name:     "when-matched",
+			rule:     DeprecationRule{Path: "kiali.jaegerInClusterURL", When: map[string]string{"kiali.enabled": "true"}},
+			values:   map[string]interface{}{"kiali": map[string]interface{}{"enabled": true, "jaegerInClusterURL": "jaeger-query"}},
+			expected: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equals(tc.rule.Matches(tc.values), tc.expected, "Unexpected result", t)
+		})
+	}
+}
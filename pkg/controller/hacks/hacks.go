@@ -30,10 +30,20 @@ func RemoveTypeObjectFieldsFromCRDSchema(ctx context.Context, crd *apiextensions
 	log := common.LogFromContext(ctx)
 	log.Info("The API server rejected the CRD. Removing type:object fields from the CRD schema and trying again.")
 
-	if crd.Spec.Validation == nil || crd.Spec.Validation.OpenAPIV3Schema == nil {
-		return fmt.Errorf("Could not remove type:object fields from CRD schema as no spec.validation.openAPIV3Schema exists")
+	found := false
+	if crd.Spec.Validation != nil && crd.Spec.Validation.OpenAPIV3Schema != nil {
+		removeTypeObjectField(crd.Spec.Validation.OpenAPIV3Schema)
+		found = true
+	}
+	for _, version := range crd.Spec.Versions {
+		if version.Schema != nil && version.Schema.OpenAPIV3Schema != nil {
+			removeTypeObjectField(version.Schema.OpenAPIV3Schema)
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("Could not remove type:object fields from CRD schema as no spec.validation.openAPIV3Schema or spec.versions[].schema.openAPIV3Schema exists")
 	}
-	removeTypeObjectField(crd.Spec.Validation.OpenAPIV3Schema)
 	return nil
 }
 
@@ -41,6 +41,15 @@ func Add(mgr manager.Manager) error {
 	}
 
 	reconciler := newReconciler(mgr.GetClient(), mgr.GetScheme(), mgr.GetRecorder(controllerName), operatorNamespace, cniConfig)
+	if err := reconciler.PatchFactory.EnableServerSideApply(mgr.GetConfig(), mgr.GetRESTMapper()); err != nil {
+		return err
+	}
+	if common.Options.OrphanSweepInterval > 0 {
+		sweeper := newOrphanSweeper(reconciler.ControllerResources, common.Options.OrphanSweepInterval, common.Options.OrphanSweepDryRun)
+		if err := mgr.Add(sweeper); err != nil {
+			return err
+		}
+	}
 	return add(mgr, reconciler)
 }
 
@@ -78,6 +87,12 @@ func add(mgr manager.Manager, r *ControlPlaneReconciler) error {
 		return err
 	}
 
+	// Watch for changes to templates referenced by ServiceMeshControlPlanes
+	if err = c.Watch(&source.Kind{Type: &v1.ServiceMeshControlPlaneTemplate{}},
+		&handler.EnqueueRequestsFromMapFunc{ToRequests: templateMapper(ctx, mgr.GetClient())}); err != nil {
+		return err
+	}
+
 	// watch created resources for use in synchronizing ready status
 	if err = c.Watch(&source.Kind{Type: &appsv1.Deployment{}},
 		&handler.EnqueueRequestForOwner{
@@ -168,6 +183,7 @@ type ControlPlaneInstanceReconciler interface {
 	Reconcile(ctx context.Context) (reconcile.Result, error)
 	UpdateReadiness(ctx context.Context) error
 	Delete(ctx context.Context) error
+	Plan(ctx context.Context) error
 	SetInstance(instance *v1.ServiceMeshControlPlane)
 	IsFinished() bool
 }
@@ -192,11 +208,14 @@ func (r *ControlPlaneReconciler) Reconcile(request reconcile.Request) (reconcile
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
 			// Return and don't requeue
 			log.Info("ServiceMeshControlPlane deleted")
+			common.DeleteControlPlaneMetrics(request.Namespace, request.Name)
+			r.PatchFactory.ForgetAppliedOwnedBy(request.Namespace)
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object
 		return reconcile.Result{}, err
 	}
+	common.RecordControlPlaneMetrics(instance)
 
 	key, reconciler := r.getOrCreateReconciler(instance)
 	defer r.deleteReconcilerIfFinished(key, reconciler)
@@ -219,11 +238,25 @@ func (r *ControlPlaneReconciler) Reconcile(request reconcile.Request) (reconcile
 		return reconcile.Result{}, err
 	}
 
-	if isFullyReconciled(instance) {
-		err := reconciler.UpdateReadiness(ctx)
+	if isDryRun(instance) {
+		if isPlanned(instance) {
+			log.V(1).Info("Changes have already been planned for this generation")
+			return reconcile.Result{}, nil
+		}
+		err := reconciler.Plan(ctx)
 		return reconcile.Result{}, err
 	}
 
+	if isFullyReconciled(instance) {
+		if changed, err := templatesChanged(ctx, r.Client, instance); err != nil {
+			return reconcile.Result{}, err
+		} else if !changed {
+			err := reconciler.UpdateReadiness(ctx)
+			return reconcile.Result{}, err
+		}
+		log.Info("Templates referenced by ServiceMeshControlPlane have changed")
+	}
+
 	return reconciler.Reconcile(ctx)
 }
 
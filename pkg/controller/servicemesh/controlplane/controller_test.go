@@ -109,6 +109,29 @@ func TestUpdateReadinessInvokedWhenInstanceFullyReconciled(t *testing.T) {
 	assert.False(instanceReconciler.reconcileInvoked, "Expected Reconcile() to NOT be invoked on instance reconciler", t)
 }
 
+func TestPlanInvokedWhenDryRunRequested(t *testing.T) {
+	controlPlane := newControlPlane()
+	controlPlane.Annotations = map[string]string{common.DryRunKey: "true"}
+
+	_, _, _, r := createClientAndReconciler(t, controlPlane)
+	assertReconcileSucceeds(r, t)
+
+	assert.True(instanceReconciler.planInvoked, "Expected Plan() to be invoked on instance reconciler", t)
+	assert.False(instanceReconciler.reconcileInvoked, "Expected Reconcile() to NOT be invoked on instance reconciler", t)
+}
+
+func TestPlanNotInvokedWhenGenerationAlreadyPlanned(t *testing.T) {
+	controlPlane := newControlPlane()
+	controlPlane.Annotations = map[string]string{common.DryRunKey: "true"}
+	controlPlane.Status.Plan = &maistrav1.ControlPlanePlan{ObservedGeneration: controlPlane.Generation}
+
+	_, _, _, r := createClientAndReconciler(t, controlPlane)
+	assertReconcileSucceeds(r, t)
+
+	assert.False(instanceReconciler.planInvoked, "Expected Plan() to NOT be invoked on instance reconciler", t)
+	assert.False(instanceReconciler.reconcileInvoked, "Expected Reconcile() to NOT be invoked on instance reconciler", t)
+}
+
 func TestReconcileDoesNothingWhenResourceIsNotFound(t *testing.T) {
 	_, tracker, _, r := createClientAndReconciler(t)
 	assertReconcileSucceeds(r, t)
@@ -138,6 +161,7 @@ type fakeInstanceReconciler struct {
 	reconcileInvoked       bool
 	updateReadinessInvoked bool
 	deleteInvoked          bool
+	planInvoked            bool
 	finished               bool
 }
 
@@ -160,6 +184,11 @@ func (r *fakeInstanceReconciler) Delete(ctx context.Context) error {
 	return nil
 }
 
+func (r *fakeInstanceReconciler) Plan(ctx context.Context) error {
+	r.planInvoked = true
+	return nil
+}
+
 func (r *fakeInstanceReconciler) SetInstance(instance *maistrav1.ServiceMeshControlPlane) {
 }
 
@@ -38,9 +38,34 @@ func (r *controlPlaneInstanceReconciler) Delete(ctx context.Context) error {
 
 	log.Info("Deleting ServiceMeshControlPlane")
 
+	if common.HasDeletionProtection(r.Instance) {
+		// the validating webhook rejects the deletion of protected control
+		// planes, but the webhook may not have been active
+		if blockers, err := common.FindDeletionBlockers(ctx, r.Client, r.Instance); err != nil {
+			return errors.Wrap(err, "Error checking for members of the mesh being deleted")
+		} else if len(blockers) > 0 {
+			message := common.DeletionBlockedMessage(r.Instance, blockers)
+			log.Info(message)
+			r.EventRecorder.Event(r.Instance, corev1.EventTypeWarning, eventReasonDeletionBlocked, message)
+			r.Status.SetCondition(maistrav1.Condition{
+				Type:    maistrav1.ConditionTypeReconciled,
+				Status:  maistrav1.ConditionStatusFalse,
+				Reason:  maistrav1.ConditionReasonDeleting,
+				Message: message,
+			})
+			if err := r.PostStatus(ctx); err != nil {
+				log.Error(err, "Error updating status")
+			}
+			return errors.New(message)
+		}
+	}
+
 	r.EventRecorder.Event(r.Instance, corev1.EventTypeNormal, eventReasonDeleting, "Deleting service mesh")
-	err := r.prune(ctx, "")
+	err := r.pruneAll(ctx)
 	if err == nil {
+		// resources retained by the deletion policy and those removed by the
+		// garbage collector are not pruned individually
+		r.PatchFactory.ForgetAppliedOwnedBy(r.Instance.Namespace)
 		r.EventRecorder.Event(r.Instance, corev1.EventTypeNormal, eventReasonDeleted, "Successfully deleted service mesh resources")
 	} else {
 		r.EventRecorder.Event(r.Instance, corev1.EventTypeWarning, eventReasonFailedDeletingResources, fmt.Sprintf("Error deleting service mesh resources: %s", err))
@@ -0,0 +1,177 @@
+package controlplane
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"sort"
+
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+	"github.com/maistra/istio-operator/pkg/controller/common"
+)
+
+const defaultRevisionHistoryLimit = 10
+
+// recordConfigurationRevision stores the configuration that was just applied
+// in a ControllerRevision owned by the control plane and removes the oldest
+// revisions beyond the history limit.  A configuration that is identical to an
+// existing revision reuses that revision.  Revisions are named after a hash of
+// their configuration; if the name is taken by a revision storing a different
+// configuration, the hash is computed again with an increasing collision
+// count, as the Deployment controller does for the names of its ReplicaSets.
+func (r *controlPlaneInstanceReconciler) recordConfigurationRevision(ctx context.Context) error {
+	log := common.LogFromContext(ctx)
+
+	data, err := json.Marshal(r.Status.LastAppliedConfiguration)
+	if err != nil {
+		return errors.Wrap(err, "error marshalling applied configuration")
+	}
+
+	revisions, err := r.listConfigurationRevisions(ctx)
+	if err != nil {
+		return err
+	}
+
+	var current *appsv1.ControllerRevision
+	var nextRevision int64 = 1
+	revisionsByName := map[string]*appsv1.ControllerRevision{}
+	for index := range revisions {
+		revisionsByName[revisions[index].Name] = &revisions[index]
+		if revisions[index].Revision >= nextRevision {
+			nextRevision = revisions[index].Revision + 1
+		}
+	}
+	name := ""
+	for collisionCount := int32(0); name == ""; collisionCount++ {
+		candidate := configurationRevisionName(r.Instance.Name, data, collisionCount)
+		existing, ok := revisionsByName[candidate]
+		if !ok {
+			name = candidate
+		} else if sameConfiguration(existing.Data.Raw, data) {
+			name, current = candidate, existing
+		} else {
+			log.Info("Configuration revision name collides with another configuration", "name", candidate, "revision", existing.Revision)
+		}
+	}
+
+	if current == nil {
+		current = &appsv1.ControllerRevision{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       r.Instance.Namespace,
+				Labels:          map[string]string{common.ControlPlaneKey: r.Instance.Name},
+				OwnerReferences: r.ownerRefs,
+			},
+			Data:     runtime.RawExtension{Raw: data},
+			Revision: nextRevision,
+		}
+		log.Info("Recording configuration revision", "revision", current.Revision)
+		if err := r.Client.Create(ctx, current); err != nil {
+			return errors.Wrap(err, "error creating configuration revision")
+		}
+		revisions = append(revisions, *current)
+	}
+	r.Status.ConfigurationRevision = current.Revision
+
+	return r.pruneConfigurationRevisions(ctx, revisions, current.Revision)
+}
+
+// pruneConfigurationRevisions deletes the oldest revisions until the number of
+// revisions is within the history limit.  The current revision and the
+// revision selected by spec.rollbackTo are never deleted.
+func (r *controlPlaneInstanceReconciler) pruneConfigurationRevisions(ctx context.Context, revisions []appsv1.ControllerRevision, currentRevision int64) error {
+	log := common.LogFromContext(ctx)
+
+	limit := int32(defaultRevisionHistoryLimit)
+	if r.Instance.Spec.RevisionHistoryLimit != nil {
+		limit = *r.Instance.Spec.RevisionHistoryLimit
+	}
+	if len(revisions) <= int(limit) {
+		return nil
+	}
+
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Revision < revisions[j].Revision
+	})
+	allErrors := []error{}
+	for index, toDelete := 0, len(revisions)-int(limit); index < len(revisions) && toDelete > 0; index++ {
+		revision := &revisions[index]
+		if revision.Revision == currentRevision || (r.Instance.Spec.RollbackTo != nil && revision.Revision == r.Instance.Spec.RollbackTo.Revision) {
+			continue
+		}
+		log.Info("Deleting configuration revision", "revision", revision.Revision)
+		if err := r.Client.Delete(ctx, revision); err != nil && !apierrors.IsNotFound(err) {
+			allErrors = append(allErrors, err)
+		}
+		toDelete--
+	}
+	return utilerrors.NewAggregate(allErrors)
+}
+
+// getConfigurationRevision returns the configuration stored in the specified
+// revision.
+func (r *controlPlaneInstanceReconciler) getConfigurationRevision(ctx context.Context, revision int64) (v1.ControlPlaneSpec, error) {
+	spec := v1.ControlPlaneSpec{}
+	revisions, err := r.listConfigurationRevisions(ctx)
+	if err != nil {
+		return spec, err
+	}
+	for _, controllerRevision := range revisions {
+		if controllerRevision.Revision == revision {
+			if err := json.Unmarshal(controllerRevision.Data.Raw, &spec); err != nil {
+				return spec, errors.Wrapf(err, "error parsing configuration revision %d", revision)
+			}
+			return spec, nil
+		}
+	}
+	return spec, fmt.Errorf("configuration revision %d does not exist", revision)
+}
+
+func (r *controlPlaneInstanceReconciler) listConfigurationRevisions(ctx context.Context) ([]appsv1.ControllerRevision, error) {
+	list := &appsv1.ControllerRevisionList{}
+	err := r.Client.List(ctx, client.MatchingLabels(map[string]string{common.ControlPlaneKey: r.Instance.Name}).InNamespace(r.Instance.Namespace), list)
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing configuration revisions")
+	}
+	return list.Items, nil
+}
+
+// configurationRevisionName returns the name of the revision storing the
+// configuration.  The collision count is only included in the hash if it is
+// not zero, so the first name of a configuration does not depend on it.
+func configurationRevisionName(controlPlaneName string, data []byte, collisionCount int32) string {
+	hash := fnv.New32a()
+	hash.Write(data)
+	if collisionCount != 0 {
+		collisionCountBytes := make([]byte, 4)
+		binary.LittleEndian.PutUint32(collisionCountBytes, uint32(collisionCount))
+		hash.Write(collisionCountBytes)
+	}
+	return fmt.Sprintf("%s-%s", controlPlaneName, rand.SafeEncodeString(fmt.Sprint(hash.Sum32())))
+}
+
+// sameConfiguration returns true if the stored configuration is the same as
+// the configuration being recorded.  The configurations are compared after
+// parsing them, as the stored data may have been reformatted.
+func sameConfiguration(stored, data []byte) bool {
+	var storedConfiguration, configuration interface{}
+	if err := json.Unmarshal(stored, &storedConfiguration); err != nil {
+		return false
+	}
+	if err := json.Unmarshal(data, &configuration); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(storedConfiguration, configuration)
+}
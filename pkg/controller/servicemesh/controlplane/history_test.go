@@ -0,0 +1,112 @@
+package controlplane
+
+import (
+	"encoding/json"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	maistrav1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+	"github.com/maistra/istio-operator/pkg/controller/common"
+	"github.com/maistra/istio-operator/pkg/controller/common/test"
+	"github.com/maistra/istio-operator/pkg/controller/common/test/assert"
+)
+
+func TestConfigurationRevisionHistory(t *testing.T) {
+	cl, _ := test.CreateClient()
+	r := newTestReconciler()
+	r.Client = cl
+	r.Instance.ObjectMeta = metav1.ObjectMeta{Name: "my-smcp", Namespace: "istio-system"}
+	limit := int32(2)
+	r.Instance.Spec.RevisionHistoryLimit = &limit
+
+	applyConfiguration := func(template string) {
+		r.Status.LastAppliedConfiguration = maistrav1.ControlPlaneSpec{Template: template}
+		if err := r.recordConfigurationRevision(ctx); err != nil {
+			t.Fatalf("unexpected error recording configuration revision: %v", err)
+		}
+	}
+
+	applyConfiguration("first")
+	assert.Equals(r.Status.ConfigurationRevision, int64(1), "Unexpected configuration revision", t)
+	applyConfiguration("first")
+	assert.Equals(r.Status.ConfigurationRevision, int64(1), "Expected identical configuration to reuse revision", t)
+	assert.Equals(len(listRevisions(t, cl)), 1, "Unexpected number of configuration revisions", t)
+
+	applyConfiguration("second")
+	assert.Equals(r.Status.ConfigurationRevision, int64(2), "Unexpected configuration revision", t)
+	applyConfiguration("third")
+	assert.Equals(r.Status.ConfigurationRevision, int64(3), "Unexpected configuration revision", t)
+
+	revisions := listRevisions(t, cl)
+	assert.Equals(len(revisions), 2, "Expected oldest configuration revision to be pruned", t)
+	for _, revision := range revisions {
+		if revision.Revision == 1 {
+			t.Errorf("Expected configuration revision 1 to be pruned")
+		}
+	}
+
+	spec, err := r.getConfigurationRevision(ctx, 2)
+	if err != nil {
+		t.Fatalf("unexpected error getting configuration revision: %v", err)
+	}
+	assert.Equals(spec.Template, "second", "Unexpected configuration in revision 2", t)
+
+	if _, err := r.getConfigurationRevision(ctx, 1); err == nil {
+		t.Errorf("Expected error getting pruned configuration revision")
+	}
+}
+
+func TestConfigurationRevisionNameCollision(t *testing.T) {
+	cl, _ := test.CreateClient()
+	r := newTestReconciler()
+	r.Client = cl
+	r.Instance.ObjectMeta = metav1.ObjectMeta{Name: "my-smcp", Namespace: "istio-system"}
+	r.Status.LastAppliedConfiguration = maistrav1.ControlPlaneSpec{Template: "second"}
+	data, err := json.Marshal(r.Status.LastAppliedConfiguration)
+	if err != nil {
+		t.Fatalf("unexpected error marshalling configuration: %v", err)
+	}
+
+	// a revision storing another configuration has the same name
+	colliding := &appsv1.ControllerRevision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      configurationRevisionName("my-smcp", data, 0),
+			Namespace: "istio-system",
+			Labels:    map[string]string{common.ControlPlaneKey: "my-smcp"},
+		},
+		Data:     runtime.RawExtension{Raw: []byte(`{"template":"first"}`)},
+		Revision: 1,
+	}
+	if err := cl.Create(ctx, colliding); err != nil {
+		t.Fatalf("unexpected error creating configuration revision: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := r.recordConfigurationRevision(ctx); err != nil {
+			t.Fatalf("unexpected error recording configuration revision: %v", err)
+		}
+		assert.Equals(r.Status.ConfigurationRevision, int64(2), "Expected colliding configuration to be recorded in a new revision", t)
+		assert.Equals(len(listRevisions(t, cl)), 2, "Unexpected number of configuration revisions", t)
+	}
+
+	for revision, template := range map[int64]string{1: "first", 2: "second"} {
+		spec, err := r.getConfigurationRevision(ctx, revision)
+		if err != nil {
+			t.Fatalf("unexpected error getting configuration revision: %v", err)
+		}
+		assert.Equals(spec.Template, template, "Unexpected configuration in revision", t)
+	}
+}
+
+func listRevisions(t *testing.T, cl client.Client) []appsv1.ControllerRevision {
+	t.Helper()
+	list := &appsv1.ControllerRevisionList{}
+	if err := cl.List(ctx, client.MatchingLabels(map[string]string{common.ControlPlaneKey: "my-smcp"}).InNamespace("istio-system"), list); err != nil {
+		t.Fatalf("unexpected error listing configuration revisions: %v", err)
+	}
+	return list.Items
+}
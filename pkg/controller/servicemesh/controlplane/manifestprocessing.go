@@ -2,6 +2,7 @@ package controlplane
 
 import (
 	"context"
+	"time"
 
 	"github.com/maistra/istio-operator/pkg/controller/common"
 )
@@ -19,14 +20,25 @@ func (r *controlPlaneInstanceReconciler) processComponentManifests(ctx context.C
 	}
 
 	log.Info("reconciling component resources")
+	start := time.Now()
 	status := r.Status.FindComponentByName(componentName)
 	defer func() {
 		updateReconcileStatus(&status.StatusType, err)
+		common.ObserveComponentReconcileDuration(r.Instance.Namespace, r.Instance.Name, componentName, time.Since(start))
 		log.Info("component reconciliation complete")
 	}()
 
 	mp := common.NewManifestProcessor(r.ControllerResources, r.Instance.GetNamespace(), r.meshGeneration, r.Instance.GetNamespace(), r.preprocessObject, r.processNewObject)
-	if err = mp.ProcessManifests(ctx, renderings, status.Resource); err != nil {
+	mp.SetOverlays(r.overlays)
+	mp.SetInventory(r.inventory)
+	mp.SetEventObject(r.Instance)
+	err = mp.ProcessManifests(ctx, renderings, status.Resource)
+	if r.Status.Inventory != nil {
+		// record the resources as they are applied, so they are pruned even if
+		// the reconciliation does not complete before the configuration changes
+		r.Status.Inventory = common.MergeInventory(r.Status.Inventory, r.inventory)
+	}
+	if err != nil {
 		return false, err
 	}
 	if err = r.processNewComponent(componentName, status); err != nil {
@@ -43,10 +55,9 @@ func (r *controlPlaneInstanceReconciler) processComponentManifests(ctx context.C
 		return false, readyErr
 	}
 
-	ready, exists := readinessMap[componentName]
-	if exists && !ready {
+	if ready, exists := readinessMap[componentName]; exists && !ready {
 		r.lastComponent = componentName
-		return false, nil
 	}
-	return true, nil
+	r.completeComponent(ctx, chartName)
+	return r.lastComponent == "", nil
 }
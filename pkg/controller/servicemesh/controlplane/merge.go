@@ -0,0 +1,166 @@
+package controlplane
+
+import (
+	"strings"
+)
+
+const (
+	// patchDirectiveKey is the key used to specify how a value is merged with
+	// the value inherited from a template, e.g. {"$patch": "delete"}
+	patchDirectiveKey = "$patch"
+
+	// patchDirectiveDelete removes the key from the merged values.  It may
+	// only be used in maps.
+	patchDirectiveDelete = "delete"
+	// patchDirectiveReplace replaces the inherited map or list instead of
+	// merging it.  This is the default for lists.
+	patchDirectiveReplace = "replace"
+	// patchDirectiveAppend appends the items in the list to the inherited
+	// list.  It may only be used in lists, e.g. [{"$patch": "append"}, ...]
+	patchDirectiveAppend = "append"
+)
+
+// mergeValues merges a map containing input values on top of a map containing
+// base values, giving preference to the base values for conflicts.  Maps are
+// merged recursively and lists in base replace lists in input, unless
+// overridden by a $patch directive in base.
+func mergeValues(base map[string]interface{}, input map[string]interface{}) map[string]interface{} {
+	return (&valueMerger{}).merge("", base, input)
+}
+
+// valueSources maps the path of a value to the source that supplied it.  The
+// source of a value is that of its longest path prefix listed in the map.
+type valueSources map[string]string
+
+func newValueSources(source string) valueSources {
+	return valueSources{"": source}
+}
+
+// sourceOf returns the source of the value at the specified path
+func (s valueSources) sourceOf(path string) string {
+	for {
+		if source, ok := s[path]; ok {
+			return source
+		}
+		if index := strings.LastIndex(path, "."); index >= 0 {
+			path = path[:index]
+		} else if path != "" {
+			path = ""
+		} else {
+			return ""
+		}
+	}
+}
+
+// copyTo records the sources of the value at path, and all the values it
+// contains, in dest
+func (s valueSources) copyTo(dest valueSources, path string) {
+	if s == nil || dest == nil {
+		return
+	}
+	dest[path] = s.sourceOf(path)
+	prefix := path + "."
+	for key, source := range s {
+		if strings.HasPrefix(key, prefix) {
+			dest[key] = source
+		}
+	}
+}
+
+// valueMerger merges values and tracks the source of each merged value
+type valueMerger struct {
+	baseSources  valueSources
+	inputSources valueSources
+	sources      valueSources
+}
+
+func (m *valueMerger) merge(path string, base map[string]interface{}, input map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(base)+len(input))
+
+	for key, value := range input {
+		//if the key doesn't exist in base, add it
+		if _, exists := base[key]; !exists {
+			result[key] = stripPatchDirectives(value)
+			m.inputSources.copyTo(m.sources, joinPath(path, key))
+		}
+	}
+
+	for key, value := range base {
+		keyPath := joinPath(path, key)
+		inputValue := input[key]
+		switch baseValue := value.(type) {
+		case map[string]interface{}:
+			directive := baseValue[patchDirectiveKey]
+			if directive == patchDirectiveDelete {
+				delete(result, key)
+				continue
+			}
+			// if both are maps, recurse, unless base replaces the input
+			if inputAsMap, ok := inputValue.(map[string]interface{}); ok && directive != patchDirectiveReplace {
+				result[key] = m.merge(keyPath, baseValue, inputAsMap)
+				continue
+			}
+		case []interface{}:
+			if inputAsList, ok := inputValue.([]interface{}); ok && listPatchDirective(baseValue) == patchDirectiveAppend {
+				merged := stripPatchDirectives(inputAsList).([]interface{})
+				result[key] = append(merged, stripPatchDirectives(baseValue).([]interface{})...)
+				m.baseSources.copyTo(m.sources, keyPath)
+				continue
+			}
+		}
+		// base takes precedence over input
+		result[key] = stripPatchDirectives(value)
+		m.baseSources.copyTo(m.sources, keyPath)
+	}
+	return result
+}
+
+// listPatchDirective returns the $patch directive specified in the list, if any
+func listPatchDirective(list []interface{}) interface{} {
+	for _, item := range list {
+		if itemAsMap, ok := item.(map[string]interface{}); ok && len(itemAsMap) == 1 {
+			if directive, ok := itemAsMap[patchDirectiveKey]; ok {
+				return directive
+			}
+		}
+	}
+	return nil
+}
+
+// stripPatchDirectives returns a copy of value with all $patch directives
+// removed, along with any keys marked for deletion
+func stripPatchDirectives(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(typedValue))
+		for key, item := range typedValue {
+			if key == patchDirectiveKey {
+				continue
+			}
+			if itemAsMap, ok := item.(map[string]interface{}); ok && itemAsMap[patchDirectiveKey] == patchDirectiveDelete {
+				continue
+			}
+			result[key] = stripPatchDirectives(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, 0, len(typedValue))
+		for _, item := range typedValue {
+			if itemAsMap, ok := item.(map[string]interface{}); ok && len(itemAsMap) == 1 {
+				if _, isDirective := itemAsMap[patchDirectiveKey]; isDirective {
+					continue
+				}
+			}
+			result = append(result, stripPatchDirectives(item))
+		}
+		return result
+	}
+	return value
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
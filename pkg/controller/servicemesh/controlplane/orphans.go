@@ -0,0 +1,178 @@
+package controlplane
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+	"github.com/maistra/istio-operator/pkg/controller/common"
+)
+
+const (
+	// orphanMinAge is the minimum age of an orphaned resource before it is
+	// deleted.  This prevents resources being created for a new control plane
+	// from being deleted before the control plane shows up in the cache.
+	orphanMinAge = 5 * time.Minute
+
+	// operatorOwner is the owner of the resources the operator creates for
+	// itself, e.g. the CNI plugin.  These are never orphaned.
+	operatorOwner = "maistra-istio-operator"
+
+	eventReasonDeletingOrphan = "DeletingOrphanedResource"
+	eventReasonFoundOrphan    = "OrphanedResourceFound"
+)
+
+// orphanSweeper periodically deletes the cluster scoped resources and the
+// resources in the operator namespace that belong to a control plane that no
+// longer exists.  Owner references cannot be set on these resources, so they
+// are left behind if a control plane is deleted without its finalizer
+// completing, e.g. because the operator was not running.
+type orphanSweeper struct {
+	common.ControllerResources
+	interval time.Duration
+	dryRun   bool
+}
+
+func newOrphanSweeper(controllerResources common.ControllerResources, interval time.Duration, dryRun bool) *orphanSweeper {
+	return &orphanSweeper{
+		ControllerResources: controllerResources,
+		interval:            interval,
+		dryRun:              dryRun,
+	}
+}
+
+// Start sweeps orphaned resources until stop is closed.  Implements
+// manager.Runnable.
+func (s *orphanSweeper) Start(stop <-chan struct{}) error {
+	log := createLogger().WithName("orphan-sweeper")
+	ctx := common.NewContextWithLog(common.NewContext(), log)
+	wait.Until(func() {
+		if err := s.sweep(ctx); err != nil {
+			log.Error(err, "error sweeping orphaned resources")
+		}
+	}, s.interval, stop)
+	return nil
+}
+
+// sweep deletes the orphaned resources, or only reports them, if running in
+// dry-run mode.
+func (s *orphanSweeper) sweep(ctx context.Context) error {
+	log := common.LogFromContext(ctx)
+	log.V(2).Info("sweeping orphaned resources", "dryRun", s.dryRun)
+
+	owners, err := s.controlPlaneOwners(ctx)
+	if err != nil {
+		return err
+	}
+
+	allErrors := []error{}
+	if err := s.sweepResources(ctx, namespacedResources, s.OperatorNamespace, owners); err != nil {
+		allErrors = append(allErrors, err)
+	}
+	if err := s.sweepResources(ctx, nonNamespacedResources, "", owners); err != nil {
+		allErrors = append(allErrors, err)
+	}
+	return utilerrors.NewAggregate(allErrors)
+}
+
+// controlPlaneOwners returns the namespaces of the existing control planes
+func (s *orphanSweeper) controlPlaneOwners(ctx context.Context) (sets.String, error) {
+	controlPlanes := &v1.ServiceMeshControlPlaneList{}
+	if err := s.Client.List(ctx, &client.ListOptions{}, controlPlanes); err != nil {
+		return nil, err
+	}
+	owners := sets.NewString()
+	for _, controlPlane := range controlPlanes.Items {
+		owners.Insert(controlPlane.Namespace)
+	}
+	return owners, nil
+}
+
+func (s *orphanSweeper) sweepResources(ctx context.Context, gvks []schema.GroupVersionKind, namespace string, owners sets.String) error {
+	log := common.LogFromContext(ctx)
+
+	ownedRequirement, err := labels.NewRequirement(common.OwnerKey, selection.Exists, nil)
+	if err != nil {
+		return err
+	}
+	listOptions := (&client.ListOptions{LabelSelector: labels.NewSelector().Add(*ownedRequirement)}).InNamespace(namespace)
+
+	allErrors := []error{}
+	for _, gvk := range gvks {
+		objects := &unstructured.UnstructuredList{}
+		objects.SetGroupVersionKind(gvk)
+		if err := s.Client.List(ctx, listOptions, objects); err != nil {
+			if !meta.IsNoMatchError(err) && !errors.IsNotFound(err) {
+				log.Error(err, "Error retrieving resources to sweep", "type", gvk.String())
+				allErrors = append(allErrors, err)
+			}
+			continue
+		}
+		for index := range objects.Items {
+			object := &objects.Items[index]
+			if !isOrphaned(object, owners) {
+				continue
+			}
+			key := v1.NewResourceKey(object, object)
+			owner := describeOwner(object)
+			if s.dryRun {
+				log.Info("found orphaned resource", "resource", key, "owner", owner)
+				s.recordOrphanEvent(object, eventReasonFoundOrphan, fmt.Sprintf("Resource belongs to %s, which no longer exists; not deleting in dry-run mode", owner))
+				continue
+			}
+			log.Info("deleting orphaned resource", "resource", key, "owner", owner)
+			s.recordOrphanEvent(object, eventReasonDeletingOrphan, fmt.Sprintf("Deleting resource belonging to %s, which no longer exists", owner))
+			err := s.Client.Delete(ctx, object, client.PropagationPolicy(metav1.DeletePropagationBackground))
+			if err == nil || errors.IsNotFound(err) {
+				s.PatchFactory.ForgetApplied(object)
+			}
+			if err == nil {
+				common.RecordResourceOperation(object.GetKind(), common.ResourceOperationDelete)
+			} else if !errors.IsNotFound(err) {
+				log.Error(err, "Error deleting orphaned resource", "resource", key)
+				allErrors = append(allErrors, err)
+			}
+		}
+	}
+	return utilerrors.NewAggregate(allErrors)
+}
+
+func (s *orphanSweeper) recordOrphanEvent(object *unstructured.Unstructured, reason, message string) {
+	if s.EventRecorder != nil {
+		s.EventRecorder.Event(object, corev1.EventTypeNormal, reason, message)
+	}
+}
+
+// isOrphaned returns true if the object belongs to a control plane that is not
+// listed in owners.  Objects that are being deleted, or that may have been
+// created for a control plane that is not yet listed, are never orphaned.
+func isOrphaned(object metav1.Object, owners sets.String) bool {
+	owner, ok := common.GetLabel(object, common.OwnerKey)
+	if !ok || owner == "" || owner == operatorOwner {
+		return false
+	}
+	if object.GetDeletionTimestamp() != nil || time.Since(object.GetCreationTimestamp().Time) < orphanMinAge {
+		return false
+	}
+	return !owners.Has(owner)
+}
+
+// describeOwner returns a description of the control plane owning the object
+func describeOwner(object metav1.Object) string {
+	owner, _ := common.GetLabel(object, common.OwnerKey)
+	return fmt.Sprintf("control plane in namespace %s", owner)
+}
@@ -0,0 +1,107 @@
+package controlplane
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	maistrav1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+	"github.com/maistra/istio-operator/pkg/controller/common"
+	"github.com/maistra/istio-operator/pkg/controller/common/test"
+	"github.com/maistra/istio-operator/pkg/controller/common/test/assert"
+)
+
+func TestOrphanSweeper(t *testing.T) {
+	testCases := []struct {
+		name           string
+		dryRun         bool
+		expectedEvents []string
+	}{
+		{
+			name: "delete",
+			expectedEvents: []string{
+				eventReasonDeletingOrphan + " Deleting resource belonging to control plane in namespace deleted-system",
+			},
+		},
+		{
+			name:   "dry run",
+			dryRun: true,
+			expectedEvents: []string{
+				eventReasonFoundOrphan + " Resource belongs to control plane in namespace deleted-system",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			controlPlane := &maistrav1.ServiceMeshControlPlane{
+				ObjectMeta: metav1.ObjectMeta{Name: "basic-install", Namespace: "istio-system"},
+			}
+			cl, _ := test.CreateClient(
+				controlPlane,
+				newOwnedClusterRole("owned", "istio-system", time.Hour),
+				newOwnedClusterRole("orphaned", "deleted-system", time.Hour),
+				newOwnedClusterRole("new", "new-system", time.Second),
+				newOwnedClusterRole("operator", operatorOwner, time.Hour),
+			)
+			recorder := record.NewFakeRecorder(10)
+			sweeper := newOrphanSweeper(common.ControllerResources{
+				Client:            cl,
+				EventRecorder:     recorder,
+				OperatorNamespace: "istio-operator",
+			}, time.Minute, tc.dryRun)
+
+			// the fake client cannot list the custom resource types swept by
+			// the sweeper, so we only sweep cluster roles
+			owners, err := sweeper.controlPlaneOwners(ctx)
+			if err != nil {
+				t.Fatalf("unexpected error listing control planes: %v", err)
+			}
+			clusterRoleGVK := rbacv1.SchemeGroupVersion.WithKind("ClusterRole")
+			if err := sweeper.sweepResources(ctx, []schema.GroupVersionKind{clusterRoleGVK}, "", owners); err != nil {
+				t.Fatalf("unexpected error sweeping orphaned resources: %v", err)
+			}
+
+			assertClusterRoleExists(t, cl, "owned", true)
+			assertClusterRoleExists(t, cl, "orphaned", tc.dryRun)
+			assertClusterRoleExists(t, cl, "new", true)
+			assertClusterRoleExists(t, cl, "operator", true)
+
+			close(recorder.Events)
+			events := []string{}
+			for event := range recorder.Events {
+				events = append(events, event)
+			}
+			assert.Equals(len(events), len(tc.expectedEvents), "Unexpected number of events", t)
+			for index, expected := range tc.expectedEvents {
+				if index < len(events) && !strings.Contains(events[index], expected) {
+					t.Errorf("expected event %q to contain %q", events[index], expected)
+				}
+			}
+		})
+	}
+}
+
+func newOwnedClusterRole(name, owner string, age time.Duration) runtime.Object {
+	return &rbacv1.ClusterRole{
+		TypeMeta: metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			ResourceVersion:   "1",
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+			Labels:            map[string]string{common.OwnerKey: owner},
+		},
+	}
+}
+
+func assertClusterRoleExists(t *testing.T, cl client.Client, name string, expected bool) {
+	t.Helper()
+	err := cl.Get(ctx, client.ObjectKey{Name: name}, &rbacv1.ClusterRole{})
+	assert.Equals(err == nil, expected, "Unexpected existence of ClusterRole "+name, t)
+}
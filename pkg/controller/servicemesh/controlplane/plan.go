@@ -0,0 +1,98 @@
+package controlplane
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	v1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+	"github.com/maistra/istio-operator/pkg/controller/common"
+)
+
+// Plan renders the charts for the current generation of the
+// ServiceMeshControlPlane and records the changes that reconciling it would
+// make to the cluster in the status, without applying any of them.  CRDs, CNI
+// and mesh RBAC resources are not included in the plan.
+func (r *controlPlaneInstanceReconciler) Plan(ctx context.Context) error {
+	log := common.LogFromContext(ctx)
+	log.Info("Planning ServiceMeshControlPlane changes")
+
+	// the changes are planned by a separate reconciler, so neither the
+	// renderings nor the status computed while planning affect a
+	// reconciliation that is in progress.  Only the plan is posted.
+	planner := &controlPlaneInstanceReconciler{
+		ControllerResources: r.ControllerResources,
+		Instance:            r.Instance,
+		Status:              r.Status.DeepCopy(),
+		cniConfig:           r.cniConfig,
+		plan:                common.NewChangePlan(),
+	}
+	if err := planner.planChanges(ctx); err != nil {
+		return err
+	}
+
+	r.Status.Plan = &v1.ControlPlanePlan{
+		ObservedGeneration: r.Instance.GetGeneration(),
+		Changes:            planner.plan.Changes(),
+	}
+	message := fmt.Sprintf("Planned changes for generation %d: %d resource(s) would be modified", r.Instance.GetGeneration(), len(r.Status.Plan.Changes))
+	log.Info(message)
+	r.EventRecorder.Event(r.Instance, corev1.EventTypeNormal, eventReasonPlanned, message)
+
+	return r.PostStatus(ctx)
+}
+
+// planChanges renders the charts and records the changes that applying them
+// and pruning the resources that are no longer rendered would make in r.plan.
+func (r *controlPlaneInstanceReconciler) planChanges(ctx context.Context) error {
+	log := common.LogFromContext(ctx)
+
+	if err := r.renderCharts(ctx); err != nil {
+		r.EventRecorder.Event(r.Instance, corev1.EventTypeWarning, eventReasonPlanning, fmt.Sprintf("Error rendering helm charts: %s", err))
+		return errors.Wrap(err, "Error rendering helm charts")
+	}
+
+	owner := metav1.NewControllerRef(r.Instance, v1.SchemeGroupVersion.WithKind("ServiceMeshControlPlane"))
+	r.ownerRefs = []metav1.OwnerReference{*owner}
+	r.meshGeneration = v1.CurrentReconciledVersion(r.Instance.GetGeneration())
+
+	allErrors := []error{}
+	for chartName, renderings := range r.renderings {
+		componentName := componentFromChartName(chartName)
+		componentCtx := common.NewContextWithLog(ctx, log.WithValues("Component", componentName))
+		mp := common.NewManifestProcessor(r.ControllerResources, r.Instance.GetNamespace(), r.meshGeneration, r.Instance.GetNamespace(), r.preprocessObject, r.processNewObject)
+		mp.EnableDryRun(r.plan)
+		mp.SetOverlays(r.overlays)
+		mp.SetInventory(r.inventory)
+		mp.SetEventObject(r.Instance)
+		if err := mp.ProcessManifests(componentCtx, renderings, componentName); err != nil {
+			allErrors = append(allErrors, err)
+		}
+	}
+	if len(allErrors) == 0 {
+		// pruning is planned like it is performed, i.e. from the difference
+		// between the recorded inventory and the rendered resources
+		if err := r.prune(ctx, r.meshGeneration); err != nil {
+			allErrors = append(allErrors, err)
+		}
+	}
+	if len(allErrors) > 0 {
+		err := utilerrors.NewAggregate(allErrors)
+		r.EventRecorder.Event(r.Instance, corev1.EventTypeWarning, eventReasonPlanning, fmt.Sprintf("Error planning changes: %s", err))
+		return errors.Wrap(err, "Error planning changes")
+	}
+	return nil
+}
+
+func isDryRun(instance *v1.ServiceMeshControlPlane) bool {
+	dryRun, ok := common.GetAnnotation(instance, common.DryRunKey)
+	return ok && dryRun == "true"
+}
+
+func isPlanned(instance *v1.ServiceMeshControlPlane) bool {
+	return instance.Status.Plan != nil && instance.Status.Plan.ObservedGeneration == instance.GetGeneration()
+}
@@ -0,0 +1,77 @@
+package controlplane
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/helm/pkg/manifest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/maistra/istio-operator/pkg/apis/maistra"
+	maistrav1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+	"github.com/maistra/istio-operator/pkg/controller/common"
+	"github.com/maistra/istio-operator/pkg/controller/common/test"
+	"github.com/maistra/istio-operator/pkg/controller/common/test/assert"
+)
+
+func TestPlanOnlyPostsPlan(t *testing.T) {
+	InitializeGlobals("istio-operator")()
+	if err := common.LoadVersionDescriptors(); err != nil {
+		t.Fatalf("unexpected error loading version descriptors: %v", err)
+	}
+	chartsDir := common.Options.ChartsDir
+	defer func() { common.Options.ChartsDir = chartsDir }()
+	common.Options.ChartsDir = newTestChartsDir(t, "v1.2", "istio")
+
+	renderedKey := maistrav1.ResourceKey("/istio=v1,Kind=ConfigMap")
+	smcp := &maistrav1.ServiceMeshControlPlane{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-smcp", Namespace: "istio-system", Generation: 2},
+		Spec: maistrav1.ControlPlaneSpec{
+			Version: maistra.V1_2.String(),
+			Istio:   maistrav1.HelmValuesType{"global": map[string]interface{}{}},
+		},
+	}
+	smcp.Status.LastAppliedConfiguration = maistrav1.ControlPlaneSpec{Version: maistra.V1_1.String()}
+	smcp.Status.ResolvedVersion = "v1.1.0"
+	smcp.Status.Inventory = []maistrav1.ResourceKey{renderedKey, configMapKey("obsolete"), configMapKey("other-owner")}
+	cl, _ := test.CreateClient(smcp,
+		newTemplate(maistrav1.DefaultTemplate, "", map[string]interface{}{"global": map[string]interface{}{}}),
+		newOwnedConfigMap("obsolete", "istio-system"),
+		newOwnedConfigMap("other-owner", "other-system"))
+
+	r := newTestReconciler()
+	r.Client = cl
+	r.EventRecorder = record.NewFakeRecorder(10)
+	r.Instance = smcp.DeepCopy()
+	r.Status = smcp.Status.DeepCopy()
+	// a reconciliation is in progress
+	r.renderings = map[string][]manifest.Manifest{"istio": {}}
+	r.lastComponent = "istio"
+
+	if err := r.Plan(ctx); err != nil {
+		t.Fatalf("unexpected error planning changes: %v", err)
+	}
+
+	assert.DeepEquals(r.renderings, map[string][]manifest.Manifest{"istio": {}}, "Expected renderings of the reconciliation in progress to be kept", t)
+	assert.Equals(r.lastComponent, "istio", "Expected component of the reconciliation in progress to be kept", t)
+
+	updated := &maistrav1.ServiceMeshControlPlane{}
+	if err := cl.Get(ctx, client.ObjectKey{Name: "my-smcp", Namespace: "istio-system"}, updated); err != nil {
+		t.Fatalf("unexpected error retrieving control plane: %v", err)
+	}
+	assert.Equals(updated.Status.LastAppliedConfiguration.Version, maistra.V1_1.String(), "Expected applied configuration not to change", t)
+	assert.Equals(updated.Status.ResolvedVersion, "v1.1.0", "Expected resolved version not to change", t)
+	assert.Equals(len(updated.Status.AppliedTemplates), 0, "Expected applied templates not to change", t)
+	assert.Equals(len(updated.Status.ValueSources), 0, "Expected value sources not to change", t)
+	assert.DeepEquals(updated.Status.Inventory, smcp.Status.Inventory, "Expected inventory not to change", t)
+
+	if updated.Status.Plan == nil {
+		t.Fatalf("Expected plan to be posted")
+	}
+	assert.DeepEquals(updated.Status.Plan.Changes, []maistrav1.PlannedChange{
+		{Resource: string(renderedKey), Action: maistrav1.PlannedActionCreate},
+		{Resource: string(configMapKey("obsolete")), Action: maistrav1.PlannedActionDelete},
+	}, "Unexpected planned changes", t)
+	assertConfigMapExists(t, cl, "obsolete", true)
+}
@@ -0,0 +1,221 @@
+package controlplane
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/helm/pkg/manifest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+	"github.com/maistra/istio-operator/pkg/controller/common"
+)
+
+const (
+	// progressConfigMapSuffix is appended to the name of the control plane to
+	// name the ConfigMap storing the progress of its reconciliation
+	progressConfigMapSuffix = "-reconcile-progress"
+	// progressKey is the key of the progress in the ConfigMap
+	progressKey = "progress"
+)
+
+// reconcilePhase identifies the steps of a reconciliation
+type reconcilePhase string
+
+const (
+	// reconcilePhasePrerequisites is the installation of the mesh namespace
+	// labels, the CRDs, CNI and the RBAC resources
+	reconcilePhasePrerequisites reconcilePhase = "Prerequisites"
+	// reconcilePhaseComponents is the installation of the components
+	reconcilePhaseComponents reconcilePhase = "Components"
+	// reconcilePhasePruning is the removal of obsolete resources
+	reconcilePhasePruning reconcilePhase = "Pruning"
+)
+
+// reconcileProgress records how far the reconciliation of the renderings of a
+// mesh generation has progressed.  It is stored in a ConfigMap owned by the
+// control plane, so an operator that is restarted during the reconciliation
+// resumes it where it stopped, instead of installing every component again.
+type reconcileProgress struct {
+	// Generation is the mesh generation being reconciled
+	Generation string `json:"generation"`
+	// RenderingsHash identifies the renderings being applied
+	RenderingsHash string `json:"renderingsHash"`
+	// Phase is the current phase of the reconciliation
+	Phase reconcilePhase `json:"phase"`
+	// CompletedComponents lists the charts whose resources have been applied
+	CompletedComponents []string `json:"completedComponents,omitempty"`
+	// PausedComponent is the component the reconciliation is waiting on
+	PausedComponent string `json:"pausedComponent,omitempty"`
+}
+
+// loadProgress initializes the progress of the reconciliation of the current
+// renderings.  The stored progress is resumed if it was recorded for the same
+// mesh generation and renderings, which is reported by the return value.  The
+// progress is only an optimization, so errors reading it start a new
+// reconciliation.
+func (r *controlPlaneInstanceReconciler) loadProgress(ctx context.Context) bool {
+	log := common.LogFromContext(ctx)
+	progress := &reconcileProgress{
+		Generation:     r.meshGeneration,
+		RenderingsHash: renderingsHash(r.renderings),
+		Phase:          reconcilePhasePrerequisites,
+	}
+
+	configMap := &corev1.ConfigMap{}
+	err := r.Client.Get(ctx, client.ObjectKey{Name: progressConfigMapName(r.Instance.Name), Namespace: r.Instance.Namespace}, configMap)
+	if err == nil {
+		r.progressConfigMap = configMap
+		stored := &reconcileProgress{}
+		if err := json.Unmarshal([]byte(configMap.Data[progressKey]), stored); err != nil {
+			log.Info("ignoring invalid reconciliation progress", "error", err.Error())
+		} else if stored.Generation == progress.Generation && stored.RenderingsHash == progress.RenderingsHash {
+			r.progress = stored
+			return true
+		}
+	} else if !apierrors.IsNotFound(err) {
+		log.Error(err, "error retrieving reconciliation progress")
+	}
+
+	r.progress = progress
+	r.saveProgress(ctx)
+	return false
+}
+
+// setPhase records that the reconciliation has entered the phase
+func (r *controlPlaneInstanceReconciler) setPhase(ctx context.Context, phase reconcilePhase) {
+	if r.progress == nil || r.progress.Phase == phase {
+		return
+	}
+	r.progress.Phase = phase
+	r.saveProgress(ctx)
+}
+
+// completeComponent records that the resources of the chart have been
+// applied, along with the component the reconciliation is waiting on, if any
+func (r *controlPlaneInstanceReconciler) completeComponent(ctx context.Context, chartName string) {
+	if r.progress == nil {
+		return
+	}
+	r.progress.CompletedComponents = append(r.progress.CompletedComponents, chartName)
+	r.progress.PausedComponent = r.lastComponent
+	r.saveProgress(ctx)
+}
+
+// restoreCompletedComponents removes the charts that have already been
+// applied from the renderings.  Their resources are rendered without being
+// applied, so they are recorded in the inventory and not pruned, and the
+// overlays matching them are not reported as unmatched.
+func (r *controlPlaneInstanceReconciler) restoreCompletedComponents(ctx context.Context) error {
+	for _, chartName := range r.progress.CompletedComponents {
+		renderings, ok := r.renderings[chartName]
+		if !ok {
+			continue
+		}
+		componentName := componentFromChartName(chartName)
+		mp := common.NewManifestProcessor(r.ControllerResources, r.Instance.GetNamespace(), r.meshGeneration, r.Instance.GetNamespace(), r.preprocessObject, r.processNewObject)
+		mp.EnableRenderOnly(func(obj *unstructured.Unstructured) error {
+			return nil
+		})
+		mp.SetOverlays(r.overlays)
+		mp.SetInventory(r.inventory)
+		if err := mp.ProcessManifests(ctx, renderings, componentName); err != nil {
+			return errors.Wrapf(err, "error restoring component %s", componentName)
+		}
+		delete(r.renderings, chartName)
+	}
+	if r.Status.Inventory != nil {
+		r.Status.Inventory = common.MergeInventory(r.Status.Inventory, r.inventory)
+	}
+	return nil
+}
+
+// saveProgress stores the progress in the ConfigMap.  Errors are logged, as
+// failing to store the progress only means a restarted operator starts the
+// reconciliation from the beginning.
+func (r *controlPlaneInstanceReconciler) saveProgress(ctx context.Context) {
+	log := common.LogFromContext(ctx)
+	data, err := json.Marshal(r.progress)
+	if err != nil {
+		log.Error(err, "error marshalling reconciliation progress")
+		return
+	}
+	if r.progressConfigMap == nil {
+		configMap := &corev1.ConfigMap{}
+		err := r.Client.Get(ctx, client.ObjectKey{Name: progressConfigMapName(r.Instance.Name), Namespace: r.Instance.Namespace}, configMap)
+		if apierrors.IsNotFound(err) {
+			configMap = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            progressConfigMapName(r.Instance.Name),
+					Namespace:       r.Instance.Namespace,
+					Labels:          map[string]string{common.ControlPlaneKey: r.Instance.Name},
+					OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(r.Instance, v1.SchemeGroupVersion.WithKind("ServiceMeshControlPlane"))},
+				},
+				Data: map[string]string{progressKey: string(data)},
+			}
+			if err := r.Client.Create(ctx, configMap); err != nil {
+				log.Error(err, "error creating reconciliation progress")
+				return
+			}
+			r.progressConfigMap = configMap
+			return
+		} else if err != nil {
+			log.Error(err, "error retrieving reconciliation progress")
+			return
+		}
+		r.progressConfigMap = configMap
+	}
+	r.progressConfigMap.Data = map[string]string{progressKey: string(data)}
+	if err := r.Client.Update(ctx, r.progressConfigMap); err != nil {
+		log.Error(err, "error updating reconciliation progress")
+		// retrieve the ConfigMap again when the progress is next saved
+		r.progressConfigMap = nil
+	}
+}
+
+// clearProgress removes the stored progress once the reconciliation is
+// complete
+func (r *controlPlaneInstanceReconciler) clearProgress(ctx context.Context) {
+	r.progress = nil
+	if r.progressConfigMap == nil {
+		return
+	}
+	if err := r.Client.Delete(ctx, r.progressConfigMap); err != nil && !apierrors.IsNotFound(err) {
+		common.LogFromContext(ctx).Error(err, "error deleting reconciliation progress")
+	}
+	r.progressConfigMap = nil
+}
+
+func progressConfigMapName(controlPlaneName string) string {
+	return controlPlaneName + progressConfigMapSuffix
+}
+
+// renderingsHash returns a hash of the rendered charts, which is independent
+// of the order of the charts
+func renderingsHash(renderings map[string][]manifest.Manifest) string {
+	chartNames := make([]string, 0, len(renderings))
+	for chartName := range renderings {
+		chartNames = append(chartNames, chartName)
+	}
+	sort.Strings(chartNames)
+	hash := sha256.New()
+	for _, chartName := range chartNames {
+		hash.Write([]byte(chartName))
+		hash.Write([]byte{0})
+		for _, rendering := range renderings[chartName] {
+			hash.Write([]byte(rendering.Name))
+			hash.Write([]byte{0})
+			hash.Write([]byte(rendering.Content))
+			hash.Write([]byte{0})
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
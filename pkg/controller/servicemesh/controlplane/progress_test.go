@@ -0,0 +1,81 @@
+package controlplane
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/helm/pkg/manifest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/maistra/istio-operator/pkg/controller/common/test"
+	"github.com/maistra/istio-operator/pkg/controller/common/test/assert"
+)
+
+func TestRenderingsHash(t *testing.T) {
+	renderings := func(content string) map[string][]manifest.Manifest {
+		return map[string][]manifest.Manifest{
+			"istio":                 {{Name: "istio/templates/configmap.yaml", Content: content}},
+			"istio/charts/security": {{Name: "istio/charts/security/templates/deployment.yaml", Content: "kind: Deployment"}},
+		}
+	}
+	assert.Equals(renderingsHash(renderings("kind: ConfigMap")), renderingsHash(renderings("kind: ConfigMap")), "Expected identical renderings to have the same hash", t)
+	if renderingsHash(renderings("kind: ConfigMap")) == renderingsHash(renderings("kind: Secret")) {
+		t.Errorf("Expected modified renderings to have a different hash")
+	}
+}
+
+func TestReconcileProgressIsResumed(t *testing.T) {
+	cl, _ := test.CreateClient()
+	newReconciler := func(content string) *controlPlaneInstanceReconciler {
+		r := newTestReconciler()
+		r.Client = cl
+		r.Instance.ObjectMeta = metav1.ObjectMeta{Name: "my-smcp", Namespace: "istio-system", UID: "1234"}
+		r.meshGeneration = "1.1.0-1"
+		r.renderings = map[string][]manifest.Manifest{
+			"istio":                 {{Name: "istio/templates/configmap.yaml", Content: content}},
+			"istio/charts/security": {},
+		}
+		return r
+	}
+
+	r := newReconciler("kind: ConfigMap")
+	if r.loadProgress(ctx) {
+		t.Fatalf("Expected new reconciliation not to resume")
+	}
+	assert.Equals(r.progress.Phase, reconcilePhasePrerequisites, "Unexpected phase of new reconciliation", t)
+	r.setPhase(ctx, reconcilePhaseComponents)
+	r.lastComponent = "istio"
+	r.completeComponent(ctx, "istio")
+
+	// the operator is restarted
+	r = newReconciler("kind: ConfigMap")
+	if !r.loadProgress(ctx) {
+		t.Fatalf("Expected reconciliation of the same renderings to resume")
+	}
+	assert.Equals(r.progress.Phase, reconcilePhaseComponents, "Unexpected phase of resumed reconciliation", t)
+	assert.DeepEquals(r.progress.CompletedComponents, []string{"istio"}, "Unexpected completed components", t)
+	assert.Equals(r.progress.PausedComponent, "istio", "Unexpected paused component", t)
+	r.clearProgress(ctx)
+	assertProgressDeleted(t, cl)
+
+	r = newReconciler("kind: ConfigMap")
+	r.loadProgress(ctx)
+	r.completeComponent(ctx, "istio")
+
+	// the renderings change
+	r = newReconciler("kind: Secret")
+	if r.loadProgress(ctx) {
+		t.Fatalf("Expected reconciliation of different renderings not to resume")
+	}
+	assert.Equals(len(r.progress.CompletedComponents), 0, "Expected no completed components", t)
+}
+
+func assertProgressDeleted(t *testing.T, cl client.Client) {
+	t.Helper()
+	err := cl.Get(ctx, client.ObjectKey{Name: progressConfigMapName("my-smcp"), Namespace: "istio-system"}, &corev1.ConfigMap{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("Expected reconciliation progress to be deleted, got: %v", err)
+	}
+}
@@ -18,7 +18,8 @@ import (
 )
 
 var (
-	// XXX: move this into a ConfigMap so users can override things if they add new types in customized charts
+	// the types scanned when pruning resources that are not recorded in the
+	// inventory, i.e. resources applied by older versions of the operator.
 	// ordered by which types should be deleted, first to last
 	namespacedResources = []schema.GroupVersionKind{
 		schema.GroupVersionKind{Group: "kiali.io", Version: "v1alpha1", Kind: "Kiali"},
@@ -68,24 +69,100 @@ var (
 	}
 )
 
+// prune deletes the resources that were applied for an earlier configuration
+// of the control plane, but are no longer rendered, and records the resources
+// that are currently rendered as the inventory of the control plane.
 func (r *controlPlaneInstanceReconciler) prune(ctx context.Context, generation string) error {
+	if r.Status.Inventory == nil {
+		// the inventory is not recorded by older versions of the operator, so we
+		// have to scan for resources belonging to an earlier generation
+		err := r.pruneUnrecordedResources(ctx, generation, nil)
+		if err == nil {
+			r.Status.Inventory = r.inventory.Keys()
+		}
+		return err
+	}
+	remaining, err := r.pruneInventory(ctx, r.inventory.Removed(r.Status.Inventory), nil)
+	// resources that could not be pruned remain in the inventory, so they are
+	// pruned during the next reconciliation
+	r.Status.Inventory = append(r.inventory.Keys(), remaining...)
+	return err
+}
+
+// pruneAll deletes all resources that have been applied for the control plane,
+// except for those retained by the control plane's deletion policy.
+func (r *controlPlaneInstanceReconciler) pruneAll(ctx context.Context) error {
+	policy := r.Instance.Spec.DeletionPolicy
 	allErrors := []error{}
-	err := r.pruneResources(ctx, namespacedResources, generation, r.Instance.Namespace)
+	remaining, err := r.pruneInventory(ctx, common.NewInventory().Removed(r.Status.Inventory), policy)
 	if err != nil {
 		allErrors = append(allErrors, err)
 	}
-	err = r.pruneResources(ctx, namespacedResources, generation, r.OperatorNamespace)
+	r.Status.Inventory = remaining
+	// resources applied by older versions of the operator are not recorded in
+	// the inventory
+	err = r.pruneUnrecordedResources(ctx, "", policy)
 	if err != nil {
 		allErrors = append(allErrors, err)
 	}
-	err = r.pruneResources(ctx, nonNamespacedResources, generation, "")
+	return utilerrors.NewAggregate(allErrors)
+}
+
+// pruneInventory deletes the resources identified by keys, in order, and
+// returns the keys of the resources that could not be deleted.  Resources that
+// no longer belong to the control plane are not deleted.  Resources retained by
+// the policy are released instead of being deleted.
+func (r *controlPlaneInstanceReconciler) pruneInventory(ctx context.Context, keys []v1.ResourceKey, policy *v1.DeletionPolicy) ([]v1.ResourceKey, error) {
+	log := common.LogFromContext(ctx)
+
+	allErrors := []error{}
+	remaining := []v1.ResourceKey{}
+	for _, key := range keys {
+		object := key.ToUnstructured()
+		err := r.Client.Get(ctx, client.ObjectKey{Namespace: object.GetNamespace(), Name: object.GetName()}, object)
+		if err != nil {
+			if !meta.IsNoMatchError(err) && !errors.IsNotFound(err) {
+				log.Error(err, "Error retrieving resource to prune", "resource", key)
+				allErrors = append(allErrors, err)
+				remaining = append(remaining, key)
+			}
+			continue
+		}
+		if owner, _ := common.GetLabel(object, common.OwnerKey); owner != r.Instance.Namespace {
+			log.Info("skipping pruning of resource owned by another control plane", "resource", key)
+			continue
+		}
+		err = r.pruneResource(ctx, object, policy)
+		if err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "Error pruning resource", "resource", key)
+			allErrors = append(allErrors, err)
+			remaining = append(remaining, key)
+		}
+	}
+	return remaining, utilerrors.NewAggregate(allErrors)
+}
+
+// pruneUnrecordedResources scans the mesh and operator namespaces for resources
+// of well known types belonging to the control plane and deletes those that
+// were applied for a generation other than the specified generation.
+func (r *controlPlaneInstanceReconciler) pruneUnrecordedResources(ctx context.Context, generation string, policy *v1.DeletionPolicy) error {
+	allErrors := []error{}
+	err := r.pruneResources(ctx, namespacedResources, generation, r.Instance.Namespace, policy)
+	if err != nil {
+		allErrors = append(allErrors, err)
+	}
+	err = r.pruneResources(ctx, namespacedResources, generation, r.OperatorNamespace, policy)
+	if err != nil {
+		allErrors = append(allErrors, err)
+	}
+	err = r.pruneResources(ctx, nonNamespacedResources, generation, "", policy)
 	if err != nil {
 		allErrors = append(allErrors, err)
 	}
 	return utilerrors.NewAggregate(allErrors)
 }
 
-func (r *controlPlaneInstanceReconciler) pruneResources(ctx context.Context, gvks []schema.GroupVersionKind, instanceGeneration string, namespace string) error {
+func (r *controlPlaneInstanceReconciler) pruneResources(ctx context.Context, gvks []schema.GroupVersionKind, instanceGeneration string, namespace string, policy *v1.DeletionPolicy) error {
 	log := common.LogFromContext(ctx)
 
 	allErrors := []error{}
@@ -102,17 +179,69 @@ func (r *controlPlaneInstanceReconciler) pruneResources(ctx context.Context, gvk
 			continue
 		}
 		for _, object := range objects.Items {
+			if instanceGeneration != "" && r.inventory != nil && r.inventory.Has(v1.NewResourceKey(&object, &object)) {
+				// the resource is still rendered, but may not have been
+				// updated because its content has not changed, in which case
+				// it still records the generation it was last updated for
+				continue
+			}
 			if generation, ok := common.GetAnnotation(&object, common.MeshGenerationKey); ok && generation != instanceGeneration {
-				log.Info("pruning resource", "resource", v1.NewResourceKey(&object, &object))
-				err = r.Client.Delete(ctx, &object, client.PropagationPolicy(metav1.DeletePropagationBackground))
+				err = r.pruneResource(ctx, &object, policy)
 				if err != nil && !errors.IsNotFound(err) {
 					log.Error(err, "Error pruning resource", "resource", v1.NewResourceKey(&object, &object))
 					allErrors = append(allErrors, err)
-				} else {
-					r.processDeletedObject(ctx, &object)
 				}
 			}
 		}
 	}
 	return utilerrors.NewAggregate(allErrors)
 }
+
+// pruneResource deletes the object, unless the policy retains objects of its
+// kind, in which case the object is released from the control plane.  When
+// planning, the deletion is only recorded in the plan.
+func (r *controlPlaneInstanceReconciler) pruneResource(ctx context.Context, object *unstructured.Unstructured, policy *v1.DeletionPolicy) error {
+	log := common.LogFromContext(ctx)
+	key := v1.NewResourceKey(object, object)
+	if r.plan != nil {
+		if policy.PolicyFor(object.GetKind()) != v1.DeletionPolicyRetain {
+			r.plan.AddChange(key, v1.PlannedActionDelete, nil)
+		}
+		return nil
+	}
+	if policy.PolicyFor(object.GetKind()) == v1.DeletionPolicyRetain {
+		log.Info("retaining resource", "resource", key)
+		err := r.releaseResource(ctx, object)
+		if err == nil {
+			r.PatchFactory.ForgetApplied(object)
+		}
+		return err
+	}
+	log.Info("pruning resource", "resource", key)
+	err := r.Client.Delete(ctx, object, client.PropagationPolicy(metav1.DeletePropagationBackground))
+	if err == nil {
+		common.RecordResourceOperation(object.GetKind(), common.ResourceOperationDelete)
+	}
+	if err == nil || errors.IsNotFound(err) {
+		r.PatchFactory.ForgetApplied(object)
+		r.processDeletedObject(ctx, object)
+	}
+	return err
+}
+
+// releaseResource removes the labels and owner references that tie the object
+// to the control plane, so it is neither deleted by the garbage collector nor
+// by the operator, and can be adopted by a control plane created later.
+func (r *controlPlaneInstanceReconciler) releaseResource(ctx context.Context, object *unstructured.Unstructured) error {
+	labels := object.GetLabels()
+	delete(labels, common.OwnerKey)
+	object.SetLabels(labels)
+	ownerRefs := []metav1.OwnerReference{}
+	for _, ownerRef := range object.GetOwnerReferences() {
+		if ownerRef.UID != r.Instance.UID {
+			ownerRefs = append(ownerRefs, ownerRef)
+		}
+	}
+	object.SetOwnerReferences(ownerRefs)
+	return r.Client.Update(ctx, object)
+}
@@ -0,0 +1,151 @@
+package controlplane
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	maistrav1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+	"github.com/maistra/istio-operator/pkg/controller/common"
+	"github.com/maistra/istio-operator/pkg/controller/common/test"
+	"github.com/maistra/istio-operator/pkg/controller/common/test/assert"
+)
+
+func TestPruneInventory(t *testing.T) {
+	kept := newOwnedConfigMap("kept", "istio-system")
+	removed := newOwnedConfigMap("removed", "istio-system")
+	foreign := newOwnedConfigMap("foreign", "other-system")
+	cl, tracker := test.CreateClient(kept, removed, foreign)
+	tracker.AddReactor("delete", "configmaps", test.ClientFailsOn("delete", "configmaps"))
+
+	r := newTestReconciler()
+	r.Client = cl
+	r.Instance.ObjectMeta = metav1.ObjectMeta{Name: "my-smcp", Namespace: "istio-system"}
+	r.Status.Inventory = []maistrav1.ResourceKey{
+		configMapKey("kept"), configMapKey("removed"), configMapKey("foreign"), configMapKey("missing"),
+	}
+	r.inventory = common.NewInventory()
+	r.inventory.Add(configMapKey("kept"))
+
+	remaining, err := r.pruneInventory(ctx, r.inventory.Removed(r.Status.Inventory), nil)
+	if err == nil {
+		t.Fatalf("expected error deleting resources")
+	}
+	assert.DeepEquals(remaining, []maistrav1.ResourceKey{configMapKey("removed")}, "Expected resources that could not be deleted to remain", t)
+}
+
+func TestPruneRecordsInventory(t *testing.T) {
+	kept := newOwnedConfigMap("kept", "istio-system")
+	removed := newOwnedConfigMap("removed", "istio-system")
+	foreign := newOwnedConfigMap("foreign", "other-system")
+	cl, _ := test.CreateClient(kept, removed, foreign)
+
+	r := newTestReconciler()
+	r.Client = cl
+	r.Instance.ObjectMeta = metav1.ObjectMeta{Name: "my-smcp", Namespace: "istio-system"}
+	r.Status.Inventory = []maistrav1.ResourceKey{configMapKey("kept"), configMapKey("removed"), configMapKey("foreign")}
+	r.inventory = common.NewInventory()
+	r.inventory.Add(configMapKey("kept"))
+	r.inventory.Add(configMapKey("added"))
+
+	if err := r.prune(ctx, maistrav1.CurrentReconciledVersion(2)); err != nil {
+		t.Fatalf("unexpected error pruning resources: %v", err)
+	}
+	assert.DeepEquals(r.Status.Inventory, []maistrav1.ResourceKey{configMapKey("kept"), configMapKey("added")}, "Unexpected inventory", t)
+	assertConfigMapExists(t, cl, "kept", true)
+	assertConfigMapExists(t, cl, "removed", false)
+	assertConfigMapExists(t, cl, "foreign", true)
+}
+
+func TestPruneUnrecordedResourcesKeepsRenderedResources(t *testing.T) {
+	rendered := newOwnedConfigMap("rendered", "istio-system").(*corev1.ConfigMap)
+	rendered.Annotations = map[string]string{common.MeshGenerationKey: "1.1.0-1"}
+	removed := newOwnedConfigMap("removed", "istio-system").(*corev1.ConfigMap)
+	removed.Annotations = map[string]string{common.MeshGenerationKey: "1.1.0-1"}
+	cl, _ := test.CreateClient(rendered, removed)
+
+	r := newTestReconciler()
+	r.Client = cl
+	r.Instance.ObjectMeta = metav1.ObjectMeta{Name: "my-smcp", Namespace: "istio-system"}
+	r.inventory = common.NewInventory()
+	// the content of the rendered resource did not change, so it was not
+	// updated for the current generation
+	r.inventory.Add(configMapKey("rendered"))
+
+	configMaps := []schema.GroupVersionKind{{Version: "v1", Kind: "ConfigMap"}}
+	if err := r.pruneResources(ctx, configMaps, "1.1.0-2", "istio-system", nil); err != nil {
+		t.Fatalf("unexpected error pruning resources: %v", err)
+	}
+	assertConfigMapExists(t, cl, "rendered", true)
+	assertConfigMapExists(t, cl, "removed", false)
+}
+
+func TestPruneInventoryRetainsResources(t *testing.T) {
+	retained := newOwnedConfigMap("retained", "istio-system").(*corev1.ConfigMap)
+	retained.OwnerReferences = []metav1.OwnerReference{
+		{APIVersion: "maistra.io/v1", Kind: "ServiceMeshControlPlane", Name: "my-smcp", UID: "smcp-uid"},
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "other", UID: "other-uid"},
+	}
+	deleted := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "deleted",
+			Namespace:       "istio-system",
+			ResourceVersion: "1",
+			Labels:          map[string]string{common.OwnerKey: "istio-system"},
+		},
+	}
+	cl, _ := test.CreateClient(retained, deleted)
+
+	r := newTestReconciler()
+	r.Client = cl
+	r.Instance.ObjectMeta = metav1.ObjectMeta{Name: "my-smcp", Namespace: "istio-system", UID: "smcp-uid"}
+	policy := &maistrav1.DeletionPolicy{
+		Kinds: map[string]maistrav1.DeletionPolicyType{"ConfigMap": maistrav1.DeletionPolicyRetain},
+	}
+	keys := []maistrav1.ResourceKey{configMapKey("retained"), maistrav1.ResourceKey("istio-system/deleted=v1,Kind=Secret")}
+
+	remaining, err := r.pruneInventory(ctx, keys, policy)
+	if err != nil {
+		t.Fatalf("unexpected error pruning resources: %v", err)
+	}
+	assert.DeepEquals(remaining, []maistrav1.ResourceKey{}, "Expected all resources to be pruned", t)
+
+	err = cl.Get(ctx, client.ObjectKey{Namespace: "istio-system", Name: "deleted"}, &corev1.Secret{})
+	assert.Equals(err == nil, false, "Expected Secret to be deleted", t)
+
+	configMap := &corev1.ConfigMap{}
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: "istio-system", Name: "retained"}, configMap); err != nil {
+		t.Fatalf("expected ConfigMap to be retained: %v", err)
+	}
+	assert.Equals(len(configMap.Labels), 0, "Expected owner labels to be removed", t)
+	assert.DeepEquals(configMap.OwnerReferences, []metav1.OwnerReference{
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "other", UID: "other-uid"},
+	}, "Expected owner reference to the control plane to be removed", t)
+}
+
+func newOwnedConfigMap(name, owner string) runtime.Object {
+	return &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       "istio-system",
+			ResourceVersion: "1",
+			Labels:          map[string]string{common.OwnerKey: owner},
+		},
+	}
+}
+
+func configMapKey(name string) maistrav1.ResourceKey {
+	return maistrav1.ResourceKey("istio-system/" + name + "=v1,Kind=ConfigMap")
+}
+
+func assertConfigMapExists(t *testing.T, cl client.Client, name string, expected bool) {
+	t.Helper()
+	err := cl.Get(ctx, client.ObjectKey{Namespace: "istio-system", Name: name}, &corev1.ConfigMap{})
+	assert.Equals(err == nil, expected, "Unexpected existence of ConfigMap "+name, t)
+}
@@ -7,6 +7,7 @@ import (
 	"path"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/ghodss/yaml"
 	"github.com/pkg/errors"
@@ -20,10 +21,13 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/maistra/istio-operator/pkg/apis/maistra"
+	"github.com/maistra/istio-operator/pkg/apis/maistra/conversion"
 	v1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+	v2 "github.com/maistra/istio-operator/pkg/apis/maistra/v2"
 	"github.com/maistra/istio-operator/pkg/bootstrap"
 	"github.com/maistra/istio-operator/pkg/controller/common"
 	"github.com/maistra/istio-operator/pkg/controller/hacks"
+	"github.com/maistra/istio-operator/pkg/controller/servicemesh/webhooks/validation"
 )
 
 type controlPlaneInstanceReconciler struct {
@@ -33,8 +37,23 @@ type controlPlaneInstanceReconciler struct {
 	ownerRefs      []metav1.OwnerReference
 	meshGeneration string
 	renderings     map[string][]manifest.Manifest
+	overlays       *common.Overlays
+	inventory      *common.Inventory
 	lastComponent  string
 	cniConfig      common.CNIConfig
+
+	// pausedSince is the time at which the reconciliation was paused waiting
+	// for lastComponent to become ready
+	pausedSince time.Time
+
+	// progress of the reconciliation of the renderings and the ConfigMap
+	// storing it
+	progress          *reconcileProgress
+	progressConfigMap *corev1.ConfigMap
+
+	// plan is non-nil when the reconciler only plans the changes it would
+	// make, see Plan()
+	plan *common.ChangePlan
 }
 
 // ensure controlPlaneInstanceReconciler implements ControlPlaneInstanceReconciler
@@ -68,8 +87,15 @@ const (
 	eventReasonPruning                 = "Pruning"
 	eventReasonFailedRemovingFinalizer = "FailedRemovingFinalizer"
 	eventReasonFailedDeletingResources = "FailedDeletingResources"
+	eventReasonDeletionBlocked         = "DeletionBlocked"
 	eventReasonNotReady                = "NotReady"
 	eventReasonReady                   = "Ready"
+	eventReasonPlanning                = "Planning"
+	eventReasonPlanned                 = "Planned"
+	eventReasonRolledBack              = "RolledBack"
+	eventReasonFailedRecordingRevision = "FailedRecordingRevision"
+	eventReasonUnmatchedOverlays       = "UnmatchedOverlays"
+	eventReasonUpgradeBlocked          = "UpgradeBlocked"
 )
 
 func NewControlPlaneInstanceReconciler(controllerResources common.ControllerResources, newInstance *v1.ServiceMeshControlPlane, cniConfig common.CNIConfig) ControlPlaneInstanceReconciler {
@@ -84,12 +110,18 @@ func NewControlPlaneInstanceReconciler(controllerResources common.ControllerReso
 func (r *controlPlaneInstanceReconciler) Reconcile(ctx context.Context) (result reconcile.Result, err error) {
 	log := common.LogFromContext(ctx)
 	log.Info("Reconciling ServiceMeshControlPlane", "Status", r.Instance.Status.StatusType)
+	defer func(start time.Time) {
+		common.ObserveControlPlaneReconcileDuration(r.Instance.Namespace, r.Instance.Name, time.Since(start))
+	}(time.Now())
 	if r.Status.GetCondition(v1.ConditionTypeReconciled).Status != v1.ConditionStatusFalse {
 		r.initializeReconcileStatus()
 		err := r.PostStatus(ctx)
 		return reconcile.Result{}, err // ensure that the new reconcile status is posted immediately. Reconciliation will resume when the status update comes back into the operator
 	}
 
+	// roll back the update if it has been failing for too long
+	r.checkRollback(ctx)
+
 	var ready bool
 	// make sure status gets updated on exit
 	reconciledCondition := r.Status.GetCondition(v1.ConditionTypeReconciled)
@@ -97,6 +129,12 @@ func (r *controlPlaneInstanceReconciler) Reconcile(ctx context.Context) (result
 	reconciliationReason := reconciledCondition.Reason
 	reconciliationComplete := false
 	defer func() {
+		if err != nil {
+			r.Status.ReconcileErrors++
+		} else if !reconciliationComplete {
+			// make sure we check the progress deadline, even if nothing else changes
+			result.RequeueAfter = r.rollbackDeadline(time.Now())
+		}
 		// this ensures we're updating status (if necessary) and recording events on exit
 		if statusErr := r.postReconciliationStatus(ctx, reconciliationReason, reconciliationMessage, err); statusErr != nil {
 			if err == nil {
@@ -130,44 +168,15 @@ func (r *controlPlaneInstanceReconciler) Reconcile(ctx context.Context) (result
 			return
 		}
 
-		// install istio
-
-		// set the auto-injection flag
-		// update injection label on namespace
-		// XXX: this should probably only be done when installing a control plane
-		// e.g. spec.pilot.enabled || spec.mixer.enabled || spec.galley.enabled || spec.sidecarInjectorWebhook.enabled || ....
-		// which is all we're supporting atm.  if the scope expands to allow
-		// installing custom gateways, etc., we should revisit this.
-		namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: r.Instance.Namespace}}
-		err = r.Client.Get(ctx, client.ObjectKey{Name: r.Instance.Namespace}, namespace)
-		if err == nil {
-			updateLabels := false
-			if namespace.Labels == nil {
-				namespace.Labels = map[string]string{}
-			}
-			// make sure injection is disabled for the control plane
-			if label, ok := namespace.Labels["maistra.io/ignore-namespace"]; !ok || label != "ignore" {
-				log.Info("Adding maistra.io/ignore-namespace=ignore label to Request.Namespace")
-				namespace.Labels["maistra.io/ignore-namespace"] = "ignore"
-				updateLabels = true
-			}
-			// make sure the member-of label is specified, so networking works correctly
-			if label, ok := namespace.Labels[common.MemberOfKey]; !ok || label != namespace.GetName() {
-				log.Info(fmt.Sprintf("Adding %s label to Request.Namespace", common.MemberOfKey))
-				namespace.Labels[common.MemberOfKey] = namespace.GetName()
-				updateLabels = true
-			}
-			if updateLabels {
-				err = r.Client.Update(ctx, namespace)
-			}
-		}
-		if err != nil {
-			// bail if there was an error updating the namespace
-			reconciliationReason = v1.ConditionReasonReconcileError
-			reconciliationMessage = "Error updating labels on mesh namespace"
-			err = errors.Wrap(err, reconciliationMessage)
-			return
-		}
+		// initialize common data
+		owner := metav1.NewControllerRef(r.Instance, v1.SchemeGroupVersion.WithKind("ServiceMeshControlPlane"))
+		r.ownerRefs = []metav1.OwnerReference{*owner}
+		r.meshGeneration = v1.CurrentReconciledVersion(r.Instance.GetGeneration())
+
+		// resume the reconciliation if it was interrupted, e.g. by a restart
+		// of the operator
+		resumed := r.loadProgress(ctx)
+		completedCharts := sets.NewString(r.progress.CompletedComponents...)
 
 		// initialize new Status
 		componentStatuses := make([]*v1.ComponentStatus, 0, len(r.Status.ComponentStatus))
@@ -178,51 +187,85 @@ func (r *controlPlaneInstanceReconciler) Reconcile(ctx context.Context) (result
 				componentStatus = v1.NewComponentStatus()
 				componentStatus.Resource = componentName
 			}
-			componentStatus.SetCondition(v1.Condition{
-				Type:   v1.ConditionTypeReconciled,
-				Status: v1.ConditionStatusFalse,
-			})
+			if resumed && completedCharts.Has(chartName) {
+				// the resources of the component have already been applied
+				updateReconcileStatus(&componentStatus.StatusType, nil)
+			} else {
+				componentStatus.SetCondition(v1.Condition{
+					Type:   v1.ConditionTypeReconciled,
+					Status: v1.ConditionStatusFalse,
+				})
+			}
 			componentStatuses = append(componentStatuses, componentStatus)
 		}
 		r.Status.ComponentStatus = componentStatuses
 
-		// initialize common data
-		owner := metav1.NewControllerRef(r.Instance, v1.SchemeGroupVersion.WithKind("ServiceMeshControlPlane"))
-		r.ownerRefs = []metav1.OwnerReference{*owner}
-		r.meshGeneration = v1.CurrentReconciledVersion(r.Instance.GetGeneration())
-
-		// Ensure CRDs are installed
-		chartsDir := common.Options.GetChartsDir(r.Instance.Spec.Version)
-		if err = bootstrap.InstallCRDs(common.NewContextWithLog(ctx, log.WithValues("version", r.Instance.Spec.Version)), r.Client, chartsDir); err != nil {
-			reconciliationReason = v1.ConditionReasonReconcileError
-			reconciliationMessage = "Failed to install/update Istio CRDs"
-			log.Error(err, reconciliationMessage)
-			return
+		if resumed {
+			log.Info("Resuming reconciliation", "phase", r.progress.Phase, "completedComponents", r.progress.CompletedComponents)
+			if err = r.restoreCompletedComponents(ctx); err != nil {
+				reconciliationReason = v1.ConditionReasonReconcileError
+				reconciliationMessage = "Error restoring completed components"
+				err = errors.Wrap(err, reconciliationMessage)
+				return
+			}
+			// make sure the component we were waiting on has become ready
+			r.lastComponent = r.progress.PausedComponent
 		}
 
-		// Ensure Istio CNI is installed
-		if r.cniConfig.Enabled {
-			r.lastComponent = "cni"
-			if err = bootstrap.InstallCNI(ctx, r.Client, r.cniConfig); err != nil {
+		if r.progress.Phase == reconcilePhasePrerequisites {
+			// install istio
+
+			// set the auto-injection flag
+			// update injection label on namespace
+			// XXX: this should probably only be done when installing a control plane
+			// e.g. spec.pilot.enabled || spec.mixer.enabled || spec.galley.enabled || spec.sidecarInjectorWebhook.enabled || ....
+			// which is all we're supporting atm.  if the scope expands to allow
+			// installing custom gateways, etc., we should revisit this.
+			if err = r.updateMeshNamespaceLabels(ctx); err != nil {
+				// bail if there was an error updating the namespace
 				reconciliationReason = v1.ConditionReasonReconcileError
-				reconciliationMessage = "Failed to install/update Istio CNI"
-				log.Error(err, reconciliationMessage)
+				reconciliationMessage = "Error updating labels on mesh namespace"
+				err = errors.Wrap(err, reconciliationMessage)
 				return
-			} else if ready, _ := r.isCNIReady(ctx); !ready {
-				reconciliationReason = v1.ConditionReasonPausingInstall
-				reconciliationMessage = fmt.Sprintf("Paused until %s becomes ready", "cni")
+			}
+
+			// Ensure the CRDs of the version being applied are installed, which
+			// differs from spec.version when a configuration is restored
+			chartsDir := common.Options.GetChartsDir(r.Status.ResolvedVersion)
+			if err = bootstrap.InstallCRDs(common.NewContextWithLog(ctx, log.WithValues("version", r.Status.ResolvedVersion)), r.Client, chartsDir); err != nil {
+				reconciliationReason = v1.ConditionReasonReconcileError
+				reconciliationMessage = "Failed to install/update Istio CRDs"
+				log.Error(err, reconciliationMessage)
 				return
 			}
-		}
 
-		if err = r.reconcileRBAC(ctx); err != nil {
-			reconciliationReason = v1.ConditionReasonReconcileError
-			reconciliationMessage = "Failed to install/update Maistra RBAC resources"
-			log.Error(err, reconciliationMessage)
-			return
+			// Ensure Istio CNI is installed
+			if r.cniConfig.Enabled {
+				r.lastComponent = "cni"
+				if err = bootstrap.InstallCNI(ctx, r.Client, r.cniConfig); err != nil {
+					reconciliationReason = v1.ConditionReasonReconcileError
+					reconciliationMessage = "Failed to install/update Istio CNI"
+					log.Error(err, reconciliationMessage)
+					return
+				} else if ready, _ := r.isCNIReady(ctx); !ready {
+					reconciliationReason = v1.ConditionReasonPausingInstall
+					reconciliationMessage = fmt.Sprintf("Paused until %s becomes ready", "cni")
+					r.startPause()
+					return
+				}
+				r.lastComponent = ""
+			}
+
+			if err = r.reconcileRBAC(ctx); err != nil {
+				reconciliationReason = v1.ConditionReasonReconcileError
+				reconciliationMessage = "Failed to install/update Maistra RBAC resources"
+				log.Error(err, reconciliationMessage)
+				return
+			}
 		}
+	}
 
-	} else if r.lastComponent != "" {
+	if r.lastComponent != "" {
 		if readinessMap, readinessErr := r.calculateComponentReadiness(ctx); readinessErr == nil {
 			// if we've already begun reconciling, make sure we weren't waiting for
 			// the last component to become ready
@@ -231,6 +274,7 @@ func (r *controlPlaneInstanceReconciler) Reconcile(ctx context.Context) (result
 				log.Info(fmt.Sprintf("Paused until %s becomes ready", r.lastComponent))
 				return
 			}
+			r.endPause(r.lastComponent)
 		} else {
 			// error calculating readiness
 			reconciliationReason = v1.ConditionReasonProbeError
@@ -242,6 +286,7 @@ func (r *controlPlaneInstanceReconciler) Reconcile(ctx context.Context) (result
 	}
 
 	// create components
+	r.setPhase(ctx, reconcilePhaseComponents)
 	for _, chartName := range orderedCharts {
 		if ready, err = r.processComponentManifests(ctx, chartName); !ready {
 			reconciliationReason, reconciliationMessage, err = r.pauseReconciliation(ctx, chartName, err)
@@ -268,10 +313,18 @@ func (r *controlPlaneInstanceReconciler) Reconcile(ctx context.Context) (result
 		}
 	}
 
+	r.Status.OverlayErrors = r.overlays.Unmatched()
+	for _, overlayErr := range r.Status.OverlayErrors {
+		message := fmt.Sprintf("Overlay %d targeting %s %s did not match any resource", overlayErr.Index, overlayErr.Kind, overlayErr.Name)
+		log.Info(message)
+		r.EventRecorder.Event(r.Instance, corev1.EventTypeWarning, eventReasonUnmatchedOverlays, message)
+	}
+
 	// we still need to prune if this is the first generation, e.g. if the operator was updated during the install,
 	// it's possible that some resources in the original version may not be present in the new version.
 	// delete unseen components
 	reconciliationMessage = "Pruning obsolete resources"
+	r.setPhase(ctx, reconcilePhasePruning)
 	r.EventRecorder.Event(r.Instance, corev1.EventTypeNormal, eventReasonPruning, reconciliationMessage)
 	log.Info(reconciliationMessage)
 	err = r.prune(ctx, r.meshGeneration)
@@ -282,7 +335,11 @@ func (r *controlPlaneInstanceReconciler) Reconcile(ctx context.Context) (result
 		return
 	}
 
-	if r.isUpdating() {
+	if r.isRollingBack() {
+		reconciliationReason = v1.ConditionReasonUpdateSuccessful
+		reconciliationMessage = fmt.Sprintf("Successfully rolled back to last known good configuration as version %s", r.meshGeneration)
+		r.EventRecorder.Event(r.Instance, corev1.EventTypeNormal, eventReasonRolledBack, reconciliationMessage)
+	} else if r.isUpdating() {
 		reconciliationReason = v1.ConditionReasonUpdateSuccessful
 		reconciliationMessage = fmt.Sprintf("Successfully updated from version %s to version %s", r.Status.GetReconciledVersion(), r.meshGeneration)
 		r.EventRecorder.Event(r.Instance, corev1.EventTypeNormal, eventReasonUpdated, reconciliationMessage)
@@ -293,15 +350,57 @@ func (r *controlPlaneInstanceReconciler) Reconcile(ctx context.Context) (result
 	}
 	r.Status.ObservedGeneration = r.Instance.GetGeneration()
 	r.Status.ReconciledVersion = r.meshGeneration
+	r.Status.ReconcileErrors = 0
+	if r.Instance.Spec.RollbackPolicy == nil {
+		r.Status.LastKnownGoodConfiguration = nil
+	} else if !r.isRollingBack() {
+		r.Status.LastKnownGoodConfiguration = r.Status.LastAppliedConfiguration.DeepCopy()
+	}
+	if historyErr := r.recordConfigurationRevision(ctx); historyErr != nil {
+		// the configuration has been applied, so we don't fail the reconciliation
+		log.Error(historyErr, "Error recording configuration revision")
+		r.EventRecorder.Event(r.Instance, corev1.EventTypeWarning, eventReasonFailedRecordingRevision, fmt.Sprintf("Error recording configuration revision: %s", historyErr))
+	}
 	updateReconcileStatus(&r.Status.StatusType, nil)
 
 	_, err = r.updateReadinessStatus(ctx) // this only updates the local object instance; it doesn't post the status update; postReconciliationStatus (called using defer) actually does that
 
+	r.clearProgress(ctx)
 	reconciliationComplete = true
 	log.Info("Completed ServiceMeshControlPlane reconcilation")
 	return
 }
 
+// updateMeshNamespaceLabels disables injection for the mesh namespace and
+// marks it a member of the mesh
+func (r *controlPlaneInstanceReconciler) updateMeshNamespaceLabels(ctx context.Context) error {
+	log := common.LogFromContext(ctx)
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: r.Instance.Namespace}}
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: r.Instance.Namespace}, namespace); err != nil {
+		return err
+	}
+	updateLabels := false
+	if namespace.Labels == nil {
+		namespace.Labels = map[string]string{}
+	}
+	// make sure injection is disabled for the control plane
+	if label, ok := namespace.Labels["maistra.io/ignore-namespace"]; !ok || label != "ignore" {
+		log.Info("Adding maistra.io/ignore-namespace=ignore label to Request.Namespace")
+		namespace.Labels["maistra.io/ignore-namespace"] = "ignore"
+		updateLabels = true
+	}
+	// make sure the member-of label is specified, so networking works correctly
+	if label, ok := namespace.Labels[common.MemberOfKey]; !ok || label != namespace.GetName() {
+		log.Info(fmt.Sprintf("Adding %s label to Request.Namespace", common.MemberOfKey))
+		namespace.Labels[common.MemberOfKey] = namespace.GetName()
+		updateLabels = true
+	}
+	if updateLabels {
+		return r.Client.Update(ctx, namespace)
+	}
+	return nil
+}
+
 func (r *controlPlaneInstanceReconciler) pauseReconciliation(ctx context.Context, chartName string, err error) (v1.ConditionReason, string, error) {
 	log := common.LogFromContext(ctx)
 	var eventReason string
@@ -319,6 +418,7 @@ func (r *controlPlaneInstanceReconciler) pauseReconciliation(ctx context.Context
 		reconciliationMessage = fmt.Sprintf("Paused until %s becomes ready", componentName)
 		r.EventRecorder.Event(r.Instance, corev1.EventTypeNormal, eventReason, reconciliationMessage)
 		log.Info(reconciliationMessage)
+		r.startPause()
 	} else {
 		conditionReason = v1.ConditionReasonReconcileError
 		reconciliationMessage = fmt.Sprintf("Error processing component %s", componentName)
@@ -327,40 +427,43 @@ func (r *controlPlaneInstanceReconciler) pauseReconciliation(ctx context.Context
 	return conditionReason, reconciliationMessage, errors.Wrapf(err, reconciliationMessage)
 }
 
-func (r *controlPlaneInstanceReconciler) isUpdating() bool {
-	return r.Instance.Status.ObservedGeneration != 0
+// startPause records the time at which the reconciliation was paused waiting
+// for a component to become ready, unless it was already paused
+func (r *controlPlaneInstanceReconciler) startPause() {
+	if r.pausedSince.IsZero() {
+		r.pausedSince = time.Now()
+	}
 }
 
-// mergeValues merges a map containing input values on top of a map containing
-// base values, giving preference to the base values for conflicts
-func mergeValues(base map[string]interface{}, input map[string]interface{}) map[string]interface{} {
-	if base == nil {
-		base = make(map[string]interface{}, 1)
+// endPause reports the time the reconciliation was paused waiting for the
+// component to become ready
+func (r *controlPlaneInstanceReconciler) endPause(component string) {
+	if r.pausedSince.IsZero() {
+		return
 	}
+	common.ObserveReconcilePauseDuration(r.Instance.Namespace, r.Instance.Name, component, time.Since(r.pausedSince))
+	r.pausedSince = time.Time{}
+}
 
-	for key, value := range input {
-		//if the key doesn't already exist, add it
-		if _, exists := base[key]; !exists {
-			base[key] = value
-			continue
-		}
-
-		// at this point, key exists in both input and base.
-		// If both are maps, recurse.
-		// If only input is a map, ignore it. We don't want to overrwrite base.
-		// If both are values, again, ignore it since we don't want to overrwrite base.
-		if baseKeyAsMap, baseOK := base[key].(map[string]interface{}); baseOK {
-			if inputAsMap, inputOK := value.(map[string]interface{}); inputOK {
-				base[key] = mergeValues(baseKeyAsMap, inputAsMap)
-			}
-		}
-	}
-	return base
+func (r *controlPlaneInstanceReconciler) isUpdating() bool {
+	return r.Instance.Status.ObservedGeneration != 0
 }
 
-func (r *controlPlaneInstanceReconciler) getSMCPTemplate(name string, maistraVersion string) (v1.ControlPlaneSpec, error) {
+// getSMCPTemplate returns the spec of the named template.  A
+// ServiceMeshControlPlaneTemplate in the control plane's namespace takes
+// precedence over the templates installed with the operator.
+func (r *controlPlaneInstanceReconciler) getSMCPTemplate(ctx context.Context, name string, maistraVersion string) (v1.ControlPlaneSpec, v1.AppliedTemplate, error) {
+	applied := v1.AppliedTemplate{Name: name}
 	if strings.Contains(name, "/") {
-		return v1.ControlPlaneSpec{}, fmt.Errorf("template name contains invalid character '/'")
+		return v1.ControlPlaneSpec{}, applied, fmt.Errorf("template name contains invalid character '/'")
+	}
+
+	templateResource := &v1.ServiceMeshControlPlaneTemplate{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: name, Namespace: r.Instance.Namespace}, templateResource); err == nil {
+		applied.ResourceVersion = templateResource.ResourceVersion
+		return templateResource.Spec, applied, nil
+	} else if !apierrors.IsNotFound(err) {
+		return v1.ControlPlaneSpec{}, applied, errors.Wrapf(err, "error retrieving ServiceMeshControlPlaneTemplate %s", name)
 	}
 
 	templateContent, err := ioutil.ReadFile(path.Join(common.Options.GetUserTemplatesDir(), name))
@@ -370,59 +473,127 @@ func (r *controlPlaneInstanceReconciler) getSMCPTemplate(name string, maistraVer
 		//configmaps mounted in directories with pre-existing content
 		defaultTemplateContent, defaultErr := ioutil.ReadFile(path.Join(common.Options.GetDefaultTemplatesDir(maistraVersion), name))
 		if defaultErr != nil {
-			return v1.ControlPlaneSpec{}, fmt.Errorf("template cannot be loaded from user or default directory. Error from user: %s. Error from default: %s", err, defaultErr)
+			return v1.ControlPlaneSpec{}, applied, fmt.Errorf("template cannot be loaded from user or default directory. Error from user: %s. Error from default: %s", err, defaultErr)
 		}
 		templateContent = defaultTemplateContent
 	}
 
 	var template v1.ServiceMeshControlPlane
 	if err = yaml.Unmarshal(templateContent, &template); err != nil {
-		return v1.ControlPlaneSpec{}, fmt.Errorf("failed to parse template %s contents: %s", name, err)
+		return v1.ControlPlaneSpec{}, applied, fmt.Errorf("failed to parse template %s contents: %s", name, err)
 	}
-	return template.Spec, nil
+	return template.Spec, applied, nil
 }
 
-//renderSMCPTemplates traverses and processes all of the references templates
-func (r *controlPlaneInstanceReconciler) recursivelyApplyTemplates(ctx context.Context, smcp v1.ControlPlaneSpec, version string, visited sets.String) (v1.ControlPlaneSpec, error) {
+// recursivelyApplyTemplates traverses and processes all of the referenced
+// templates.  Templates are applied in order, so values in later templates take
+// precedence over values in earlier templates, and values in smcp take
+// precedence over values in all of its templates.  The returned sources record
+// which template supplied each value, with source identifying smcp itself.
+func (r *controlPlaneInstanceReconciler) recursivelyApplyTemplates(ctx context.Context, smcp v1.ControlPlaneSpec, source string, version string, visited sets.String) (v1.ControlPlaneSpec, valueSources, error) {
 	log := common.LogFromContext(ctx)
-	if smcp.Template == "" {
-		return smcp, nil
+	sources := newValueSources(source)
+
+	if err := validateTemplateNames(smcp); err != nil {
+		if source != "" {
+			err = fmt.Errorf("invalid template %s: %v", source, err)
+		}
+		return smcp, sources, err
 	}
-	log.Info(fmt.Sprintf("processing smcp template %s", smcp.Template))
 
-	if visited.Has(smcp.Template) {
-		return smcp, fmt.Errorf("SMCP templates form cyclic dependency. Cannot proceed")
+	inherited := v1.ControlPlaneSpec{}
+	inheritedSources := valueSources{}
+	for _, name := range templateNames(smcp) {
+		log.Info(fmt.Sprintf("processing smcp template %s", name))
+
+		// visited holds the templates currently being processed, so the same
+		// template may be inherited through more than one parent
+		if visited.Has(name) {
+			return smcp, sources, fmt.Errorf("SMCP templates form cyclic dependency. Cannot proceed")
+		}
+
+		template, applied, err := r.getSMCPTemplate(ctx, name, version)
+		if err != nil {
+			return smcp, sources, err
+		}
+		r.recordAppliedTemplate(applied)
+
+		visited.Insert(name)
+		template, templateSources, err := r.recursivelyApplyTemplates(ctx, template, name, version, visited)
+		visited.Delete(name)
+		if err != nil {
+			log.Info(fmt.Sprintf("error rendering SMCP templates: %s\n", err))
+			return smcp, sources, err
+		}
+
+		inherited, inheritedSources = mergeTemplate(template, templateSources, inherited, inheritedSources)
 	}
 
-	template, err := r.getSMCPTemplate(smcp.Template, version)
-	if err != nil {
-		return smcp, err
+	smcp, sources = mergeTemplate(smcp, sources, inherited, inheritedSources)
+	return smcp, sources, nil
+}
+
+// mergeTemplate merges the values of the template into the spec
+func mergeTemplate(spec v1.ControlPlaneSpec, specSources valueSources, template v1.ControlPlaneSpec, templateSources valueSources) (v1.ControlPlaneSpec, valueSources) {
+	merger := &valueMerger{baseSources: specSources, inputSources: templateSources, sources: valueSources{}}
+	spec.Istio = merger.merge("istio", spec.Istio, template.Istio)
+	spec.ThreeScale = merger.merge("threeScale", spec.ThreeScale, template.ThreeScale)
+	if len(template.Overlays) > 0 {
+		// the template's overlays are applied first
+		spec.Overlays = append(append([]v1.ResourceOverlay{}, template.Overlays...), spec.Overlays...)
 	}
+	return spec, merger.sources
+}
 
-	template, err = r.recursivelyApplyTemplates(ctx, template, version, visited)
-	if err != nil {
-		log.Info(fmt.Sprintf("error rendering SMCP templates: %s\n", err))
-		return smcp, err
+// templateNames returns the names of the templates referenced by the spec, in
+// the order in which they are applied
+func templateNames(spec v1.ControlPlaneSpec) []string {
+	if len(spec.Templates) > 0 {
+		return spec.Templates
+	} else if spec.Template != "" {
+		return []string{spec.Template}
 	}
+	return nil
+}
 
-	visited.Insert(smcp.Template)
+// validateTemplateNames returns an error if the spec references templates
+// through both spec.template and spec.templates
+func validateTemplateNames(spec v1.ControlPlaneSpec) error {
+	if spec.Template != "" && len(spec.Templates) > 0 {
+		return fmt.Errorf("only one of spec.template and spec.templates may be set; list all templates in spec.templates")
+	}
+	return nil
+}
 
-	smcp.Istio = mergeValues(smcp.Istio, template.Istio)
-	smcp.ThreeScale = mergeValues(smcp.ThreeScale, template.ThreeScale)
-	return smcp, nil
+func (r *controlPlaneInstanceReconciler) recordAppliedTemplate(applied v1.AppliedTemplate) {
+	for _, existing := range r.Status.AppliedTemplates {
+		if existing.Name == applied.Name {
+			return
+		}
+	}
+	r.Status.AppliedTemplates = append(r.Status.AppliedTemplates, applied)
 }
 
 func (r *controlPlaneInstanceReconciler) applyTemplates(ctx context.Context, smcpSpec v1.ControlPlaneSpec) (v1.ControlPlaneSpec, error) {
 	log := common.LogFromContext(ctx)
 	log.Info("updating servicemeshcontrolplane with templates")
-	if smcpSpec.Template == "" {
+	if smcpSpec.Template == "" && len(smcpSpec.Templates) == 0 {
 		smcpSpec.Template = v1.DefaultTemplate
 		log.Info("No template provided. Using default")
 	}
 
-	spec, err := r.recursivelyApplyTemplates(ctx, smcpSpec, smcpSpec.Version, sets.NewString())
+	r.Status.AppliedTemplates = nil
+	spec, sources, err := r.recursivelyApplyTemplates(ctx, smcpSpec, "", smcpSpec.Version, sets.NewString())
 	log.Info("finished updating ServiceMeshControlPlane", "Spec", spec)
 
+	// values specified in the ServiceMeshControlPlane itself are not listed
+	r.Status.ValueSources = map[string]string{}
+	for path, source := range sources {
+		if source != "" {
+			r.Status.ValueSources[path] = source
+		}
+	}
+
 	return spec, err
 }
 
@@ -439,25 +610,57 @@ func (r *controlPlaneInstanceReconciler) validateSMCPSpec(spec v1.ControlPlaneSp
 
 func (r *controlPlaneInstanceReconciler) renderCharts(ctx context.Context) error {
 	log := common.LogFromContext(ctx)
-	//Generate the spec
-	r.Status.LastAppliedConfiguration = r.Instance.Spec
-	if len(r.Status.LastAppliedConfiguration.Version) == 0 {
-		// this must be from a 1.0 operator
-		r.Status.LastAppliedConfiguration.Version = maistra.LegacyVersion.String()
-	}
+	// whether 3scale is enabled is taken from the resource's spec, unless a
+	// configuration is being restored
+	threeScale := r.Instance.Spec.ThreeScale
+	if r.isRollingBack() && r.Status.LastKnownGoodConfiguration != nil {
+		// templates have already been applied to the last known good configuration
+		log.Info("rendering last known good configuration")
+		r.Status.LastAppliedConfiguration = *r.Status.LastKnownGoodConfiguration.DeepCopy()
+		r.Status.AppliedTemplates = nil
+		r.Status.ValueSources = nil
+		threeScale = r.Status.LastAppliedConfiguration.ThreeScale
+	} else if r.Instance.Spec.RollbackTo != nil {
+		// templates have already been applied to the configuration stored in the revision
+		log.Info("rendering configuration revision", "revision", r.Instance.Spec.RollbackTo.Revision)
+		spec, err := r.getConfigurationRevision(ctx, r.Instance.Spec.RollbackTo.Revision)
+		if err != nil {
+			return err
+		}
+		r.Status.LastAppliedConfiguration = spec
+		r.Status.AppliedTemplates = nil
+		r.Status.ValueSources = nil
+		threeScale = spec.ThreeScale
+	} else {
+		//Generate the spec
+		r.Status.LastAppliedConfiguration = r.Instance.Spec
+		if len(r.Status.LastAppliedConfiguration.Version) == 0 {
+			// this must be from a 1.0 operator
+			r.Status.LastAppliedConfiguration.Version = maistra.LegacyVersion.String()
+		}
 
-	spec, err := r.applyTemplates(ctx, r.Status.LastAppliedConfiguration)
-	if err != nil {
-		log.Error(err, "warning: failed to apply ServiceMeshControlPlane templates")
+		spec, err := r.applyTemplates(ctx, r.Status.LastAppliedConfiguration)
+		if err != nil {
+			log.Error(err, "warning: failed to apply ServiceMeshControlPlane templates")
 
-		return err
+			return err
+		}
+		r.Status.LastAppliedConfiguration = spec
 	}
-	r.Status.LastAppliedConfiguration = spec
 
 	if err := r.validateSMCPSpec(r.Status.LastAppliedConfiguration); err != nil {
 		return err
 	}
 
+	resolvedVersion, err := r.resolveVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if resolvedVersion != r.Status.ResolvedVersion {
+		log.Info("resolved version", "version", r.Status.LastAppliedConfiguration.Version, "resolvedVersion", resolvedVersion)
+	}
+	r.Status.ResolvedVersion = resolvedVersion
+
 	if globalValues, ok := r.Status.LastAppliedConfiguration.Istio["global"].(map[string]interface{}); ok {
 		globalValues["operatorNamespace"] = r.OperatorNamespace
 	}
@@ -469,9 +672,14 @@ func (r *controlPlaneInstanceReconciler) renderCharts(ctx context.Context) error
 		r.Status.LastAppliedConfiguration.Istio["istio_cni"] = CNIValues
 	}
 	CNIValues["enabled"] = r.cniConfig.Enabled
-	CNIValues["istio_cni_network"], ok = common.GetCNINetworkName(r.Status.LastAppliedConfiguration.Version)
+	CNIValues["istio_cni_network"], ok = common.GetCNINetworkName(resolvedVersion)
 	if !ok {
-		return fmt.Errorf("unknown maistra version: %s", r.Status.LastAppliedConfiguration.Version)
+		return fmt.Errorf("unknown maistra version: %s", resolvedVersion)
+	}
+
+	istioValues, err := chartValues(r.Status.LastAppliedConfiguration, resolvedVersion)
+	if err != nil {
+		return err
 	}
 
 	//Render the charts
@@ -479,13 +687,13 @@ func (r *controlPlaneInstanceReconciler) renderCharts(ctx context.Context) error
 	var threeScaleRenderings map[string][]manifest.Manifest
 	log.Info("rendering helm charts")
 	log.V(2).Info("rendering Istio charts")
-	istioRenderings, _, err := common.RenderHelmChart(path.Join(common.Options.GetChartsDir(r.Status.LastAppliedConfiguration.Version), "istio"), r.Instance.GetNamespace(), r.Status.LastAppliedConfiguration.Istio)
+	istioRenderings, err := common.RenderHelmChart(path.Join(common.Options.GetChartsDir(resolvedVersion), "istio"), r.Instance.GetNamespace(), istioValues)
 	if err != nil {
 		allErrors = append(allErrors, err)
 	}
-	if isEnabled(r.Instance.Spec.ThreeScale) {
+	if isEnabled(threeScale) {
 		log.V(2).Info("rendering 3scale charts")
-		threeScaleRenderings, _, err = common.RenderHelmChart(path.Join(common.Options.GetChartsDir(r.Status.LastAppliedConfiguration.Version), "maistra-threescale"), r.Instance.GetNamespace(), r.Status.LastAppliedConfiguration.ThreeScale)
+		threeScaleRenderings, err = common.RenderHelmChart(path.Join(common.Options.GetChartsDir(resolvedVersion), "maistra-threescale"), r.Instance.GetNamespace(), r.Status.LastAppliedConfiguration.ThreeScale)
 		if err != nil {
 			allErrors = append(allErrors, err)
 		}
@@ -505,6 +713,90 @@ func (r *controlPlaneInstanceReconciler) renderCharts(ctx context.Context) error
 	for key, value := range threeScaleRenderings {
 		r.renderings[key] = value
 	}
+	r.overlays = common.NewOverlays(r.Status.LastAppliedConfiguration.Overlays)
+	r.inventory = common.NewInventory()
+	return nil
+}
+
+// chartValues returns the values used to render the Istio charts of the
+// resolved release.  The control plane is reconciled as v2: the applied
+// configuration is converted to the typed fields of the v2 API, which are then
+// translated to the values used by the charts of the release.  Both steps use
+// the release, rather than spec.version, which may be a range, so values the
+// charts of the release do not support are passed through unchanged.
+func chartValues(spec v1.ControlPlaneSpec, resolvedVersion string) (v1.HelmValuesType, error) {
+	version, err := maistra.ParseVersion(resolvedVersion)
+	if err != nil {
+		return nil, err
+	}
+	specv2 := &v2.ControlPlaneSpec{}
+	if err := conversion.ConvertSpecV1ToV2(&spec, specv2, version); err != nil {
+		return nil, errors.Wrap(err, "Error converting ServiceMeshControlPlane to v2")
+	}
+	translated := &v1.ControlPlaneSpec{}
+	if err := conversion.ConvertSpecV2ToV1(specv2, translated, version); err != nil {
+		return nil, errors.Wrapf(err, "Error translating ServiceMeshControlPlane to the values of version %s", resolvedVersion)
+	}
+	if translated.Istio == nil {
+		return v1.HelmValuesType{}, nil
+	}
+	return translated.Istio, nil
+}
+
+// resolveVersion returns the release the applied configuration is rendered
+// with.  The release resolved by the previous reconciliation is pinned in the
+// status and only moves to another version once the upgrade or downgrade
+// checks pass.  A range keeps using the pinned release while the checks for
+// a newer release in the range fail.
+func (r *controlPlaneInstanceReconciler) resolveVersion(ctx context.Context) (string, error) {
+	version := r.Status.LastAppliedConfiguration.Version
+	resolvedVersion, err := common.ResolveVersion(version)
+	if err != nil {
+		return "", err
+	}
+	pinnedVersion := r.Status.ResolvedVersion
+	if len(pinnedVersion) == 0 || resolvedVersion == pinnedVersion {
+		return resolvedVersion, nil
+	}
+	err = r.checkVersionChange(ctx, &r.Status.LastAppliedConfiguration)
+	if err == nil {
+		return resolvedVersion, nil
+	}
+	if common.IsVersionRange(version) && common.RangeContains(version, pinnedVersion) {
+		message := fmt.Sprintf("Not upgrading to release %s of version %s: %s", resolvedVersion, version, err)
+		common.LogFromContext(ctx).Info(message)
+		r.EventRecorder.Event(r.Instance, corev1.EventTypeWarning, eventReasonUpgradeBlocked, message)
+		return pinnedVersion, nil
+	}
+	return "", err
+}
+
+// checkVersionChange runs the upgrade or downgrade checks for changing the
+// release of the deployed control plane to the version of the configuration.
+// The validating webhook only checks changes to spec.version, so these checks
+// cover the changes it cannot see: restored configurations and ranges that
+// resolve to a release shipped with a newer operator.
+func (r *controlPlaneInstanceReconciler) checkVersionChange(ctx context.Context, spec *v1.ControlPlaneSpec) error {
+	if len(r.Status.ResolvedVersion) == 0 {
+		// nothing has been deployed yet
+		return nil
+	}
+	// the checks use the version of the control plane as the current version
+	// and check the values of the control plane against the target version
+	smcp := r.Instance.DeepCopy()
+	smcp.Spec = *spec.DeepCopy()
+	smcp.Spec.Version = r.Status.ResolvedVersion
+	issues, err := validation.CheckVersionChange(ctx, r.Client, smcp, spec.Version)
+	if err != nil {
+		return err
+	}
+	if len(issues) > 0 {
+		messages := make([]string, 0, len(issues))
+		for _, issue := range issues {
+			messages = append(messages, issue.Message)
+		}
+		return fmt.Errorf("cannot change version from %s to %s: %s", r.Status.ResolvedVersion, spec.Version, strings.Join(messages, ", "))
+	}
 	return nil
 }
 
@@ -513,8 +805,12 @@ func (r *controlPlaneInstanceReconciler) PostStatus(ctx context.Context) error {
 	instance := &v1.ServiceMeshControlPlane{}
 	log.Info("Posting status update", "conditions", r.Status.Conditions)
 	if err := r.Client.Get(ctx, client.ObjectKey{Name: r.Instance.Name, Namespace: r.Instance.Namespace}, instance); err == nil {
+		// the upgrade policy controller owns the available upgrade
+		r.retainUpgradeStatus(&instance.Status)
 		instance.Status = *r.Status.DeepCopy()
-		if err = r.Client.Status().Update(ctx, instance); err != nil && !(apierrors.IsGone(err) || apierrors.IsNotFound(err)) {
+		if err = r.Client.Status().Update(ctx, instance); err == nil {
+			common.RecordControlPlaneMetrics(instance)
+		} else if !(apierrors.IsGone(err) || apierrors.IsNotFound(err)) {
 			return errors.Wrap(err, "error updating ServiceMeshControlPlane status")
 		}
 	} else if !(apierrors.IsGone(err) || apierrors.IsNotFound(err)) {
@@ -524,6 +820,20 @@ func (r *controlPlaneInstanceReconciler) PostStatus(ctx context.Context) error {
 	return nil
 }
 
+// retainUpgradeStatus copies the available version and the UpgradeAvailable
+// condition, which are set by the upgrade policy controller, from the current
+// status of the control plane, so posting the status does not clear them.
+func (r *controlPlaneInstanceReconciler) retainUpgradeStatus(current *v1.ControlPlaneStatus) {
+	r.Status.AvailableVersion = current.AvailableVersion
+	r.Status.RemoveCondition(v1.ConditionTypeUpgradeAvailable)
+	for _, condition := range current.Conditions {
+		if condition.Type == v1.ConditionTypeUpgradeAvailable {
+			r.Status.Conditions = append(r.Status.Conditions, condition)
+			break
+		}
+	}
+}
+
 func (r *controlPlaneInstanceReconciler) postReconciliationStatus(ctx context.Context, reconciliationReason v1.ConditionReason, reconciliationMessage string, processingErr error) error {
 	var reason string
 	if r.isUpdating() {
@@ -567,7 +877,12 @@ func (r *controlPlaneInstanceReconciler) initializeReconcileStatus() {
 		if r.Status.ObservedGeneration == r.Instance.GetGeneration() {
 			fromVersion := r.Status.GetReconciledVersion()
 			toVersion := v1.CurrentReconciledVersion(r.Instance.GetGeneration())
-			readyMessage = fmt.Sprintf("Upgrading mesh from version %s to version %s", fromVersion[strings.LastIndex(fromVersion, "-")+1:], toVersion[strings.LastIndex(toVersion, "-")+1:])
+			if fromVersion == toVersion {
+				// a template referenced by the spec has changed
+				readyMessage = fmt.Sprintf("Updating mesh generation %d with modified templates", r.Instance.GetGeneration())
+			} else {
+				readyMessage = fmt.Sprintf("Upgrading mesh from version %s to version %s", fromVersion[strings.LastIndex(fromVersion, "-")+1:], toVersion[strings.LastIndex(toVersion, "-")+1:])
+			}
 		} else {
 			readyMessage = fmt.Sprintf("Updating mesh from generation %d to generation %d", r.Status.ObservedGeneration, r.Instance.GetGeneration())
 		}
@@ -585,6 +900,9 @@ func (r *controlPlaneInstanceReconciler) initializeReconcileStatus() {
 			Message: readyMessage,
 		})
 	}
+	// any plan is stale once we start applying changes
+	r.Status.Plan = nil
+	r.resetRollback()
 	r.EventRecorder.Event(r.Instance, corev1.EventTypeNormal, eventReason, readyMessage)
 	r.Status.SetCondition(v1.Condition{
 		Type:    v1.ConditionTypeReconciled,
@@ -605,6 +923,7 @@ func (r *controlPlaneInstanceReconciler) SetInstance(newInstance *v1.ServiceMesh
 		// we need to regenerate the renderings
 		r.renderings = nil
 		r.lastComponent = ""
+		r.pausedSince = time.Time{}
 		// reset reconcile status
 		r.Status.SetCondition(v1.Condition{Type: v1.ConditionTypeReconciled, Status: v1.ConditionStatusUnknown})
 	}
@@ -4,16 +4,22 @@ import (
 	"reflect"
 	"testing"
 
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/maistra/istio-operator/pkg/apis/maistra"
 	maistrav1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
 	"github.com/maistra/istio-operator/pkg/controller/common"
+	"github.com/maistra/istio-operator/pkg/controller/common/test"
+	"github.com/maistra/istio-operator/pkg/controller/common/test/assert"
 )
 
 func TestGetSMCPTemplateWithSlashReturnsError(t *testing.T) {
 	instanceReconciler := newTestReconciler()
-	_, err := instanceReconciler.getSMCPTemplate("/", maistra.DefaultVersion.String())
+	_, _, err := instanceReconciler.getSMCPTemplate(ctx, "/", maistra.DefaultVersion.String())
 	if err == nil {
 		t.Fatalf("Allowed to access path outside of deployment directory")
 	}
@@ -73,6 +79,72 @@ func TestMerge(t *testing.T) {
 				"a": 3,
 			},
 		},
+		{
+			name: "delete directive removes key",
+			base: map[string]interface{}{
+				"a": map[string]interface{}{
+					"$patch": "delete",
+				},
+				"b": map[string]interface{}{
+					"c": map[string]interface{}{
+						"$patch": "delete",
+					},
+				},
+			},
+			input: map[string]interface{}{
+				"a": map[string]interface{}{
+					"b": 1,
+				},
+				"d": 2,
+			},
+			expectedResult: map[string]interface{}{
+				"b": map[string]interface{}{},
+				"d": 2,
+			},
+		},
+		{
+			name: "replace directive replaces map",
+			base: map[string]interface{}{
+				"a": map[string]interface{}{
+					"$patch": "replace",
+					"b":      1,
+				},
+			},
+			input: map[string]interface{}{
+				"a": map[string]interface{}{
+					"c": 2,
+				},
+			},
+			expectedResult: map[string]interface{}{
+				"a": map[string]interface{}{
+					"b": 1,
+				},
+			},
+		},
+		{
+			name: "lists are replaced by default",
+			base: map[string]interface{}{
+				"a": []interface{}{1},
+			},
+			input: map[string]interface{}{
+				"a": []interface{}{2},
+			},
+			expectedResult: map[string]interface{}{
+				"a": []interface{}{1},
+			},
+		},
+		{
+			name: "append directive appends to list",
+			base: map[string]interface{}{
+				"a": []interface{}{map[string]interface{}{"$patch": "append"}, 1},
+			},
+			input: map[string]interface{}{
+				"a": []interface{}{2},
+			},
+			expectedResult: map[string]interface{}{
+				"a": []interface{}{2, 1},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -87,12 +159,61 @@ func TestMerge(t *testing.T) {
 
 func TestCyclicTemplate(t *testing.T) {
 	instanceReconciler := newTestReconciler()
-	_, err := instanceReconciler.recursivelyApplyTemplates(ctx, maistrav1.ControlPlaneSpec{Template: "visited"}, "", sets.NewString("visited"))
+	_, _, err := instanceReconciler.recursivelyApplyTemplates(ctx, maistrav1.ControlPlaneSpec{Template: "visited"}, "", "", sets.NewString("visited"))
 	if err == nil {
 		t.Fatalf("Expected error to not be nil. Cyclic dependencies should not be allowed.")
 	}
 }
 
+func TestPostStatusRetainsAvailableUpgrade(t *testing.T) {
+	smcp := &maistrav1.ServiceMeshControlPlane{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-smcp", Namespace: "istio-system"},
+		Spec:       maistrav1.ControlPlaneSpec{Version: "v1.1"},
+	}
+	cl, _ := test.CreateClient(smcp)
+	r := newTestReconciler()
+	r.Client = cl
+	r.Instance = smcp.DeepCopy()
+	key := client.ObjectKey{Name: "my-smcp", Namespace: "istio-system"}
+
+	// setUpgradeStatus updates the status like the upgrade policy controller
+	setUpgradeStatus := func(version string, status maistrav1.ConditionStatus, reason maistrav1.ConditionReason) {
+		current := &maistrav1.ServiceMeshControlPlane{}
+		if err := cl.Get(ctx, key, current); err != nil {
+			t.Fatalf("unexpected error retrieving control plane: %v", err)
+		}
+		current.Status.AvailableVersion = version
+		current.Status.SetCondition(maistrav1.Condition{Type: maistrav1.ConditionTypeUpgradeAvailable, Status: status, Reason: reason})
+		if err := cl.Status().Update(ctx, current); err != nil {
+			t.Fatalf("unexpected error updating control plane status: %v", err)
+		}
+	}
+	postStatus := func(reason maistrav1.ConditionReason) *maistrav1.ServiceMeshControlPlane {
+		r.Status.SetCondition(maistrav1.Condition{Type: maistrav1.ConditionTypeReconciled, Status: maistrav1.ConditionStatusTrue, Reason: reason})
+		if err := r.PostStatus(ctx); err != nil {
+			t.Fatalf("unexpected error posting status: %v", err)
+		}
+		updated := &maistrav1.ServiceMeshControlPlane{}
+		if err := cl.Get(ctx, key, updated); err != nil {
+			t.Fatalf("unexpected error retrieving control plane: %v", err)
+		}
+		assert.Equals(updated.Status.GetCondition(maistrav1.ConditionTypeReconciled).Reason, reason, "Unexpected Reconciled condition", t)
+		return updated
+	}
+
+	setUpgradeStatus("v1.2", maistrav1.ConditionStatusTrue, maistrav1.ConditionReasonUpgradePendingApproval)
+	updated := postStatus(maistrav1.ConditionReasonInstallSuccessful)
+	assert.Equals(updated.Status.AvailableVersion, "v1.2", "Expected the available version to be retained", t)
+	assert.Equals(updated.Status.GetCondition(maistrav1.ConditionTypeUpgradeAvailable).Reason, maistrav1.ConditionReasonUpgradePendingApproval,
+		"Expected the UpgradeAvailable condition to be retained", t)
+
+	setUpgradeStatus("", maistrav1.ConditionStatusFalse, maistrav1.ConditionReasonUpToDate)
+	updated = postStatus(maistrav1.ConditionReasonUpdateSuccessful)
+	assert.Equals(updated.Status.AvailableVersion, "", "Expected the available version to be cleared", t)
+	assert.Equals(updated.Status.GetCondition(maistrav1.ConditionTypeUpgradeAvailable).Reason, maistrav1.ConditionReasonUpToDate,
+		"Expected the updated UpgradeAvailable condition to be retained", t)
+}
+
 func newTestReconciler() *controlPlaneInstanceReconciler {
 	reconciler := NewControlPlaneInstanceReconciler(
 		common.ControllerResources{},
@@ -100,3 +221,130 @@ func newTestReconciler() *controlPlaneInstanceReconciler {
 		common.CNIConfig{Enabled: true})
 	return reconciler.(*controlPlaneInstanceReconciler)
 }
+
+func TestResolveVersionPinsReleaseUntilUpgradeChecksPass(t *testing.T) {
+	InitializeGlobals("istio-operator")()
+	if err := common.LoadVersionDescriptors(); err != nil {
+		t.Fatalf("unexpected error loading version descriptors: %v", err)
+	}
+	testCases := []struct {
+		name            string
+		version         string
+		servicePortName string
+		expectedVersion string
+		expectError     bool
+	}{
+		{
+			name:            "range-upgrade-allowed",
+			version:         ">=1.0",
+			servicePortName: "https",
+			expectedVersion: maistra.V1_2.String(),
+		},
+		{
+			name:            "range-upgrade-blocked",
+			version:         ">=1.0",
+			servicePortName: "http",
+			expectedVersion: maistra.V1_0.String(),
+		},
+		{
+			name:            "version-upgrade-blocked",
+			version:         maistra.V1_2.String(),
+			servicePortName: "http",
+			expectError:     true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			service := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "my-service", Namespace: "istio-system"},
+				Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: tc.servicePortName, Port: 443}}},
+			}
+			cl, _ := test.CreateClient(service)
+			r := newTestReconciler()
+			r.Client = cl
+			r.EventRecorder = record.NewFakeRecorder(10)
+			r.Instance.ObjectMeta = metav1.ObjectMeta{Name: "my-smcp", Namespace: "istio-system"}
+			r.Instance.Spec.Version = tc.version
+			r.Status.LastAppliedConfiguration = maistrav1.ControlPlaneSpec{Version: tc.version}
+			r.Status.ResolvedVersion = maistra.V1_0.String()
+
+			resolvedVersion, err := r.resolveVersion(ctx)
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("Expected upgrade to be blocked")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error resolving version: %v", err)
+			}
+			assert.Equals(resolvedVersion, tc.expectedVersion, "Unexpected resolved version", t)
+		})
+	}
+}
+
+func TestChartValuesAreTranslatedForResolvedVersion(t *testing.T) {
+	InitializeGlobals("istio-operator")()
+	if err := common.LoadVersionDescriptors(); err != nil {
+		t.Fatalf("unexpected error loading version descriptors: %v", err)
+	}
+	testCases := []struct {
+		name            string
+		resolvedVersion string
+	}{
+		{
+			name:            "typed-fields-supported",
+			resolvedVersion: maistra.V1_1.String(),
+		},
+		{
+			// global.mtls.auto has no typed field in v1.0, so it is passed
+			// through, even though the range also matches newer versions
+			name:            "typed-field-not-supported",
+			resolvedVersion: maistra.V1_0.String(),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := maistrav1.ControlPlaneSpec{
+				Version: ">=1.0",
+				Istio: maistrav1.HelmValuesType{
+					"global": map[string]interface{}{
+						"mtls": map[string]interface{}{
+							"enabled": true,
+							"auto":    true,
+						},
+					},
+					"pilot": map[string]interface{}{
+						"autoscaleMin":  float64(2),
+						"traceSampling": float64(0.5),
+					},
+					"kiali": map[string]interface{}{
+						"dashboard": map[string]interface{}{"user": "admin"},
+					},
+				},
+			}
+			expected := maistrav1.HelmValuesType{
+				"global": map[string]interface{}{
+					"mtls": map[string]interface{}{
+						"enabled": true,
+						"auto":    true,
+					},
+				},
+				"pilot": map[string]interface{}{
+					"autoscaleMin":  int64(2),
+					"traceSampling": float64(0.5),
+				},
+				"kiali": map[string]interface{}{
+					"dashboard": map[string]interface{}{"user": "admin"},
+				},
+			}
+
+			values, err := chartValues(spec, tc.resolvedVersion)
+			if err != nil {
+				t.Fatalf("unexpected error translating values: %v", err)
+			}
+			assert.DeepEquals(values, expected, "Unexpected chart values", t)
+			assert.DeepEquals(spec.Istio["pilot"], map[string]interface{}{"autoscaleMin": float64(2), "traceSampling": float64(0.5)}, "Expected applied configuration not to be modified", t)
+		})
+	}
+}
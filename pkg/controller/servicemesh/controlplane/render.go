@@ -0,0 +1,197 @@
+package controlplane
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/helm/pkg/manifest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	v1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+	"github.com/maistra/istio-operator/pkg/controller/common"
+)
+
+// RenderedComponent holds the objects rendered for a component of the control
+// plane
+type RenderedComponent struct {
+	Name    string
+	Objects []*unstructured.Unstructured
+}
+
+// RenderOffline renders the resources the operator would create for the
+// ServiceMeshControlPlane without accessing the cluster.  Templates are read
+// from the template directories and the charts are rendered and labeled just
+// like they are when the control plane is reconciled.  The components are
+// returned in the order in which the operator applies them.  CRDs, CNI and
+// mesh RBAC resources are not included.
+func RenderOffline(ctx context.Context, instance *v1.ServiceMeshControlPlane, operatorNamespace string, scheme *runtime.Scheme, cniConfig common.CNIConfig) ([]RenderedComponent, error) {
+	return renderOffline(ctx, newOfflineClient(scheme), instance, operatorNamespace, cniConfig)
+}
+
+// DiffVersions renders the ServiceMeshControlPlane for both maistra versions,
+// like RenderOffline does, and returns the differences between the
+// renderings.  The resources rendered for fromVersion are treated as existing
+// resources when rendering toVersion, so generated secrets are kept, just like
+// they are when the version of the control plane is updated.
+func DiffVersions(ctx context.Context, instance *v1.ServiceMeshControlPlane, fromVersion, toVersion string, operatorNamespace string, scheme *runtime.Scheme, cniConfig common.CNIConfig) ([]common.ResourceDiff, error) {
+	cl := newOfflineClient(scheme)
+	renderVersion := func(version string) ([]*unstructured.Unstructured, error) {
+		versioned := instance.DeepCopy()
+		versioned.Spec.Version = version
+		components, err := renderOffline(ctx, cl, versioned, operatorNamespace, cniConfig)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error rendering version %s", version)
+		}
+		objects := []*unstructured.Unstructured{}
+		for _, component := range components {
+			objects = append(objects, component.Objects...)
+		}
+		return objects, nil
+	}
+
+	oldObjects, err := renderVersion(fromVersion)
+	if err != nil {
+		return nil, err
+	}
+	newObjects, err := renderVersion(toVersion)
+	if err != nil {
+		return nil, err
+	}
+	return common.DiffResources(oldObjects, newObjects), nil
+}
+
+func renderOffline(ctx context.Context, cl *offlineClient, instance *v1.ServiceMeshControlPlane, operatorNamespace string, cniConfig common.CNIConfig) ([]RenderedComponent, error) {
+	r := NewControlPlaneInstanceReconciler(common.ControllerResources{
+		Client:            cl,
+		Scheme:            cl.scheme,
+		EventRecorder:     &record.FakeRecorder{},
+		OperatorNamespace: operatorNamespace,
+	}, instance, cniConfig).(*controlPlaneInstanceReconciler)
+
+	if err := r.renderCharts(ctx); err != nil {
+		return nil, errors.Wrap(err, "Error rendering helm charts")
+	}
+
+	owner := metav1.NewControllerRef(r.Instance, v1.SchemeGroupVersion.WithKind("ServiceMeshControlPlane"))
+	r.ownerRefs = []metav1.OwnerReference{*owner}
+	r.meshGeneration = v1.CurrentReconciledVersion(r.Instance.GetGeneration())
+
+	components := []RenderedComponent{}
+	for _, chartName := range renderedChartOrder(r.renderings) {
+		component := RenderedComponent{Name: componentFromChartName(chartName)}
+		mp := common.NewManifestProcessor(r.ControllerResources, r.Instance.GetNamespace(), r.meshGeneration, r.Instance.GetNamespace(), r.preprocessObject, r.processNewObject)
+		mp.EnableRenderOnly(func(obj *unstructured.Unstructured) error {
+			// later components may depend on the objects rendered for earlier
+			// components, e.g. the htpasswd secret
+			cl.add(obj)
+			component.Objects = append(component.Objects, obj)
+			return nil
+		})
+		mp.SetOverlays(r.overlays)
+		if err := mp.ProcessManifests(ctx, r.renderings[chartName], component.Name); err != nil {
+			return nil, errors.Wrapf(err, "Error rendering component %s", component.Name)
+		}
+		if len(component.Objects) > 0 {
+			components = append(components, component)
+		}
+	}
+	return components, nil
+}
+
+// renderedChartOrder returns the names of the rendered charts in the order in
+// which they are applied by Reconcile().  Charts without a predefined order
+// are sorted by name.
+func renderedChartOrder(renderings map[string][]manifest.Manifest) []string {
+	ordered := []string{}
+	for _, chartName := range orderedCharts {
+		if _, ok := renderings[chartName]; ok {
+			ordered = append(ordered, chartName)
+		}
+	}
+	istioCharts := []string{}
+	otherCharts := []string{}
+	for chartName := range renderings {
+		if common.IndexOf(orderedCharts, chartName) >= 0 {
+			continue
+		} else if strings.HasPrefix(chartName, "istio/") {
+			istioCharts = append(istioCharts, chartName)
+		} else {
+			otherCharts = append(otherCharts, chartName)
+		}
+	}
+	sort.Strings(istioCharts)
+	sort.Strings(otherCharts)
+	return append(append(ordered, istioCharts...), otherCharts...)
+}
+
+// offlineClient is a client.Client that does not access the cluster.  Get()
+// returns the objects that have been added to the client and reports any
+// other object as not found.  Lists are always empty and writes fail.
+type offlineClient struct {
+	scheme  *runtime.Scheme
+	objects map[v1.ResourceKey]*unstructured.Unstructured
+}
+
+var _ client.Client = (*offlineClient)(nil)
+
+func newOfflineClient(scheme *runtime.Scheme) *offlineClient {
+	return &offlineClient{
+		scheme:  scheme,
+		objects: map[v1.ResourceKey]*unstructured.Unstructured{},
+	}
+}
+
+func (c *offlineClient) add(obj *unstructured.Unstructured) {
+	c.objects[v1.NewResourceKey(obj, obj)] = obj.DeepCopy()
+}
+
+func (c *offlineClient) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	gvk, err := apiutil.GVKForObject(obj, c.scheme)
+	if err != nil {
+		return err
+	}
+	receiver := &unstructured.Unstructured{}
+	receiver.SetGroupVersionKind(gvk)
+	receiver.SetNamespace(key.Namespace)
+	receiver.SetName(key.Name)
+	stored, ok := c.objects[v1.NewResourceKey(receiver, receiver)]
+	if !ok {
+		return apierrors.NewNotFound(gvk.GroupVersion().WithResource(strings.ToLower(gvk.Kind)).GroupResource(), key.Name)
+	}
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		u.SetUnstructuredContent(stored.DeepCopy().UnstructuredContent())
+		return nil
+	}
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(stored.DeepCopy().UnstructuredContent(), obj)
+}
+
+func (c *offlineClient) List(ctx context.Context, opts *client.ListOptions, list runtime.Object) error {
+	return nil
+}
+
+func (c *offlineClient) Create(ctx context.Context, obj runtime.Object) error {
+	return errOffline
+}
+
+func (c *offlineClient) Delete(ctx context.Context, obj runtime.Object, opts ...client.DeleteOptionFunc) error {
+	return errOffline
+}
+
+func (c *offlineClient) Update(ctx context.Context, obj runtime.Object) error {
+	return errOffline
+}
+
+func (c *offlineClient) Status() client.StatusWriter {
+	return c
+}
+
+var errOffline = fmt.Errorf("cannot modify resources when rendering offline")
@@ -0,0 +1,58 @@
+package controlplane
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/helm/pkg/manifest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/maistra/istio-operator/pkg/controller/common/test/assert"
+)
+
+func TestRenderedChartOrder(t *testing.T) {
+	renderings := map[string][]manifest.Manifest{
+		"maistra-threescale":    nil,
+		"istio/charts/kiali":    nil,
+		"istio/charts/zipkin":   nil,
+		"istio/charts/galley":   nil,
+		"istio/charts/aaa":      nil,
+		"istio":                 nil,
+		"istio/charts/security": nil,
+	}
+	assert.DeepEquals(renderedChartOrder(renderings), []string{
+		"istio",
+		"istio/charts/security",
+		"istio/charts/galley",
+		"istio/charts/kiali",
+		"istio/charts/aaa",
+		"istio/charts/zipkin",
+		"maistra-threescale",
+	}, "Unexpected chart order", t)
+}
+
+func TestOfflineClient(t *testing.T) {
+	cl := newOfflineClient(scheme.Scheme)
+
+	secret := &corev1.Secret{}
+	err := cl.Get(ctx, client.ObjectKey{Namespace: "istio-system", Name: "htpasswd"}, secret)
+	assert.True(apierrors.IsNotFound(err), "Expected NotFound error for object that has not been rendered", t)
+
+	rendered := &unstructured.Unstructured{}
+	rendered.SetAPIVersion("v1")
+	rendered.SetKind("Secret")
+	rendered.SetNamespace("istio-system")
+	rendered.SetName("htpasswd")
+	unstructured.SetNestedField(rendered.Object, "c2VjcmV0", "data", "rawPassword")
+	cl.add(rendered)
+
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: "istio-system", Name: "htpasswd"}, secret); err != nil {
+		t.Fatalf("unexpected error retrieving rendered object: %v", err)
+	}
+	assert.Equals(string(secret.Data["rawPassword"]), "secret", "Unexpected data in rendered Secret", t)
+
+	assert.True(cl.Create(ctx, &corev1.Secret{}) != nil, "Expected offline client to refuse creating objects", t)
+}
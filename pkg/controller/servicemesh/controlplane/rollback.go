@@ -0,0 +1,105 @@
+package controlplane
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	v1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+	"github.com/maistra/istio-operator/pkg/controller/common"
+)
+
+// checkRollback rolls the update back to the last known good configuration if
+// it has exceeded any of the limits specified in the rollback policy.
+// The update is not rolled back if the last known good configuration uses
+// another version and the checks for changing to that version fail.
+func (r *controlPlaneInstanceReconciler) checkRollback(ctx context.Context) {
+	reason, message := r.rollbackReason(time.Now())
+	if len(reason) == 0 {
+		return
+	}
+	if err := r.checkVersionChange(ctx, r.Status.LastKnownGoodConfiguration); err != nil {
+		log := common.LogFromContext(ctx)
+		message = fmt.Sprintf("%s; cannot roll back to last known good configuration: %s", message, err)
+		log.Info(message)
+		r.EventRecorder.Event(r.Instance, corev1.EventTypeWarning, eventReasonRolledBack, message)
+		return
+	}
+	r.rollback(ctx, reason, message)
+}
+
+// rollbackReason returns the reason the update should be rolled back, or an
+// empty reason if it should not be rolled back.
+func (r *controlPlaneInstanceReconciler) rollbackReason(now time.Time) (v1.ConditionReason, string) {
+	policy := r.Instance.Spec.RollbackPolicy
+	if policy == nil || !r.isUpdating() || r.Status.LastKnownGoodConfiguration == nil || r.isRollingBack() {
+		return "", ""
+	}
+	if policy.MaxReconcileErrors > 0 && r.Status.ReconcileErrors >= policy.MaxReconcileErrors {
+		return v1.ConditionReasonTooManyReconcileErrors, fmt.Sprintf("Update failed after %d consecutive reconcile errors", r.Status.ReconcileErrors)
+	}
+	if policy.ProgressDeadlineSeconds > 0 {
+		if elapsed := now.Sub(r.updateStartTime()); elapsed >= time.Duration(policy.ProgressDeadlineSeconds)*time.Second {
+			return v1.ConditionReasonProgressDeadlineExceeded, fmt.Sprintf("Update did not complete within %d seconds", policy.ProgressDeadlineSeconds)
+		}
+	}
+	return "", ""
+}
+
+// rollback discards the renderings for the current configuration, so the
+// remainder of the reconciliation applies the last known good configuration.
+func (r *controlPlaneInstanceReconciler) rollback(ctx context.Context, reason v1.ConditionReason, message string) {
+	log := common.LogFromContext(ctx)
+	message = fmt.Sprintf("%s; rolling back to last known good configuration", message)
+	log.Info(message)
+	r.EventRecorder.Event(r.Instance, corev1.EventTypeWarning, eventReasonRolledBack, message)
+
+	r.renderings = nil
+	r.lastComponent = ""
+	r.Status.ReconcileErrors = 0
+	r.Status.SetCondition(v1.Condition{
+		Type:    v1.ConditionTypeRolledBack,
+		Status:  v1.ConditionStatusTrue,
+		Reason:  reason,
+		Message: message,
+	})
+}
+
+// isRollingBack returns true if the current generation has been rolled back
+func (r *controlPlaneInstanceReconciler) isRollingBack() bool {
+	return r.Status.GetCondition(v1.ConditionTypeRolledBack).Status == v1.ConditionStatusTrue
+}
+
+// updateStartTime returns the time the current update started, i.e. when the
+// Reconciled condition last became false.
+func (r *controlPlaneInstanceReconciler) updateStartTime() time.Time {
+	return r.Status.GetCondition(v1.ConditionTypeReconciled).LastTransitionTime.Time
+}
+
+// rollbackDeadline returns the time remaining before the update is rolled back
+// for exceeding its progress deadline, or zero if there is no deadline.
+func (r *controlPlaneInstanceReconciler) rollbackDeadline(now time.Time) time.Duration {
+	policy := r.Instance.Spec.RollbackPolicy
+	if policy == nil || policy.ProgressDeadlineSeconds <= 0 || !r.isUpdating() || r.Status.LastKnownGoodConfiguration == nil || r.isRollingBack() {
+		return 0
+	}
+	remaining := r.updateStartTime().Add(time.Duration(policy.ProgressDeadlineSeconds) * time.Second).Sub(now)
+	if remaining <= 0 {
+		// make sure we come back right away
+		return time.Second
+	}
+	return remaining
+}
+
+// resetRollback clears the rollback state when a new generation is reconciled
+func (r *controlPlaneInstanceReconciler) resetRollback() {
+	r.Status.ReconcileErrors = 0
+	if condition := r.Status.GetCondition(v1.ConditionTypeRolledBack); condition.Status == v1.ConditionStatusTrue {
+		r.Status.SetCondition(v1.Condition{
+			Type:   v1.ConditionTypeRolledBack,
+			Status: v1.ConditionStatusFalse,
+		})
+	}
+}
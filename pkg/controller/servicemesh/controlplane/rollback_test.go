@@ -0,0 +1,268 @@
+package controlplane
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/maistra/istio-operator/pkg/apis/maistra"
+	maistrav1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+	"github.com/maistra/istio-operator/pkg/controller/common"
+	"github.com/maistra/istio-operator/pkg/controller/common/test"
+	"github.com/maistra/istio-operator/pkg/controller/common/test/assert"
+)
+
+func TestRollbackReason(t *testing.T) {
+	now := time.Now()
+	testCases := []struct {
+		name            string
+		policy          *maistrav1.RollbackPolicy
+		installing      bool
+		noKnownGood     bool
+		rollingBack     bool
+		reconcileErrors int32
+		updateStarted   time.Time
+		expectedReason  maistrav1.ConditionReason
+	}{
+		{
+			name:            "no-policy",
+			reconcileErrors: 10,
+			updateStarted:   now.Add(-time.Hour),
+		},
+		{
+			name:            "too-many-errors",
+			policy:          &maistrav1.RollbackPolicy{MaxReconcileErrors: 3},
+			reconcileErrors: 3,
+			updateStarted:   now,
+			expectedReason:  maistrav1.ConditionReasonTooManyReconcileErrors,
+		},
+		{
+			name:            "too-few-errors",
+			policy:          &maistrav1.RollbackPolicy{MaxReconcileErrors: 3},
+			reconcileErrors: 2,
+			updateStarted:   now,
+		},
+		{
+			name:           "deadline-exceeded",
+			policy:         &maistrav1.RollbackPolicy{ProgressDeadlineSeconds: 600},
+			updateStarted:  now.Add(-11 * time.Minute),
+			expectedReason: maistrav1.ConditionReasonProgressDeadlineExceeded,
+		},
+		{
+			name:          "deadline-not-exceeded",
+			policy:        &maistrav1.RollbackPolicy{ProgressDeadlineSeconds: 600},
+			updateStarted: now.Add(-9 * time.Minute),
+		},
+		{
+			name:          "installing",
+			policy:        &maistrav1.RollbackPolicy{ProgressDeadlineSeconds: 600},
+			installing:    true,
+			updateStarted: now.Add(-time.Hour),
+		},
+		{
+			name:          "no-known-good-configuration",
+			policy:        &maistrav1.RollbackPolicy{ProgressDeadlineSeconds: 600},
+			noKnownGood:   true,
+			updateStarted: now.Add(-time.Hour),
+		},
+		{
+			name:          "already-rolling-back",
+			policy:        &maistrav1.RollbackPolicy{ProgressDeadlineSeconds: 600},
+			rollingBack:   true,
+			updateStarted: now.Add(-time.Hour),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newTestReconciler()
+			r.Instance.Spec.RollbackPolicy = tc.policy
+			if !tc.installing {
+				r.Instance.Status.ObservedGeneration = 1
+			}
+			if !tc.noKnownGood {
+				r.Status.LastKnownGoodConfiguration = &maistrav1.ControlPlaneSpec{}
+			}
+			if tc.rollingBack {
+				r.Status.Conditions = append(r.Status.Conditions, maistrav1.Condition{
+					Type:   maistrav1.ConditionTypeRolledBack,
+					Status: maistrav1.ConditionStatusTrue,
+				})
+			}
+			r.Status.Conditions = append(r.Status.Conditions, maistrav1.Condition{
+				Type:               maistrav1.ConditionTypeReconciled,
+				Status:             maistrav1.ConditionStatusFalse,
+				LastTransitionTime: metav1.NewTime(tc.updateStarted),
+			})
+			r.Status.ReconcileErrors = tc.reconcileErrors
+
+			reason, _ := r.rollbackReason(now)
+			if reason != tc.expectedReason {
+				t.Errorf("expected reason %q, got %q", tc.expectedReason, reason)
+			}
+		})
+	}
+}
+
+func TestRollbackRendersRestoredThreeScaleConfiguration(t *testing.T) {
+	InitializeGlobals("istio-operator")()
+	if err := common.LoadVersionDescriptors(); err != nil {
+		t.Fatalf("unexpected error loading version descriptors: %v", err)
+	}
+	chartsDir := common.Options.ChartsDir
+	defer func() { common.Options.ChartsDir = chartsDir }()
+	common.Options.ChartsDir = newTestChartsDir(t, "v1.2", "istio", "maistra-threescale")
+
+	r := newTestReconciler()
+	r.Instance.ObjectMeta = metav1.ObjectMeta{Name: "my-smcp", Namespace: "istio-system"}
+	r.Instance.Spec.Version = maistra.V1_2.String()
+
+	// the current spec disables 3scale, the last known good configuration enables it
+	r.Status.LastKnownGoodConfiguration = &maistrav1.ControlPlaneSpec{
+		Version:    maistra.V1_2.String(),
+		Istio:      maistrav1.HelmValuesType{"global": map[string]interface{}{}},
+		ThreeScale: maistrav1.HelmValuesType{"enabled": true},
+	}
+	r.Status.SetCondition(maistrav1.Condition{Type: maistrav1.ConditionTypeRolledBack, Status: maistrav1.ConditionStatusTrue})
+
+	if err := r.renderCharts(ctx); err != nil {
+		t.Fatalf("unexpected error rendering charts: %v", err)
+	}
+	if _, ok := r.renderings["maistra-threescale"]; !ok {
+		t.Errorf("Expected 3scale chart of the restored configuration to be rendered")
+	}
+}
+
+// newTestChartsDir creates a charts directory for the version containing the
+// specified charts, each rendering a single ConfigMap
+func newTestChartsDir(t *testing.T, version string, charts ...string) string {
+	t.Helper()
+	root, err := ioutil.TempDir("", "charts")
+	if err != nil {
+		t.Fatalf("unexpected error creating charts directory: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(root) })
+	descriptor, err := ioutil.ReadFile(path.Join(common.Options.ResourceDir, "helm", version, common.VersionDescriptorFile))
+	if err != nil {
+		t.Fatalf("unexpected error reading version descriptor: %v", err)
+	}
+	files := map[string]string{
+		path.Join(version, common.VersionDescriptorFile): string(descriptor),
+	}
+	for _, chart := range charts {
+		files[path.Join(version, chart, "Chart.yaml")] = fmt.Sprintf("name: %s\nversion: 1.0.0\n", chart)
+		files[path.Join(version, chart, "templates", "configmap.yaml")] = fmt.Sprintf("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: %s\n", chart)
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(path.Dir(path.Join(root, name)), 0755); err != nil {
+			t.Fatalf("unexpected error creating chart: %v", err)
+		}
+		if err := ioutil.WriteFile(path.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatalf("unexpected error creating chart: %v", err)
+		}
+	}
+	return root
+}
+
+func TestRollbackChecksVersionChange(t *testing.T) {
+	InitializeGlobals("istio-operator")()
+	if err := common.LoadVersionDescriptors(); err != nil {
+		t.Fatalf("unexpected error loading version descriptors: %v", err)
+	}
+	testCases := []struct {
+		name               string
+		namespaceLabels    map[string]string
+		expectRolledBack   bool
+		expectEventMessage string
+	}{
+		{
+			name:             "downgrade-allowed",
+			namespaceLabels:  map[string]string{common.MemberOfKey: "istio-system"},
+			expectRolledBack: true,
+		},
+		{
+			name: "downgrade-blocked",
+			namespaceLabels: map[string]string{
+				common.MemberOfKey: "istio-system",
+				"ca.istio.io/env":  "test",
+			},
+			expectEventMessage: "ca.istio.io/env label on namespace app is not supported in older version",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cl, _ := test.CreateClient(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app", Labels: tc.namespaceLabels}})
+			recorder := record.NewFakeRecorder(10)
+			r := newTestReconciler()
+			r.Client = cl
+			r.EventRecorder = recorder
+			r.Instance.ObjectMeta = metav1.ObjectMeta{Name: "my-smcp", Namespace: "istio-system"}
+			r.Instance.Spec.Version = maistra.V1_1.String()
+			r.Instance.Spec.RollbackPolicy = &maistrav1.RollbackPolicy{MaxReconcileErrors: 1}
+			r.Instance.Status.ObservedGeneration = 1
+			r.Status.ResolvedVersion = maistra.V1_1.String()
+			r.Status.ReconcileErrors = 1
+			r.Status.LastKnownGoodConfiguration = &maistrav1.ControlPlaneSpec{Version: maistra.V1_0.String()}
+
+			r.checkRollback(ctx)
+
+			assert.Equals(r.isRollingBack(), tc.expectRolledBack, "Unexpected rollback state", t)
+			if tc.expectEventMessage != "" {
+				select {
+				case event := <-recorder.Events:
+					assert.True(strings.Contains(event, tc.expectEventMessage), fmt.Sprintf("Expected event to report blocking issue, got %q", event), t)
+				default:
+					t.Errorf("Expected warning event")
+				}
+			}
+		})
+	}
+}
+
+func TestRollbackToRevisionChecksVersionChange(t *testing.T) {
+	InitializeGlobals("istio-operator")()
+	if err := common.LoadVersionDescriptors(); err != nil {
+		t.Fatalf("unexpected error loading version descriptors: %v", err)
+	}
+	data, err := json.Marshal(maistrav1.ControlPlaneSpec{
+		Version: maistra.V1_0.String(),
+		Istio:   maistrav1.HelmValuesType{"global": map[string]interface{}{}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error marshalling configuration: %v", err)
+	}
+	revision := &appsv1.ControllerRevision{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-smcp-1", Namespace: "istio-system", Labels: map[string]string{common.ControlPlaneKey: "my-smcp"}},
+		Data:       runtime.RawExtension{Raw: data},
+		Revision:   1,
+	}
+	member := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app", Labels: map[string]string{
+		common.MemberOfKey: "istio-system",
+		"ca.istio.io/env":  "test",
+	}}}
+	cl, _ := test.CreateClient(revision, member)
+
+	r := newTestReconciler()
+	r.Client = cl
+	r.Instance.ObjectMeta = metav1.ObjectMeta{Name: "my-smcp", Namespace: "istio-system"}
+	r.Instance.Spec.Version = maistra.V1_1.String()
+	r.Instance.Spec.RollbackTo = &maistrav1.RollbackConfig{Revision: 1}
+	r.Status.ResolvedVersion = maistra.V1_1.String()
+
+	err = r.renderCharts(ctx)
+	if err == nil {
+		t.Fatalf("Expected rollback to revision with a blocked downgrade to fail")
+	}
+	assert.True(strings.Contains(err.Error(), "cannot change version from v1.1 to v1.0"), fmt.Sprintf("Unexpected error: %v", err), t)
+	assert.Equals(r.Status.ResolvedVersion, maistra.V1_1.String(), "Expected resolved version not to change", t)
+}
@@ -0,0 +1,85 @@
+package controlplane
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+	"github.com/maistra/istio-operator/pkg/controller/common"
+)
+
+// templatesChanged returns true if any of the templates applied to the
+// control plane's configuration were modified, or if a template that was
+// loaded from the operator's templates is now overridden by a
+// ServiceMeshControlPlaneTemplate resource.  Deleting a template resource is
+// treated as a change, so the control plane falls back to the operator's
+// templates.
+func templatesChanged(ctx context.Context, cl client.Client, instance *v1.ServiceMeshControlPlane) (bool, error) {
+	for _, applied := range instance.Status.AppliedTemplates {
+		template := &v1.ServiceMeshControlPlaneTemplate{}
+		err := cl.Get(ctx, client.ObjectKey{Name: applied.Name, Namespace: instance.Namespace}, template)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				if len(applied.ResourceVersion) > 0 {
+					return true, nil
+				}
+				continue
+			}
+			return false, err
+		}
+		if template.ResourceVersion != applied.ResourceVersion {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// referencesTemplate returns true if the control plane references the named
+// template, either directly or through the templates it inherits from.
+func referencesTemplate(instance *v1.ServiceMeshControlPlane, name string) bool {
+	if len(instance.Spec.Template) == 0 && len(instance.Spec.Templates) == 0 && name == v1.DefaultTemplate {
+		return true
+	}
+	for _, template := range templateNames(instance.Spec) {
+		if template == name {
+			return true
+		}
+	}
+	for _, applied := range instance.Status.AppliedTemplates {
+		if applied.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// templateMapper returns a handler.Mapper that maps a
+// ServiceMeshControlPlaneTemplate to the control planes in the same namespace
+// that reference it.
+func templateMapper(ctx context.Context, cl client.Client) handler.Mapper {
+	return handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
+		log := common.LogFromContext(ctx)
+		smcpList := &v1.ServiceMeshControlPlaneList{}
+		if err := cl.List(ctx, client.InNamespace(obj.Meta.GetNamespace()), smcpList); err != nil {
+			log.Error(err, "error listing ServiceMeshControlPlane objects in ServiceMeshControlPlaneTemplate watcher")
+			return nil
+		}
+		var requests []reconcile.Request
+		for _, smcp := range smcpList.Items {
+			if referencesTemplate(&smcp, obj.Meta.GetName()) {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      smcp.Name,
+						Namespace: smcp.Namespace,
+					},
+				})
+			}
+		}
+		return requests
+	})
+}
@@ -0,0 +1,153 @@
+package controlplane
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	maistrav1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+	"github.com/maistra/istio-operator/pkg/controller/common/test"
+	"github.com/maistra/istio-operator/pkg/controller/common/test/assert"
+)
+
+func TestTemplateResourcesAreApplied(t *testing.T) {
+	cl, _ := test.CreateClient(
+		newTemplate("base", "", map[string]interface{}{"a": "base", "b": "base"}),
+		newTemplate("custom", "base", map[string]interface{}{"a": "custom"}))
+	r := newTestReconciler()
+	r.Client = cl
+	r.Instance.ObjectMeta = metav1.ObjectMeta{Name: "my-smcp", Namespace: "istio-system"}
+
+	spec, err := r.applyTemplates(ctx, maistrav1.ControlPlaneSpec{Template: "custom"})
+	if err != nil {
+		t.Fatalf("unexpected error applying templates: %v", err)
+	}
+	assert.DeepEquals(spec.Istio, maistrav1.HelmValuesType{"a": "custom", "b": "base"}, "Unexpected values after applying templates", t)
+
+	applied := r.Status.AppliedTemplates
+	assert.Equals(len(applied), 2, "Unexpected number of applied templates", t)
+	assert.Equals(applied[0].Name, "custom", "Unexpected first applied template", t)
+	assert.Equals(applied[1].Name, "base", "Unexpected second applied template", t)
+	if len(applied[0].ResourceVersion) == 0 || len(applied[1].ResourceVersion) == 0 {
+		t.Errorf("Expected resource versions to be recorded for applied templates: %v", applied)
+	}
+}
+
+func TestMultipleTemplatesAreAppliedInOrder(t *testing.T) {
+	cl, _ := test.CreateClient(
+		newTemplate("base", "", map[string]interface{}{"a": "base", "b": "base", "c": "base"}),
+		newTemplate("first", "base", map[string]interface{}{"a": "first", "b": "first", "d": "first"}),
+		newTemplate("second", "base", map[string]interface{}{"b": "second"}))
+	r := newTestReconciler()
+	r.Client = cl
+	r.Instance.ObjectMeta = metav1.ObjectMeta{Name: "my-smcp", Namespace: "istio-system"}
+
+	spec, err := r.applyTemplates(ctx, maistrav1.ControlPlaneSpec{
+		Templates: []string{"first", "second"},
+		Istio:     maistrav1.HelmValuesType{"c": "smcp", "d": map[string]interface{}{"$patch": "delete"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error applying templates: %v", err)
+	}
+	// second is applied after first, including the values it inherits from base
+	assert.DeepEquals(spec.Istio, maistrav1.HelmValuesType{"a": "base", "b": "second", "c": "smcp"}, "Unexpected values after applying templates", t)
+	assert.DeepEquals(r.Status.ValueSources, map[string]string{"istio.a": "base", "istio.b": "second"}, "Unexpected value sources", t)
+	assert.Equals(len(r.Status.AppliedTemplates), 3, "Expected template inherited by multiple templates to be listed once", t)
+}
+
+func TestTemplateAndTemplateListAreExclusive(t *testing.T) {
+	invalid := newTemplate("invalid", "base", map[string]interface{}{"a": "invalid"})
+	invalid.Spec.Templates = []string{"base"}
+	cl, _ := test.CreateClient(newTemplate("base", "", map[string]interface{}{"a": "base"}), invalid)
+	r := newTestReconciler()
+	r.Client = cl
+	r.Instance.ObjectMeta = metav1.ObjectMeta{Name: "my-smcp", Namespace: "istio-system"}
+
+	if _, err := r.applyTemplates(ctx, maistrav1.ControlPlaneSpec{Template: "base", Templates: []string{"base"}}); err == nil {
+		t.Errorf("Expected error applying both template and list of templates")
+	}
+	if _, err := r.applyTemplates(ctx, maistrav1.ControlPlaneSpec{Templates: []string{"invalid"}}); err == nil {
+		t.Errorf("Expected error applying template specifying both template and list of templates")
+	}
+
+	spec, err := r.applyTemplates(ctx, maistrav1.ControlPlaneSpec{Templates: []string{"base"}})
+	if err != nil {
+		t.Fatalf("unexpected error applying templates: %v", err)
+	}
+	assert.DeepEquals(spec.Istio, maistrav1.HelmValuesType{"a": "base"}, "Expected only the listed templates to be applied", t)
+	assert.Equals(len(r.Status.AppliedTemplates), 1, "Expected the default template not to be applied", t)
+}
+
+func TestTemplatesChanged(t *testing.T) {
+	base := newTemplate("base", "", map[string]interface{}{"a": "base"})
+	cl, _ := test.CreateClient(base)
+	current := &maistrav1.ServiceMeshControlPlaneTemplate{}
+	test.GetObject(ctx, cl, client.ObjectKey{Name: "base", Namespace: "istio-system"}, current)
+
+	testCases := []struct {
+		name     string
+		applied  []maistrav1.AppliedTemplate
+		expected bool
+	}{
+		{
+			name:    "unchanged",
+			applied: []maistrav1.AppliedTemplate{{Name: "base", ResourceVersion: current.ResourceVersion}},
+		},
+		{
+			name:     "modified",
+			applied:  []maistrav1.AppliedTemplate{{Name: "base", ResourceVersion: "0"}},
+			expected: true,
+		},
+		{
+			name:     "overrides-operator-template",
+			applied:  []maistrav1.AppliedTemplate{{Name: "base"}},
+			expected: true,
+		},
+		{
+			name:     "deleted",
+			applied:  []maistrav1.AppliedTemplate{{Name: "missing", ResourceVersion: "1"}},
+			expected: true,
+		},
+		{
+			name:    "operator-template",
+			applied: []maistrav1.AppliedTemplate{{Name: maistrav1.DefaultTemplate}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			smcp := &maistrav1.ServiceMeshControlPlane{ObjectMeta: metav1.ObjectMeta{Name: "my-smcp", Namespace: "istio-system"}}
+			smcp.Status.AppliedTemplates = tc.applied
+			changed, err := templatesChanged(ctx, cl, smcp)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			assert.Equals(changed, tc.expected, "Unexpected result from templatesChanged", t)
+		})
+	}
+}
+
+func TestReferencesTemplate(t *testing.T) {
+	smcp := &maistrav1.ServiceMeshControlPlane{Spec: maistrav1.ControlPlaneSpec{Template: "custom"}}
+	smcp.Status.AppliedTemplates = []maistrav1.AppliedTemplate{{Name: "custom"}, {Name: "base"}}
+	assert.True(referencesTemplate(smcp, "custom"), "Expected directly referenced template to match", t)
+	assert.True(referencesTemplate(smcp, "base"), "Expected inherited template to match", t)
+	assert.False(referencesTemplate(smcp, "other"), "Expected unrelated template not to match", t)
+
+	smcp = &maistrav1.ServiceMeshControlPlane{}
+	assert.True(referencesTemplate(smcp, maistrav1.DefaultTemplate), "Expected default template to match when no template is specified", t)
+
+	smcp = &maistrav1.ServiceMeshControlPlane{Spec: maistrav1.ControlPlaneSpec{Templates: []string{"custom"}}}
+	assert.True(referencesTemplate(smcp, "custom"), "Expected listed template to match", t)
+	assert.False(referencesTemplate(smcp, maistrav1.DefaultTemplate), "Expected default template not to match when templates are listed", t)
+}
+
+func newTemplate(name, parent string, values map[string]interface{}) *maistrav1.ServiceMeshControlPlaneTemplate {
+	return &maistrav1.ServiceMeshControlPlaneTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "istio-system", ResourceVersion: "1"},
+		Spec: maistrav1.ControlPlaneSpec{
+			Template: parent,
+			Istio:    values,
+		},
+	}
+}
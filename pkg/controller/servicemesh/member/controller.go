@@ -172,11 +172,13 @@ func (r *MemberReconciler) Reconcile(request reconcile.Request) (reconcile.Resul
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// Request object not found, could have been deleted after reconcile request.
+			common.DeleteMemberMetrics(request.Namespace, request.Name)
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object
 		return reconcile.Result{}, err
 	}
+	common.RecordMemberMetrics(member)
 
 	mayContinue, err := common.HandleFinalization(ctx, member, r.finalizeMember, r.Client, r.EventRecorder)
 	if err != nil || !mayContinue {
@@ -343,6 +345,7 @@ func (r *MemberReconciler) updateStatus(ctx context.Context, member *maistra.Ser
 		Reason:  reason,
 		Message: message,
 	})
+	common.RecordMemberMetrics(member)
 
 	// TODO: use Client().Status().Patch() and remove the retry code below after we upgrade to controller-runtime 0.2+
 	err := r.Client.Status().Update(ctx, member)
@@ -195,11 +195,13 @@ func (r *MemberRollReconciler) Reconcile(request reconcile.Request) (reconcile.R
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
 			// Return and don't requeue
 			reqLogger.Info("ServiceMeshMemberRoll deleted")
+			common.DeleteMemberRollMetrics(request.Namespace, request.Name)
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object
 		return reconcile.Result{}, err
 	}
+	common.RecordMemberRollMetrics(instance)
 
 	deleted := instance.GetDeletionTimestamp() != nil
 	finalizers := sets.NewString(instance.Finalizers...)
@@ -414,6 +416,8 @@ func (r *MemberRollReconciler) Reconcile(request reconcile.Request) (reconcile.R
 		err = r.Client.Status().Update(ctx, instance)
 		if err != nil {
 			reqLogger.Error(err, "error updating status for ServiceMeshMemberRoll")
+		} else {
+			common.RecordMemberRollMetrics(instance)
 		}
 	} else {
 		return reconcile.Result{}, err
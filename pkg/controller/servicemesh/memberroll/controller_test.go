@@ -63,6 +63,9 @@ var (
 
 func init() {
 	logf.SetLogger(logf.ZapLogger(true))
+	// version descriptors are loaded from the charts in the repository
+	common.Options.ChartsDir = "../../../../resources/helm"
+	common.Options.DefaultTemplatesDir = "../../../../resources/smcp-templates"
 }
 
 func TestReconcileAddsFinalizer(t *testing.T) {
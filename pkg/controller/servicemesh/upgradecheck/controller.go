@@ -0,0 +1,218 @@
+package upgradecheck
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	pkgerrors "github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	maistrav1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+	"github.com/maistra/istio-operator/pkg/controller/common"
+	"github.com/maistra/istio-operator/pkg/controller/servicemesh/webhooks/validation"
+)
+
+const (
+	controllerName = "servicemeshupgradecheck-controller"
+)
+
+// Add creates a new ServiceMeshUpgradeCheck Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, newReconciler(mgr.GetClient(), mgr.GetScheme(), mgr.GetRecorder(controllerName)))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(cl client.Client, scheme *runtime.Scheme, eventRecorder record.EventRecorder) *UpgradeCheckReconciler {
+	return &UpgradeCheckReconciler{
+		ControllerResources: common.ControllerResources{
+			Client:        cl,
+			Scheme:        scheme,
+			EventRecorder: eventRecorder,
+			PatchFactory:  common.NewPatchFactory(cl),
+		},
+		checkInterval: common.Options.UpgradeCheckInterval,
+	}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r *UpgradeCheckReconciler) error {
+	ctx := common.NewContextWithLog(common.NewContext(), createLogger())
+	// Create a new controller
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to primary resource ServiceMeshUpgradeCheck
+	err = c.Watch(&source.Kind{Type: &maistrav1.ServiceMeshUpgradeCheck{}}, &handler.EnqueueRequestForObject{}, predicate.Funcs{
+		UpdateFunc: func(event event.UpdateEvent) bool {
+			// status updates are ignored; checks are repeated periodically
+			return event.MetaOld.GetGeneration() != event.MetaNew.GetGeneration()
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// check again when the version of a control plane changes
+	err = c.Watch(&source.Kind{Type: &maistrav1.ServiceMeshControlPlane{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(smcp handler.MapObject) []reconcile.Request {
+			return r.getRequestsForChecksReferencing(ctx, smcp.Meta.GetNamespace(), smcp.Meta.GetName())
+		}),
+	}, predicate.Funcs{
+		UpdateFunc: func(event event.UpdateEvent) bool {
+			return event.MetaOld.GetGeneration() != event.MetaNew.GetGeneration()
+		},
+		GenericFunc: func(_ event.GenericEvent) bool {
+			return false
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *UpgradeCheckReconciler) getRequestsForChecksReferencing(ctx context.Context, namespace, name string) []reconcile.Request {
+	log := common.LogFromContext(ctx)
+	list := &maistrav1.ServiceMeshUpgradeCheckList{}
+	if err := r.Client.List(ctx, client.InNamespace(namespace), list); err != nil {
+		log.Error(err, "Could not list ServiceMeshUpgradeChecks")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, check := range list.Items {
+		if check.Spec.ControlPlaneName == name {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      check.Name,
+					Namespace: check.Namespace,
+				},
+			})
+		}
+	}
+	return requests
+}
+
+var _ reconcile.Reconciler = &UpgradeCheckReconciler{}
+
+// UpgradeCheckReconciler reconciles ServiceMeshUpgradeCheck objects.  The
+// checks are repeated every checkInterval, as the objects being checked, e.g.
+// Services in member namespaces, are not watched.
+type UpgradeCheckReconciler struct {
+	common.ControllerResources
+	checkInterval time.Duration
+}
+
+// Reconcile runs the checks for the control plane referenced by the
+// ServiceMeshUpgradeCheck and records the issues found in its status.
+func (r *UpgradeCheckReconciler) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := createLogger().WithValues("ServiceMeshUpgradeCheck", request)
+	ctx := common.NewReconcileContext(reqLogger)
+
+	reqLogger.Info("Processing ServiceMeshUpgradeCheck")
+	defer func() {
+		reqLogger.Info("processing complete")
+	}()
+
+	check := &maistrav1.ServiceMeshUpgradeCheck{}
+	err := r.Client.Get(ctx, request.NamespacedName, check)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			return reconcile.Result{}, nil
+		}
+		// Error reading the object
+		return reconcile.Result{}, err
+	}
+
+	r.runCheck(ctx, check)
+
+	if err := r.Client.Status().Update(ctx, check); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, pkgerrors.Wrapf(err, "could not update status of ServiceMeshUpgradeCheck %s/%s", check.Namespace, check.Name)
+	}
+	return reconcile.Result{RequeueAfter: r.checkInterval}, nil
+}
+
+// runCheck updates the status of the ServiceMeshUpgradeCheck with the result
+// of checking the control plane against the target version
+func (r *UpgradeCheckReconciler) runCheck(ctx context.Context, check *maistrav1.ServiceMeshUpgradeCheck) {
+	log := common.LogFromContext(ctx)
+	now := metav1.NewTime(time.Now().Truncate(time.Second))
+	check.Status.ObservedGeneration = check.Generation
+	check.Status.TargetVersion = check.Spec.TargetVersion
+	check.Status.LastCheckTime = &now
+	check.Status.Issues = nil
+
+	smcp := &maistrav1.ServiceMeshControlPlane{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Namespace: check.Namespace, Name: check.Spec.ControlPlaneName}, smcp); err != nil {
+		log.Error(err, "error retrieving ServiceMeshControlPlane")
+		check.Status.CurrentVersion = ""
+		r.setUpgradeableCondition(check, maistrav1.ConditionStatusUnknown, maistrav1.ConditionReasonUpgradeCheckError,
+			fmt.Sprintf("Error retrieving ServiceMeshControlPlane %s: %s", check.Spec.ControlPlaneName, err))
+		return
+	}
+	check.Status.CurrentVersion = smcp.Spec.Version
+
+	issues, err := validation.CheckVersionChange(ctx, r.Client, smcp, check.Spec.TargetVersion)
+	if err != nil {
+		log.Error(err, "error checking ServiceMeshControlPlane")
+		r.setUpgradeableCondition(check, maistrav1.ConditionStatusUnknown, maistrav1.ConditionReasonUpgradeCheckError,
+			fmt.Sprintf("Error checking ServiceMeshControlPlane: %s", err))
+		return
+	}
+	check.Status.Issues = issues
+
+	if len(issues) > 0 {
+		log.Info("found objects blocking the version change", "issues", len(issues))
+		r.setUpgradeableCondition(check, maistrav1.ConditionStatusFalse, maistrav1.ConditionReasonUpgradeBlocked,
+			fmt.Sprintf("%d object(s) block the update to version %s", len(issues), check.Spec.TargetVersion))
+		return
+	}
+	r.setUpgradeableCondition(check, maistrav1.ConditionStatusTrue, maistrav1.ConditionReasonUpgradeCheckPassed,
+		fmt.Sprintf("No objects block the update to version %s", check.Spec.TargetVersion))
+}
+
+func (r *UpgradeCheckReconciler) setUpgradeableCondition(check *maistrav1.ServiceMeshUpgradeCheck, status maistrav1.ConditionStatus, reason maistrav1.ConditionReason, message string) {
+	previous := check.Status.GetCondition(maistrav1.ConditionTypeUpgradeable)
+	check.Status.SetCondition(maistrav1.Condition{
+		Type:    maistrav1.ConditionTypeUpgradeable,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	})
+	if previous.Status == status && previous.Reason == reason {
+		return
+	}
+	eventType := corev1.EventTypeWarning
+	if status == maistrav1.ConditionStatusTrue {
+		eventType = corev1.EventTypeNormal
+	}
+	r.EventRecorder.Event(check, eventType, string(reason), message)
+}
+
+func createLogger() logr.Logger {
+	return logf.Log.WithName(controllerName)
+}
@@ -0,0 +1,156 @@
+package upgradecheck
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+
+	maistrav1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+	"github.com/maistra/istio-operator/pkg/controller/common"
+	"github.com/maistra/istio-operator/pkg/controller/common/test"
+	"github.com/maistra/istio-operator/pkg/controller/common/test/assert"
+)
+
+var ctx = common.NewContextWithLog(context.Background(), logf.Log)
+
+func init() {
+	// version descriptors are loaded from the charts in the repository
+	common.Options.ChartsDir = "../../../../resources/helm"
+	common.Options.DefaultTemplatesDir = "../../../../resources/smcp-templates"
+}
+
+const (
+	checkName             = "upgrade-to-v1.1"
+	controlPlaneName      = "basic-install"
+	controlPlaneNamespace = "istio-system"
+)
+
+func TestReconcileUpgradeCheck(t *testing.T) {
+	testCases := []struct {
+		name              string
+		objects           []runtime.Object
+		expectedStatus    maistrav1.ConditionStatus
+		expectedReason    maistrav1.ConditionReason
+		expectedIssues    []maistrav1.UpgradeCheckIssue
+		expectedVersion   string
+		expectedEventType string
+	}{
+		{
+			name:              "no-issues",
+			objects:           []runtime.Object{newControlPlane("v1.0")},
+			expectedStatus:    maistrav1.ConditionStatusTrue,
+			expectedReason:    maistrav1.ConditionReasonUpgradeCheckPassed,
+			expectedVersion:   "v1.0",
+			expectedEventType: corev1.EventTypeNormal,
+		},
+		{
+			name: "blocked",
+			objects: []runtime.Object{
+				newControlPlane("v1.0"),
+				newService("legacy-app", "http-legacy", 443),
+				newService("other-app", "https", 443),
+			},
+			expectedStatus: maistrav1.ConditionStatusFalse,
+			expectedReason: maistrav1.ConditionReasonUpgradeBlocked,
+			expectedIssues: []maistrav1.UpgradeCheckIssue{
+				{
+					Kind:        "Service",
+					Namespace:   controlPlaneNamespace,
+					Name:        "legacy-app",
+					Message:     "Port 443 is not allowed for http/http2 protocols on Service istio-system/legacy-app",
+					Remediation: "rename port http-legacy, e.g. to https, or serve http traffic on a port other than 443",
+				},
+			},
+			expectedVersion:   "v1.0",
+			expectedEventType: corev1.EventTypeWarning,
+		},
+		{
+			name:              "missing-control-plane",
+			expectedStatus:    maistrav1.ConditionStatusUnknown,
+			expectedReason:    maistrav1.ConditionReasonUpgradeCheckError,
+			expectedEventType: corev1.EventTypeWarning,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			check := newUpgradeCheck("v1.1")
+			cl, _ := test.CreateClient(append(tc.objects, check)...)
+			recorder := record.NewFakeRecorder(10)
+			r := newReconciler(cl, test.GetScheme(), recorder)
+			r.checkInterval = time.Hour
+
+			result, err := r.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: controlPlaneNamespace, Name: checkName}})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			assert.Equals(result.RequeueAfter, time.Hour, "Expected check to be repeated after the check interval", t)
+
+			updated := test.GetUpdatedObject(ctx, cl, check.ObjectMeta, &maistrav1.ServiceMeshUpgradeCheck{}).(*maistrav1.ServiceMeshUpgradeCheck)
+			condition := updated.Status.GetCondition(maistrav1.ConditionTypeUpgradeable)
+			assert.Equals(condition.Status, tc.expectedStatus, "Unexpected Upgradeable condition status", t)
+			assert.Equals(condition.Reason, tc.expectedReason, "Unexpected Upgradeable condition reason", t)
+			assert.DeepEquals(updated.Status.Issues, tc.expectedIssues, "Unexpected issues", t)
+			assert.Equals(updated.Status.CurrentVersion, tc.expectedVersion, "Unexpected current version", t)
+			assert.Equals(updated.Status.TargetVersion, "v1.1", "Unexpected target version", t)
+			assert.True(updated.Status.LastCheckTime != nil, "Expected last check time to be set", t)
+			assert.Equals(len(recorder.Events), 1, "Expected an event for the changed condition", t)
+			assert.Equals((<-recorder.Events)[:len(tc.expectedEventType)], tc.expectedEventType, "Unexpected event type", t)
+		})
+	}
+}
+
+func newUpgradeCheck(targetVersion string) *maistrav1.ServiceMeshUpgradeCheck {
+	return &maistrav1.ServiceMeshUpgradeCheck{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: maistrav1.SchemeGroupVersion.String(),
+			Kind:       "ServiceMeshUpgradeCheck",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       checkName,
+			Namespace:  controlPlaneNamespace,
+			Generation: 1,
+		},
+		Spec: maistrav1.ServiceMeshUpgradeCheckSpec{
+			ControlPlaneName: controlPlaneName,
+			TargetVersion:    targetVersion,
+		},
+	}
+}
+
+func newControlPlane(version string) *maistrav1.ServiceMeshControlPlane {
+	return &maistrav1.ServiceMeshControlPlane{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: maistrav1.SchemeGroupVersion.String(),
+			Kind:       "ServiceMeshControlPlane",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      controlPlaneName,
+			Namespace: controlPlaneNamespace,
+		},
+		Spec: maistrav1.ControlPlaneSpec{
+			Version: version,
+		},
+	}
+}
+
+func newService(name, portName string, port int32) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: controlPlaneNamespace,
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{
+				{Name: portName, Port: port},
+			},
+		},
+	}
+}
@@ -0,0 +1,263 @@
+package upgradepolicy
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/go-logr/logr"
+	pkgerrors "github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/maistra/istio-operator/pkg/apis/maistra"
+	maistrav1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+	"github.com/maistra/istio-operator/pkg/controller/common"
+	"github.com/maistra/istio-operator/pkg/controller/servicemesh/webhooks/validation"
+)
+
+const (
+	controllerName = "upgradepolicy-controller"
+
+	eventReasonUpgrading = "Upgrading"
+)
+
+// Add creates a new upgrade policy Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, newReconciler(mgr.GetClient(), mgr.GetScheme(), mgr.GetRecorder(controllerName)))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(cl client.Client, scheme *runtime.Scheme, eventRecorder record.EventRecorder) *UpgradePolicyReconciler {
+	return &UpgradePolicyReconciler{
+		ControllerResources: common.ControllerResources{
+			Client:        cl,
+			Scheme:        scheme,
+			EventRecorder: eventRecorder,
+			PatchFactory:  common.NewPatchFactory(cl),
+		},
+		checkInterval: common.Options.UpgradeCheckInterval,
+	}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r *UpgradePolicyReconciler) error {
+	// Create a new controller
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to ServiceMeshControlPlanes.  Other status updates
+	// are ignored, but a control plane is checked once it has been reconciled
+	// and whenever its UpgradeAvailable condition has been replaced.
+	err = c.Watch(&source.Kind{Type: &maistrav1.ServiceMeshControlPlane{}}, &handler.EnqueueRequestForObject{}, predicate.Funcs{
+		UpdateFunc: func(event event.UpdateEvent) bool {
+			if event.MetaOld.GetGeneration() != event.MetaNew.GetGeneration() ||
+				event.MetaOld.GetAnnotations()[common.ApproveUpgradeKey] != event.MetaNew.GetAnnotations()[common.ApproveUpgradeKey] {
+				return true
+			}
+			oldSmcp, oldOk := event.ObjectOld.(*maistrav1.ServiceMeshControlPlane)
+			newSmcp, newOk := event.ObjectNew.(*maistrav1.ServiceMeshControlPlane)
+			if !oldOk || !newOk {
+				return false
+			}
+			for _, conditionType := range []maistrav1.ConditionType{maistrav1.ConditionTypeReconciled, maistrav1.ConditionTypeUpgradeAvailable} {
+				if oldSmcp.Status.GetCondition(conditionType).Status != newSmcp.Status.GetCondition(conditionType).Status {
+					return true
+				}
+			}
+			return false
+		},
+		GenericFunc: func(_ event.GenericEvent) bool {
+			return false
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+var _ reconcile.Reconciler = &UpgradePolicyReconciler{}
+
+// UpgradePolicyReconciler reports the upgrades available for
+// ServiceMeshControlPlanes and applies them according to their upgrade
+// policy.  Available upgrades are checked again every checkInterval, as the
+// objects that may block an upgrade are not watched.
+type UpgradePolicyReconciler struct {
+	common.ControllerResources
+	checkInterval time.Duration
+}
+
+// Reconcile checks whether the operator ships a newer version the
+// ServiceMeshControlPlane can be upgraded to.  The upgrade is applied by
+// updating spec.version if the upgrade policy allows it or the upgrade has
+// been approved and no objects block it.  Otherwise, the upgrade is reported
+// in the UpgradeAvailable condition.
+func (r *UpgradePolicyReconciler) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := createLogger().WithValues("ServiceMeshControlPlane", request)
+	ctx := common.NewReconcileContext(reqLogger)
+
+	reqLogger.Info("Processing ServiceMeshControlPlane")
+	defer func() {
+		reqLogger.Info("processing complete")
+	}()
+
+	smcp := &maistrav1.ServiceMeshControlPlane{}
+	err := r.Client.Get(ctx, request.NamespacedName, smcp)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			return reconcile.Result{}, nil
+		}
+		// Error reading the object
+		return reconcile.Result{}, err
+	}
+	if smcp.GetDeletionTimestamp() != nil {
+		return reconcile.Result{}, nil
+	}
+	if !isFullyReconciled(smcp) {
+		// the status is owned by the control plane controller until the
+		// control plane has been reconciled
+		reqLogger.V(2).Info("ServiceMeshControlPlane has not been reconciled yet")
+		return reconcile.Result{}, nil
+	}
+
+	previousStatus := smcp.Status.DeepCopy()
+	upgraded, err := r.checkForUpgrade(ctx, smcp)
+	if err != nil || upgraded {
+		return reconcile.Result{}, err
+	}
+
+	if !reflect.DeepEqual(previousStatus.Conditions, smcp.Status.Conditions) || previousStatus.AvailableVersion != smcp.Status.AvailableVersion {
+		if err := r.Client.Status().Update(ctx, smcp); err != nil {
+			if errors.IsNotFound(err) {
+				return reconcile.Result{}, nil
+			}
+			return reconcile.Result{}, pkgerrors.Wrapf(err, "could not update status of ServiceMeshControlPlane %s/%s", smcp.Namespace, smcp.Name)
+		}
+	}
+	if smcp.Status.AvailableVersion == "" {
+		return reconcile.Result{}, nil
+	}
+	return reconcile.Result{RequeueAfter: r.checkInterval}, nil
+}
+
+// checkForUpgrade upgrades the control plane if an upgrade is available and
+// either allowed by the upgrade policy or approved.  Otherwise, it updates the
+// UpgradeAvailable condition and the available version in the status of the
+// control plane, which the caller must post.
+func (r *UpgradePolicyReconciler) checkForUpgrade(ctx context.Context, smcp *maistrav1.ServiceMeshControlPlane) (bool, error) {
+	log := common.LogFromContext(ctx)
+
+	patch, minor, err := common.AvailableUpgrades(smcp.Spec.Version)
+	if err != nil {
+		log.Error(err, "error checking available upgrades")
+		r.setAvailableUpgrade(smcp, "", maistrav1.ConditionStatusUnknown, maistrav1.ConditionReasonUpgradeCheckError,
+			fmt.Sprintf("Error checking available upgrades: %s", err))
+		return false, nil
+	}
+
+	targetVersion := minor
+	if targetVersion == "" {
+		targetVersion = patch
+	}
+	automatic := false
+	switch smcp.Spec.UpgradePolicy {
+	case maistrav1.UpgradePolicyAutomaticMinor:
+		automatic = true
+	case maistrav1.UpgradePolicyAutomaticPatch:
+		// the newer version is offered once the patch upgrade has been applied
+		if patch != "" {
+			targetVersion, automatic = patch, true
+		}
+	}
+	if targetVersion == "" {
+		r.setAvailableUpgrade(smcp, "", maistrav1.ConditionStatusFalse, maistrav1.ConditionReasonUpToDate,
+			"No newer version is available")
+		return false, nil
+	}
+
+	issues, err := validation.CheckVersionChange(ctx, r.Client, smcp, targetVersion)
+	if err != nil {
+		log.Error(err, "error checking upgrade", "version", targetVersion)
+		r.setAvailableUpgrade(smcp, targetVersion, maistrav1.ConditionStatusUnknown, maistrav1.ConditionReasonUpgradeCheckError,
+			fmt.Sprintf("Error checking upgrade to version %s: %s", targetVersion, err))
+		return false, nil
+	}
+	if len(issues) > 0 {
+		log.Info("found objects blocking the upgrade", "version", targetVersion, "issues", len(issues))
+		r.setAvailableUpgrade(smcp, targetVersion, maistrav1.ConditionStatusTrue, maistrav1.ConditionReasonUpgradeBlocked,
+			fmt.Sprintf("Version %s is available, but %d object(s) block the upgrade; create a ServiceMeshUpgradeCheck to list them", targetVersion, len(issues)))
+		return false, nil
+	}
+
+	if !automatic && smcp.GetAnnotations()[common.ApproveUpgradeKey] != targetVersion {
+		r.setAvailableUpgrade(smcp, targetVersion, maistrav1.ConditionStatusTrue, maistrav1.ConditionReasonUpgradePendingApproval,
+			fmt.Sprintf("Version %s is available; annotate the ServiceMeshControlPlane with %s=%s to upgrade", targetVersion, common.ApproveUpgradeKey, targetVersion))
+		return false, nil
+	}
+
+	log.Info("upgrading ServiceMeshControlPlane", "version", targetVersion)
+	currentVersion := smcp.Spec.Version
+	if currentVersion == "" {
+		currentVersion = maistra.LegacyVersion.String()
+	}
+	smcp.Spec.Version = targetVersion
+	delete(smcp.Annotations, common.ApproveUpgradeKey)
+	if err := r.Client.Update(ctx, smcp); err != nil {
+		if errors.IsNotFound(err) {
+			return true, nil
+		}
+		return true, pkgerrors.Wrapf(err, "could not upgrade ServiceMeshControlPlane %s/%s to version %s", smcp.Namespace, smcp.Name, targetVersion)
+	}
+	r.EventRecorder.Event(smcp, corev1.EventTypeNormal, eventReasonUpgrading, fmt.Sprintf("Upgrading control plane from version %s to %s", currentVersion, targetVersion))
+	return true, nil
+}
+
+// setAvailableUpgrade records the available version and the UpgradeAvailable
+// condition in the status of the control plane
+func (r *UpgradePolicyReconciler) setAvailableUpgrade(smcp *maistrav1.ServiceMeshControlPlane, version string, status maistrav1.ConditionStatus, reason maistrav1.ConditionReason, message string) {
+	smcp.Status.AvailableVersion = version
+	previous := smcp.Status.GetCondition(maistrav1.ConditionTypeUpgradeAvailable)
+	smcp.Status.SetCondition(maistrav1.Condition{
+		Type:    maistrav1.ConditionTypeUpgradeAvailable,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	})
+	if previous.Status == status && previous.Reason == reason {
+		return
+	}
+	eventType := corev1.EventTypeNormal
+	if status == maistrav1.ConditionStatusUnknown || reason == maistrav1.ConditionReasonUpgradeBlocked {
+		eventType = corev1.EventTypeWarning
+	}
+	r.EventRecorder.Event(smcp, eventType, string(reason), message)
+}
+
+// isFullyReconciled returns true if the current generation of the control
+// plane has been reconciled successfully
+func isFullyReconciled(smcp *maistrav1.ServiceMeshControlPlane) bool {
+	return maistrav1.CurrentReconciledVersion(smcp.GetGeneration()) == smcp.Status.GetReconciledVersion() &&
+		smcp.Status.GetCondition(maistrav1.ConditionTypeReconciled).Status == maistrav1.ConditionStatusTrue
+}
+
+func createLogger() logr.Logger {
+	return logf.Log.WithName(controllerName)
+}
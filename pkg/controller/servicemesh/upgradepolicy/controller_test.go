@@ -0,0 +1,210 @@
+package upgradepolicy
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+
+	maistrav1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+	"github.com/maistra/istio-operator/pkg/controller/common"
+	"github.com/maistra/istio-operator/pkg/controller/common/test"
+	"github.com/maistra/istio-operator/pkg/controller/common/test/assert"
+)
+
+var ctx = common.NewContextWithLog(context.Background(), logf.Log)
+
+func init() {
+	// version descriptors are loaded from the charts in the repository
+	common.Options.ChartsDir = "../../../../resources/helm"
+	common.Options.DefaultTemplatesDir = "../../../../resources/smcp-templates"
+}
+
+const (
+	controlPlaneName      = "basic-install"
+	controlPlaneNamespace = "istio-system"
+)
+
+func TestReconcileUpgradePolicy(t *testing.T) {
+	testCases := []struct {
+		name                     string
+		version                  string
+		policy                   maistrav1.UpgradePolicyType
+		approvedVersion          string
+		objects                  []runtime.Object
+		expectedVersion          string
+		expectedStatus           maistrav1.ConditionStatus
+		expectedReason           maistrav1.ConditionReason
+		expectedAvailableVersion string
+		expectedRequeue          bool
+		expectedEventReason      string
+	}{
+		{
+			name:                "up-to-date",
+			version:             "v1.2",
+			expectedVersion:     "v1.2",
+			expectedStatus:      maistrav1.ConditionStatusFalse,
+			expectedReason:      maistrav1.ConditionReasonUpToDate,
+			expectedEventReason: string(maistrav1.ConditionReasonUpToDate),
+		},
+		{
+			name:                     "pending-approval",
+			version:                  "v1.1",
+			expectedVersion:          "v1.1",
+			expectedStatus:           maistrav1.ConditionStatusTrue,
+			expectedReason:           maistrav1.ConditionReasonUpgradePendingApproval,
+			expectedAvailableVersion: "v1.2",
+			expectedRequeue:          true,
+			expectedEventReason:      string(maistrav1.ConditionReasonUpgradePendingApproval),
+		},
+		{
+			name:                     "approval-for-other-version",
+			version:                  "v1.1",
+			approvedVersion:          "v1.3",
+			expectedVersion:          "v1.1",
+			expectedStatus:           maistrav1.ConditionStatusTrue,
+			expectedReason:           maistrav1.ConditionReasonUpgradePendingApproval,
+			expectedAvailableVersion: "v1.2",
+			expectedRequeue:          true,
+			expectedEventReason:      string(maistrav1.ConditionReasonUpgradePendingApproval),
+		},
+		{
+			name:                "approved",
+			version:             "v1.1",
+			approvedVersion:     "v1.2",
+			expectedVersion:     "v1.2",
+			expectedStatus:      maistrav1.ConditionStatusUnknown,
+			expectedEventReason: eventReasonUpgrading,
+		},
+		{
+			name:                     "automatic-patch",
+			version:                  "v1.1",
+			policy:                   maistrav1.UpgradePolicyAutomaticPatch,
+			expectedVersion:          "v1.1",
+			expectedStatus:           maistrav1.ConditionStatusTrue,
+			expectedReason:           maistrav1.ConditionReasonUpgradePendingApproval,
+			expectedAvailableVersion: "v1.2",
+			expectedRequeue:          true,
+			expectedEventReason:      string(maistrav1.ConditionReasonUpgradePendingApproval),
+		},
+		{
+			name:                "automatic-minor",
+			version:             "v1.1",
+			policy:              maistrav1.UpgradePolicyAutomaticMinor,
+			expectedVersion:     "v1.2",
+			expectedStatus:      maistrav1.ConditionStatusUnknown,
+			expectedEventReason: eventReasonUpgrading,
+		},
+		{
+			name:    "blocked",
+			version: "v1.0",
+			policy:  maistrav1.UpgradePolicyAutomaticMinor,
+			objects: []runtime.Object{
+				newService("legacy-app", "http-legacy", 443),
+			},
+			expectedVersion:          "v1.0",
+			expectedStatus:           maistrav1.ConditionStatusTrue,
+			expectedReason:           maistrav1.ConditionReasonUpgradeBlocked,
+			expectedAvailableVersion: "v1.2",
+			expectedRequeue:          true,
+			expectedEventReason:      string(maistrav1.ConditionReasonUpgradeBlocked),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			smcp := newControlPlane(tc.version, tc.policy)
+			if tc.approvedVersion != "" {
+				smcp.Annotations = map[string]string{common.ApproveUpgradeKey: tc.approvedVersion}
+			}
+			cl, _ := test.CreateClient(append(tc.objects, smcp)...)
+			recorder := record.NewFakeRecorder(10)
+			r := newReconciler(cl, test.GetScheme(), recorder)
+			r.checkInterval = time.Hour
+
+			result, err := r.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: controlPlaneNamespace, Name: controlPlaneName}})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			assert.Equals(result.RequeueAfter > 0, tc.expectedRequeue, "Unexpected requeue", t)
+
+			updated := test.GetUpdatedObject(ctx, cl, smcp.ObjectMeta, &maistrav1.ServiceMeshControlPlane{}).(*maistrav1.ServiceMeshControlPlane)
+			assert.Equals(updated.Spec.Version, tc.expectedVersion, "Unexpected version", t)
+			if tc.expectedVersion != tc.version {
+				assert.Equals(updated.Annotations[common.ApproveUpgradeKey], "", "Expected approval to be removed after the upgrade", t)
+			}
+			condition := updated.Status.GetCondition(maistrav1.ConditionTypeUpgradeAvailable)
+			assert.Equals(condition.Status, tc.expectedStatus, "Unexpected UpgradeAvailable condition status", t)
+			assert.Equals(condition.Reason, tc.expectedReason, "Unexpected UpgradeAvailable condition reason", t)
+			assert.Equals(updated.Status.AvailableVersion, tc.expectedAvailableVersion, "Unexpected available version", t)
+			assert.Equals(len(recorder.Events), 1, "Expected a single event", t)
+			event := <-recorder.Events
+			// events are recorded as "<type> <reason> <message>"
+			assert.True(strings.Contains(event, " "+tc.expectedEventReason+" "), "Unexpected event: "+event, t)
+		})
+	}
+}
+
+func TestReconcileSkipsControlPlanesBeingReconciled(t *testing.T) {
+	smcp := newControlPlane("v1.1", maistrav1.UpgradePolicyAutomaticMinor)
+	smcp.Generation = 2
+	cl, _ := test.CreateClient(smcp)
+	recorder := record.NewFakeRecorder(10)
+	r := newReconciler(cl, test.GetScheme(), recorder)
+
+	_, err := r.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: controlPlaneNamespace, Name: controlPlaneName}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	updated := test.GetUpdatedObject(ctx, cl, smcp.ObjectMeta, &maistrav1.ServiceMeshControlPlane{}).(*maistrav1.ServiceMeshControlPlane)
+	assert.Equals(updated.Spec.Version, "v1.1", "Expected control plane not to be upgraded before it has been reconciled", t)
+	assert.Equals(len(recorder.Events), 0, "Expected no events", t)
+}
+
+func newControlPlane(version string, policy maistrav1.UpgradePolicyType) *maistrav1.ServiceMeshControlPlane {
+	smcp := &maistrav1.ServiceMeshControlPlane{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: maistrav1.SchemeGroupVersion.String(),
+			Kind:       "ServiceMeshControlPlane",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       controlPlaneName,
+			Namespace:  controlPlaneNamespace,
+			Generation: 1,
+		},
+		Spec: maistrav1.ControlPlaneSpec{
+			Version:       version,
+			UpgradePolicy: policy,
+		},
+	}
+	// fully reconciled
+	smcp.Status.ObservedGeneration = 1
+	smcp.Status.ReconciledVersion = maistrav1.CurrentReconciledVersion(1)
+	smcp.Status.SetCondition(maistrav1.Condition{
+		Type:   maistrav1.ConditionTypeReconciled,
+		Status: maistrav1.ConditionStatusTrue,
+	})
+	return smcp
+}
+
+func newService(name, portName string, port int32) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: controlPlaneNamespace,
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{
+				{Name: portName, Port: port},
+			},
+		},
+	}
+}
@@ -0,0 +1,122 @@
+package conversion
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+
+	"github.com/maistra/istio-operator/pkg/apis/maistra/conversion"
+	maistrav1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+	maistrav2 "github.com/maistra/istio-operator/pkg/apis/maistra/v2"
+)
+
+var log = logf.Log.WithName("smcp-converter")
+
+// ControlPlaneConverter serves the conversion webhook of the
+// ServiceMeshControlPlane CustomResourceDefinition, which converts control
+// planes between v1 and v2 for the API server.
+type ControlPlaneConverter struct{}
+
+var _ http.Handler = (*ControlPlaneConverter)(nil)
+
+func NewControlPlaneConverter() *ControlPlaneConverter {
+	return &ControlPlaneConverter{}
+}
+
+// ServeHTTP handles a ConversionReview.  Conversion errors are reported in the
+// result of the review, as expected by the API server.
+func (c *ControlPlaneConverter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		log.Error(err, "error reading conversion request")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	review := &apiextensionsv1beta1.ConversionReview{}
+	if err := json.Unmarshal(data, review); err != nil || review.Request == nil {
+		if err == nil {
+			err = fmt.Errorf("conversion review contains no request")
+		}
+		log.Error(err, "error decoding conversion request")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	review.Response = c.convert(review.Request)
+	review.Request = nil
+	data, err = json.Marshal(review)
+	if err != nil {
+		log.Error(err, "error encoding conversion response")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(data); err != nil {
+		log.Error(err, "error writing conversion response")
+	}
+}
+
+func (c *ControlPlaneConverter) convert(request *apiextensionsv1beta1.ConversionRequest) *apiextensionsv1beta1.ConversionResponse {
+	response := &apiextensionsv1beta1.ConversionResponse{
+		UID:              request.UID,
+		ConvertedObjects: []runtime.RawExtension{},
+	}
+	for _, object := range request.Objects {
+		converted, err := convertControlPlane(object.Raw, request.DesiredAPIVersion)
+		if err != nil {
+			log.Info("error converting ServiceMeshControlPlane", "apiVersion", request.DesiredAPIVersion, "error", err.Error())
+			response.ConvertedObjects = nil
+			response.Result = metav1.Status{
+				Status:  metav1.StatusFailure,
+				Reason:  metav1.StatusReasonBadRequest,
+				Message: err.Error(),
+			}
+			return response
+		}
+		response.ConvertedObjects = append(response.ConvertedObjects, runtime.RawExtension{Raw: converted})
+	}
+	response.Result = metav1.Status{Status: metav1.StatusSuccess}
+	return response
+}
+
+// convertControlPlane converts the json representation of a control plane to
+// the desired API version
+func convertControlPlane(data []byte, desiredAPIVersion string) ([]byte, error) {
+	typeMeta := metav1.TypeMeta{}
+	if err := json.Unmarshal(data, &typeMeta); err != nil {
+		return nil, err
+	}
+	if typeMeta.APIVersion == desiredAPIVersion {
+		return data, nil
+	}
+
+	switch {
+	case typeMeta.APIVersion == maistrav1.SchemeGroupVersion.String() && desiredAPIVersion == maistrav2.SchemeGroupVersion.String():
+		in := &maistrav1.ServiceMeshControlPlane{}
+		if err := json.Unmarshal(data, in); err != nil {
+			return nil, err
+		}
+		out := &maistrav2.ServiceMeshControlPlane{}
+		if err := conversion.ConvertV1ToV2(in, out); err != nil {
+			return nil, fmt.Errorf("error converting ServiceMeshControlPlane %s/%s to %s: %v", in.Namespace, in.Name, desiredAPIVersion, err)
+		}
+		return json.Marshal(out)
+	case typeMeta.APIVersion == maistrav2.SchemeGroupVersion.String() && desiredAPIVersion == maistrav1.SchemeGroupVersion.String():
+		in := &maistrav2.ServiceMeshControlPlane{}
+		if err := json.Unmarshal(data, in); err != nil {
+			return nil, err
+		}
+		out := &maistrav1.ServiceMeshControlPlane{}
+		if err := conversion.ConvertV2ToV1(in, out); err != nil {
+			return nil, fmt.Errorf("error converting ServiceMeshControlPlane %s/%s to %s: %v", in.Namespace, in.Name, desiredAPIVersion, err)
+		}
+		return json.Marshal(out)
+	}
+	return nil, fmt.Errorf("unsupported conversion of ServiceMeshControlPlane from %s to %s", typeMeta.APIVersion, desiredAPIVersion)
+}
@@ -0,0 +1,100 @@
+package conversion
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	maistrav1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+	maistrav2 "github.com/maistra/istio-operator/pkg/apis/maistra/v2"
+	"github.com/maistra/istio-operator/pkg/controller/common/test/assert"
+)
+
+func TestConvertControlPlanes(t *testing.T) {
+	v1smcp := &maistrav1.ServiceMeshControlPlane{
+		TypeMeta:   metav1.TypeMeta{APIVersion: maistrav1.SchemeGroupVersion.String(), Kind: "ServiceMeshControlPlane"},
+		ObjectMeta: metav1.ObjectMeta{Name: "basic-install", Namespace: "istio-system", UID: "1234"},
+		Spec: maistrav1.ControlPlaneSpec{
+			Version: "v1.1",
+			Istio: maistrav1.HelmValuesType{
+				"kiali": map[string]interface{}{"enabled": true},
+			},
+		},
+	}
+	review := postConversionReview(t, maistrav2.SchemeGroupVersion.String(), v1smcp)
+
+	assert.Equals(review.Response.UID, review.Request.UID, "Expected response to identify the request", t)
+	assert.Equals(review.Response.Result.Status, metav1.StatusSuccess, "Expected conversion to succeed", t)
+	assert.Equals(len(review.Response.ConvertedObjects), 1, "Expected a converted object", t)
+	v2smcp := &maistrav2.ServiceMeshControlPlane{}
+	if err := json.Unmarshal(review.Response.ConvertedObjects[0].Raw, v2smcp); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Equals(v2smcp.APIVersion, maistrav2.SchemeGroupVersion.String(), "Unexpected apiVersion", t)
+	assert.Equals(v2smcp.UID, v1smcp.UID, "Expected metadata to be preserved", t)
+	assert.True(v2smcp.Spec.Addons != nil && v2smcp.Spec.Addons.Kiali != nil && *v2smcp.Spec.Addons.Kiali.Enabled,
+		"Expected kiali.enabled to be converted to addons.kiali.enabled", t)
+}
+
+func TestConvertControlPlanesFailure(t *testing.T) {
+	autoMTLS := true
+	v2smcp := &maistrav2.ServiceMeshControlPlane{
+		TypeMeta:   metav1.TypeMeta{APIVersion: maistrav2.SchemeGroupVersion.String(), Kind: "ServiceMeshControlPlane"},
+		ObjectMeta: metav1.ObjectMeta{Name: "basic-install", Namespace: "istio-system"},
+		Spec: maistrav2.ControlPlaneSpec{
+			Version: "v1.0",
+			Security: &maistrav2.SecurityConfig{
+				DataPlane: &maistrav2.DataPlaneSecurityConfig{AutoMTLS: &autoMTLS},
+			},
+		},
+	}
+	review := postConversionReview(t, maistrav1.SchemeGroupVersion.String(), v2smcp)
+
+	assert.Equals(review.Response.Result.Status, metav1.StatusFailure, "Expected conversion to fail", t)
+	assert.Equals(len(review.Response.ConvertedObjects), 0, "Expected no converted objects", t)
+}
+
+func TestConvertControlPlanesRejectsInvalidRequest(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewControlPlaneConverter().ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/convert-smcp", bytes.NewBufferString("{}")))
+	assert.Equals(recorder.Code, http.StatusBadRequest, "Expected a review without a request to be rejected", t)
+}
+
+// postConversionReview posts a review requesting the conversion of the object
+// to the converter and returns the review it responds with.  The request is
+// added to the returned review.
+func postConversionReview(t *testing.T, desiredAPIVersion string, obj runtime.Object) *apiextensionsv1beta1.ConversionReview {
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	request := &apiextensionsv1beta1.ConversionRequest{
+		UID:               "review-uid",
+		DesiredAPIVersion: desiredAPIVersion,
+		Objects:           []runtime.RawExtension{{Raw: raw}},
+	}
+	body, err := json.Marshal(&apiextensionsv1beta1.ConversionReview{Request: request})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	NewControlPlaneConverter().ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/convert-smcp", bytes.NewBuffer(body)))
+	assert.Equals(recorder.Code, http.StatusOK, "Unexpected status code", t)
+
+	review := &apiextensionsv1beta1.ConversionReview{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), review); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if review.Response == nil {
+		t.Fatalf("expected a response")
+	}
+	review.Request = request
+	return review
+}
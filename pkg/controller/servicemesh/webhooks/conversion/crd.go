@@ -0,0 +1,125 @@
+package conversion
+
+import (
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"path"
+	"reflect"
+	"time"
+
+	"github.com/pkg/errors"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const (
+	// ControlPlaneConversionPath is the path of the conversion webhook on the
+	// webhook server
+	ControlPlaneConversionPath = "/convert-smcp"
+
+	controlPlaneCRDName = "servicemeshcontrolplanes.maistra.io"
+	// caCertFile is the file to which the webhook server writes its CA
+	// certificate
+	caCertFile = "ca-cert.pem"
+)
+
+var (
+	// initialSyncInterval is the interval at which the CRD is checked until
+	// it has been configured, i.e. until the webhook server has written its
+	// certificates
+	initialSyncInterval = 5 * time.Second
+	// syncInterval is the interval at which the CRD is checked afterwards
+	syncInterval = time.Minute
+)
+
+// CRDConfigurer points the conversion webhook of the ServiceMeshControlPlane
+// CustomResourceDefinition at the operator's webhook server.  The CRD cannot
+// specify the CA bundle of the webhook server, whose certificates are
+// generated when the server starts, nor the namespace of its service, which
+// depends on where the operator is installed, so both are set by the operator.
+type CRDConfigurer struct {
+	client      client.Client
+	certDir     string
+	serviceName string
+	namespace   string
+}
+
+var _ manager.Runnable = (*CRDConfigurer)(nil)
+
+// NewCRDConfigurer returns a CRDConfigurer for the webhook server serving
+// from the specified service and writing its certificates to certDir
+func NewCRDConfigurer(cl client.Client, certDir, serviceName, namespace string) *CRDConfigurer {
+	return &CRDConfigurer{
+		client:      cl,
+		certDir:     certDir,
+		serviceName: serviceName,
+		namespace:   namespace,
+	}
+}
+
+// Start configures the CRD once the certificates have been written and keeps
+// it configured, e.g. if the CRD is replaced when the operator is upgraded.
+func (c *CRDConfigurer) Start(stop <-chan struct{}) error {
+	ctx := context.Background()
+	err := wait.PollImmediateUntil(initialSyncInterval, func() (bool, error) {
+		if err := c.configure(ctx); err != nil {
+			log.V(2).Info("conversion webhook not configured yet", "error", err.Error())
+			return false, nil
+		}
+		return true, nil
+	}, stop)
+	if err != nil {
+		// stopped
+		return nil
+	}
+	wait.Until(func() {
+		if err := c.configure(ctx); err != nil {
+			log.Error(err, "error configuring conversion webhook")
+		}
+	}, syncInterval, stop)
+	return nil
+}
+
+// configure updates the conversion of the CRD if it does not use the
+// webhook server.  The CRD is updated as unstructured object, so fields not
+// known to the vendored API, e.g. preserveUnknownFields, are retained.
+func (c *CRDConfigurer) configure(ctx context.Context) error {
+	caBundle, err := ioutil.ReadFile(path.Join(c.certDir, caCertFile))
+	if err != nil {
+		return errors.Wrap(err, "could not read CA certificate of the webhook server")
+	}
+
+	crd := &unstructured.Unstructured{}
+	crd.SetGroupVersionKind(apiextensionsv1beta1.SchemeGroupVersion.WithKind("CustomResourceDefinition"))
+	if err := c.client.Get(ctx, client.ObjectKey{Name: controlPlaneCRDName}, crd); err != nil {
+		return errors.Wrapf(err, "could not get CustomResourceDefinition %s", controlPlaneCRDName)
+	}
+
+	conversion := map[string]interface{}{
+		"strategy": string(apiextensionsv1beta1.WebhookConverter),
+		"webhookClientConfig": map[string]interface{}{
+			"caBundle": base64.StdEncoding.EncodeToString(caBundle),
+			"service": map[string]interface{}{
+				"name":      c.serviceName,
+				"namespace": c.namespace,
+				"path":      ControlPlaneConversionPath,
+			},
+		},
+	}
+	current, _, _ := unstructured.NestedMap(crd.Object, "spec", "conversion")
+	if reflect.DeepEqual(current, conversion) {
+		return nil
+	}
+	if err := unstructured.SetNestedMap(crd.Object, conversion, "spec", "conversion"); err != nil {
+		return err
+	}
+	if err := c.client.Update(ctx, crd); err != nil {
+		return errors.Wrapf(err, "could not update conversion of CustomResourceDefinition %s", controlPlaneCRDName)
+	}
+	log.Info("configured conversion webhook", "CustomResourceDefinition", controlPlaneCRDName)
+	return nil
+}
@@ -0,0 +1,55 @@
+package conversion
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/maistra/istio-operator/pkg/controller/common/test"
+	"github.com/maistra/istio-operator/pkg/controller/common/test/assert"
+)
+
+func TestCRDConfigurer(t *testing.T) {
+	certDir, err := ioutil.TempDir("", "cert")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(certDir)
+
+	crd := &apiextensionsv1beta1.CustomResourceDefinition{
+		// the CRD is read as unstructured object, which requires the kind
+		TypeMeta:   metav1.TypeMeta{APIVersion: apiextensionsv1beta1.SchemeGroupVersion.String(), Kind: "CustomResourceDefinition"},
+		ObjectMeta: metav1.ObjectMeta{Name: controlPlaneCRDName},
+		Spec: apiextensionsv1beta1.CustomResourceDefinitionSpec{
+			Group: "maistra.io",
+			Conversion: &apiextensionsv1beta1.CustomResourceConversion{
+				Strategy: apiextensionsv1beta1.WebhookConverter,
+			},
+		},
+	}
+	cl, _ := test.CreateClient(crd)
+	configurer := NewCRDConfigurer(cl, certDir, "admission-controller", "custom-operator-namespace")
+	ctx := context.Background()
+
+	assert.True(configurer.configure(ctx) != nil, "Expected an error before the webhook server has written its certificates", t)
+
+	if err := ioutil.WriteFile(path.Join(certDir, caCertFile), []byte("CABundle"), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := configurer.configure(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	updated := test.GetUpdatedObject(ctx, cl, crd.ObjectMeta, &apiextensionsv1beta1.CustomResourceDefinition{}).(*apiextensionsv1beta1.CustomResourceDefinition)
+	assert.Equals(updated.Spec.Group, "maistra.io", "Expected other fields of the CRD to be retained", t)
+	clientConfig := updated.Spec.Conversion.WebhookClientConfig
+	assert.DeepEquals(clientConfig.CABundle, []byte("CABundle"), "Unexpected CA bundle", t)
+	assert.Equals(clientConfig.Service.Namespace, "custom-operator-namespace", "Unexpected service namespace", t)
+	assert.Equals(clientConfig.Service.Name, "admission-controller", "Unexpected service name", t)
+	assert.Equals(*clientConfig.Service.Path, ControlPlaneConversionPath, "Unexpected service path", t)
+}
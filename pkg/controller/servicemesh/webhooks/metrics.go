@@ -0,0 +1,80 @@
+package webhooks
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	atypes "sigs.k8s.io/controller-runtime/pkg/webhook/admission/types"
+)
+
+var (
+	webhookRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "maistra_operator_webhook_request_duration_seconds",
+			Help:    "Duration of the admission requests handled by a webhook",
+			Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5},
+		},
+		[]string{"webhook", "operation"},
+	)
+	webhookRejections = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "maistra_operator_webhook_rejections_total",
+			Help: "Number of admission requests rejected by a webhook",
+		},
+		[]string{"webhook", "operation"},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(webhookRequestDuration, webhookRejections)
+}
+
+// instrumentedHandler records the duration of the requests handled by an
+// admission handler and counts the requests it rejects
+type instrumentedHandler struct {
+	webhook string
+	handler admission.Handler
+}
+
+var (
+	_ admission.Handler = (*instrumentedHandler)(nil)
+	_ inject.Client     = (*instrumentedHandler)(nil)
+	_ inject.Decoder    = (*instrumentedHandler)(nil)
+)
+
+// instrument returns a handler reporting the metrics of handler, labeled with
+// the name of the webhook
+func instrument(webhook string, handler admission.Handler) admission.Handler {
+	return &instrumentedHandler{webhook: webhook, handler: handler}
+}
+
+func (h *instrumentedHandler) Handle(ctx context.Context, req atypes.Request) atypes.Response {
+	start := time.Now()
+	resp := h.handler.Handle(ctx, req)
+	operation := ""
+	if req.AdmissionRequest != nil {
+		operation = string(req.AdmissionRequest.Operation)
+	}
+	webhookRequestDuration.WithLabelValues(h.webhook, operation).Observe(time.Since(start).Seconds())
+	if resp.Response != nil && !resp.Response.Allowed {
+		webhookRejections.WithLabelValues(h.webhook, operation).Inc()
+	}
+	return resp
+}
+
+// InjectClient injects the client into the instrumented handler
+func (h *instrumentedHandler) InjectClient(c client.Client) error {
+	_, err := inject.ClientInto(c, h.handler)
+	return err
+}
+
+// InjectDecoder injects the decoder into the instrumented handler
+func (h *instrumentedHandler) InjectDecoder(d atypes.Decoder) error {
+	_, err := inject.DecoderInto(d, h.handler)
+	return err
+}
@@ -0,0 +1,81 @@
+package webhooks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	atypes "sigs.k8s.io/controller-runtime/pkg/webhook/admission/types"
+
+	"github.com/maistra/istio-operator/pkg/controller/common/test"
+)
+
+func TestInstrumentedHandlerCountsRejections(t *testing.T) {
+	testCases := []struct {
+		name            string
+		allowed         bool
+		expectRejection bool
+	}{
+		{
+			name:    "allowed",
+			allowed: true,
+		},
+		{
+			name:            "rejected",
+			allowed:         false,
+			expectRejection: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			webhook := "test-" + tc.name
+			handler := instrument(webhook, admission.HandlerFunc(func(_ context.Context, _ atypes.Request) atypes.Response {
+				return admission.ValidationResponse(tc.allowed, "")
+			}))
+			handler.Handle(context.TODO(), atypes.Request{AdmissionRequest: &admissionv1beta1.AdmissionRequest{Operation: admissionv1beta1.Create}})
+
+			metric := &dto.Metric{}
+			if err := webhookRequestDuration.WithLabelValues(webhook, "CREATE").(prometheus.Histogram).Write(metric); err != nil {
+				t.Fatalf("unexpected error reading metric: %v", err)
+			}
+			if metric.GetHistogram().GetSampleCount() != 1 {
+				t.Errorf("expected the duration of the request to be recorded")
+			}
+			if err := webhookRejections.WithLabelValues(webhook, "CREATE").Write(metric); err != nil {
+				t.Fatalf("unexpected error reading metric: %v", err)
+			}
+			if rejected := metric.GetCounter().GetValue() == 1; rejected != tc.expectRejection {
+				t.Errorf("expected rejection to be counted: %t", tc.expectRejection)
+			}
+		})
+	}
+}
+
+func TestInstrumentedHandlerInjectsClient(t *testing.T) {
+	handler := &clientRecordingHandler{}
+	cl, _ := test.CreateClient()
+	if _, err := inject.ClientInto(cl, instrument("test", handler)); err != nil {
+		t.Fatalf("unexpected error injecting client: %v", err)
+	}
+	if handler.client != cl {
+		t.Errorf("expected client to be injected into the instrumented handler")
+	}
+}
+
+type clientRecordingHandler struct {
+	client client.Client
+}
+
+func (h *clientRecordingHandler) Handle(_ context.Context, _ atypes.Request) atypes.Response {
+	return admission.ValidationResponse(true, "")
+}
+
+func (h *clientRecordingHandler) InjectClient(c client.Client) error {
+	h.client = c
+	return nil
+}
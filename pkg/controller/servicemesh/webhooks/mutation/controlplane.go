@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/go-logr/logr"
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
@@ -14,6 +17,7 @@ import (
 
 	"github.com/maistra/istio-operator/pkg/apis/maistra"
 	maistrav1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+	maistrav2 "github.com/maistra/istio-operator/pkg/apis/maistra/v2"
 	webhookcommon "github.com/maistra/istio-operator/pkg/controller/servicemesh/webhooks/common"
 )
 
@@ -36,45 +40,62 @@ var _ inject.Decoder = (*ControlPlaneMutator)(nil)
 func (v *ControlPlaneMutator) Handle(ctx context.Context, req atypes.Request) atypes.Response {
 	log := logf.Log.WithName("smcp-mutator").
 		WithValues("ServiceMeshControlPlane", webhookcommon.ToNamespacedName(req.AdmissionRequest))
-	smcp := &maistrav1.ServiceMeshControlPlane{}
 
-	err := v.decoder.Decode(req, smcp)
-	if err != nil {
+	// the defaulted fields are the same in v1 and v2
+	if req.AdmissionRequest.Kind.Version == maistrav2.SchemeGroupVersion.Version {
+		smcp := &maistrav2.ServiceMeshControlPlane{}
+		if err := v.decoder.Decode(req, smcp); err != nil {
+			log.Error(err, "error decoding admission request")
+			return admission.ErrorResponse(http.StatusBadRequest, err)
+		}
+		newSmcp := smcp.DeepCopy()
+		return v.mutate(req, smcp, newSmcp, &newSmcp.ObjectMeta, &newSmcp.Spec.Version, &newSmcp.Spec.Template, newSmcp.Spec.Templates, log)
+	}
+
+	smcp := &maistrav1.ServiceMeshControlPlane{}
+	if err := v.decoder.Decode(req, smcp); err != nil {
 		log.Error(err, "error decoding admission request")
 		return admission.ErrorResponse(http.StatusBadRequest, err)
-	} else if smcp.ObjectMeta.DeletionTimestamp != nil {
+	}
+	newSmcp := smcp.DeepCopy()
+	return v.mutate(req, smcp, newSmcp, &newSmcp.ObjectMeta, &newSmcp.Spec.Version, &newSmcp.Spec.Template, newSmcp.Spec.Templates, log)
+}
+
+// mutate sets the defaults of newSmcp, a copy of smcp, through its metadata,
+// version and template, and returns a patch for the changes.  The template is
+// not defaulted if the control plane lists its templates.
+func (v *ControlPlaneMutator) mutate(req atypes.Request, smcp, newSmcp runtime.Object, meta *metav1.ObjectMeta, version, template *string, templates []string, log logr.Logger) atypes.Response {
+	if meta.DeletionTimestamp != nil {
 		log.Info("skipping deleted smcp resource")
 		return admission.ValidationResponse(true, "")
 	}
 
 	// do we care about this object?
-	if !v.namespaceFilter.Watching(smcp.Namespace) {
-		log.Info(fmt.Sprintf("operator is not watching namespace '%s'", smcp.Namespace))
+	if !v.namespaceFilter.Watching(meta.Namespace) {
+		log.Info(fmt.Sprintf("operator is not watching namespace '%s'", meta.Namespace))
 		return admission.ValidationResponse(true, "")
 	}
 
-	newSmcp := smcp.DeepCopy()
 	smcpMutated := false
 
 	// on create we set the version to the current default version
 	// on update we leave the version intact to preserve the v1.0 version
 	// implied by the missing version field, which we added in version v1.1
-	if smcp.Spec.Version == "" {
+	if *version == "" {
 		switch req.AdmissionRequest.Operation {
 		case admissionv1beta1.Create:
 			log.Info("Setting .spec.version to default value", "version", maistra.DefaultVersion.String())
-			newSmcp.Spec.Version = maistra.DefaultVersion.String()
+			*version = maistra.DefaultVersion.String()
 			smcpMutated = true
 		case admissionv1beta1.Update:
-			log.Info("Setting .spec.version to default value", "version", smcp.Spec.Version)
-			newSmcp.Spec.Version = smcp.Spec.Version
+			log.Info("Setting .spec.version to default value", "version", *version)
 			smcpMutated = true
 		}
 	}
 
-	if smcp.Spec.Template == "" {
+	if *template == "" && len(templates) == 0 {
 		log.Info("Setting .spec.template to default value", "template", maistrav1.DefaultTemplate)
-		newSmcp.Spec.Template = maistrav1.DefaultTemplate
+		*template = maistrav1.DefaultTemplate
 		smcpMutated = true
 	}
 
@@ -2,8 +2,10 @@ package mutation
 
 import (
 	"fmt"
+	"sort"
 	"testing"
 
+	"github.com/appscode/jsonpatch"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -11,6 +13,7 @@ import (
 
 	"github.com/maistra/istio-operator/pkg/apis/maistra"
 	maistrav1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+	maistrav2 "github.com/maistra/istio-operator/pkg/apis/maistra/v2"
 	"github.com/maistra/istio-operator/pkg/controller/common/test"
 	"github.com/maistra/istio-operator/pkg/controller/common/test/assert"
 )
@@ -128,6 +131,51 @@ func TestTemplateIsDefaultedOnUpdate(t *testing.T) {
 	assert.DeepEquals(response, expectedResponse, "Expected the response to set the template on update", t)
 }
 
+func TestTemplateIsNotDefaultedWithTemplateList(t *testing.T) {
+	controlPlane := newControlPlane("my-smcp", "istio-system")
+	controlPlane.Spec.Template = ""
+	controlPlane.Spec.Templates = []string{"small", "ingress-only"}
+
+	mutator, _, _ := createControlPlaneMutatorTestFixture()
+	response := mutator.Handle(ctx, newCreateRequest(controlPlane))
+	assert.DeepEquals(response, webhookadmission.ValidationResponse(true, ""), "Expected the response not to set the template when templates are listed", t)
+}
+
+func TestV2ControlPlaneIsDefaultedOnCreate(t *testing.T) {
+	controlPlane := &maistrav2.ServiceMeshControlPlane{
+		TypeMeta: meta.TypeMeta{
+			APIVersion: maistrav2.SchemeGroupVersion.String(),
+			Kind:       "ServiceMeshControlPlane",
+		},
+		ObjectMeta: meta.ObjectMeta{
+			Name:      "my-smcp",
+			Namespace: "istio-system",
+		},
+		Spec: maistrav2.ControlPlaneSpec{
+			Tracing: &maistrav2.TracingConfig{Type: maistrav2.TracerTypeJaeger},
+		},
+	}
+
+	mutatedControlPlane := controlPlane.DeepCopy()
+	mutatedControlPlane.Spec.Version = maistra.DefaultVersion.String()
+	mutatedControlPlane.Spec.Template = maistrav1.DefaultTemplate
+
+	mutator, _, _ := createControlPlaneMutatorTestFixture()
+	request := newCreateRequest(controlPlane)
+	request.AdmissionRequest.Kind = meta.GroupVersionKind{
+		Group:   maistrav2.SchemeGroupVersion.Group,
+		Version: maistrav2.SchemeGroupVersion.Version,
+		Kind:    "ServiceMeshControlPlane",
+	}
+	response := mutator.Handle(ctx, request)
+	expectedResponse := webhookadmission.PatchResponse(controlPlane, mutatedControlPlane)
+	// the order of the patches is not defined
+	for _, patches := range [][]jsonpatch.Operation{response.Patches, expectedResponse.Patches} {
+		sort.Slice(patches, func(i, j int) bool { return patches[i].Path < patches[j].Path })
+	}
+	assert.DeepEquals(response, expectedResponse, "Expected the response to set the version and template of the v2 resource", t)
+}
+
 func createControlPlaneMutatorTestFixture(clientObjects ...runtime.Object) (*ControlPlaneMutator, client.Client, *test.EnhancedTracker) {
 	cl, tracker := test.CreateClient(clientObjects...)
 	decoder, err := webhookadmission.NewDecoder(test.GetScheme())
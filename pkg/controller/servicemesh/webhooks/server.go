@@ -7,10 +7,12 @@ import (
 
 	"github.com/maistra/istio-operator/pkg/controller/common"
 	webhookcommon "github.com/maistra/istio-operator/pkg/controller/servicemesh/webhooks/common"
+	"github.com/maistra/istio-operator/pkg/controller/servicemesh/webhooks/conversion"
 	"github.com/maistra/istio-operator/pkg/controller/servicemesh/webhooks/mutation"
 	"github.com/maistra/istio-operator/pkg/controller/servicemesh/webhooks/validation"
 
 	maistrav1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+	maistrav2 "github.com/maistra/istio-operator/pkg/apis/maistra/v2"
 
 	arbeta1 "k8s.io/api/admissionregistration/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -20,7 +22,11 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook/types"
 )
 
-const componentName = "servicemesh-webhook-server"
+const (
+	componentName = "servicemesh-webhook-server"
+	serviceName   = "admission-controller"
+	certDir       = "/tmp/cert"
+)
 
 var log = logf.Log.WithName(componentName)
 
@@ -33,12 +39,12 @@ func Add(mgr manager.Manager) error {
 		mgr,
 		webhook.ServerOptions{
 			Port:    11999,
-			CertDir: "/tmp/cert",
+			CertDir: certDir,
 			BootstrapOptions: &webhook.BootstrapOptions{
 				ValidatingWebhookConfigName: fmt.Sprintf("%s.servicemesh-resources.maistra.io", operatorNamespace),
 				MutatingWebhookConfigName:   fmt.Sprintf("%s.servicemesh-resources.maistra.io", operatorNamespace),
 				Service: &webhook.Service{
-					Name:      "admission-controller",
+					Name:      serviceName,
 					Namespace: operatorNamespace,
 					Selectors: map[string]string{
 						"name": "istio-operator",
@@ -56,26 +62,32 @@ func Add(mgr manager.Manager) error {
 	}
 	namespaceFilter := webhookcommon.NamespaceFilter(watchNamespaceStr)
 
+	log.Info("Registering conversion webhook to the webhook server")
+	hookServer.Handle(conversion.ControlPlaneConversionPath, conversion.NewControlPlaneConverter())
+	if err := mgr.Add(conversion.NewCRDConfigurer(mgr.GetClient(), certDir, serviceName, operatorNamespace)); err != nil {
+		return err
+	}
+
 	log.Info("Registering webhooks to the webhook server")
 	failurePolicy := arbeta1.Fail
-	return hookServer.Register(
-		&admission.Webhook{
+	admissionWebhooks := []*admission.Webhook{
+		{
 			Name:          "smcp.validation.maistra.io",
 			Path:          "/validate-smcp",
-			Rules:         rulesFor("servicemeshcontrolplanes", arbeta1.Create, arbeta1.Update),
+			Rules:         controlPlaneRulesFor(arbeta1.Create, arbeta1.Update, arbeta1.Delete),
 			FailurePolicy: &failurePolicy,
 			Type:          types.WebhookTypeValidating,
 			Handlers:      []admission.Handler{validation.NewControlPlaneValidator(namespaceFilter)},
 		},
-		&admission.Webhook{
+		{
 			Name:          "smcp.mutation.maistra.io",
 			Path:          "/mutate-smcp",
-			Rules:         rulesFor("servicemeshcontrolplanes", arbeta1.Create, arbeta1.Update),
+			Rules:         controlPlaneRulesFor(arbeta1.Create, arbeta1.Update),
 			FailurePolicy: &failurePolicy,
 			Type:          types.WebhookTypeMutating,
 			Handlers:      []admission.Handler{mutation.NewControlPlaneMutator(namespaceFilter)},
 		},
-		&admission.Webhook{
+		{
 			Name:          "smmr.validation.maistra.io",
 			Path:          "/validate-smmr",
 			Rules:         rulesFor("servicemeshmemberrolls", arbeta1.Create, arbeta1.Update),
@@ -83,7 +95,7 @@ func Add(mgr manager.Manager) error {
 			Type:          types.WebhookTypeValidating,
 			Handlers:      []admission.Handler{validation.NewMemberRollValidator(namespaceFilter)},
 		},
-		&admission.Webhook{
+		{
 			Name:          "smmr.mutation.maistra.io",
 			Path:          "/mutate-smmr",
 			Rules:         rulesFor("servicemeshmemberrolls", arbeta1.Create, arbeta1.Update),
@@ -91,7 +103,7 @@ func Add(mgr manager.Manager) error {
 			Type:          types.WebhookTypeMutating,
 			Handlers:      []admission.Handler{mutation.NewMemberRollMutator(namespaceFilter)},
 		},
-		&admission.Webhook{
+		{
 			Name:          "smm.validation.maistra.io",
 			Path:          "/validate-smm",
 			Rules:         rulesFor("servicemeshmembers", arbeta1.Create, arbeta1.Update),
@@ -99,15 +111,34 @@ func Add(mgr manager.Manager) error {
 			Type:          types.WebhookTypeValidating,
 			Handlers:      []admission.Handler{validation.NewMemberValidator()},
 		},
-	)
+	}
+	webhooks := make([]webhook.Webhook, 0, len(admissionWebhooks))
+	for _, admissionWebhook := range admissionWebhooks {
+		for index, handler := range admissionWebhook.Handlers {
+			admissionWebhook.Handlers[index] = instrument(admissionWebhook.Name, handler)
+		}
+		webhooks = append(webhooks, admissionWebhook)
+	}
+	return hookServer.Register(webhooks...)
 }
 
 func rulesFor(resource string, operations ...arbeta1.OperationType) []arbeta1.RuleWithOperations {
+	return rulesForVersions(resource, []string{maistrav1.SchemeGroupVersion.Version}, operations...)
+}
+
+// controlPlaneRulesFor returns the rules for ServiceMeshControlPlanes of all
+// versions, as requests for v2 resources are not sent to webhooks registered
+// for v1
+func controlPlaneRulesFor(operations ...arbeta1.OperationType) []arbeta1.RuleWithOperations {
+	return rulesForVersions("servicemeshcontrolplanes", []string{maistrav1.SchemeGroupVersion.Version, maistrav2.SchemeGroupVersion.Version}, operations...)
+}
+
+func rulesForVersions(resource string, versions []string, operations ...arbeta1.OperationType) []arbeta1.RuleWithOperations {
 	return []arbeta1.RuleWithOperations{
 		{
 			Rule: arbeta1.Rule{
 				APIGroups:   []string{maistrav1.SchemeGroupVersion.Group},
-				APIVersions: []string{maistrav1.SchemeGroupVersion.Version},
+				APIVersions: versions,
 				Resources:   []string{resource},
 			},
 			Operations: operations,
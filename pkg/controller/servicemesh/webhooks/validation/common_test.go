@@ -19,6 +19,12 @@ import (
 
 var ctx = common.NewContextWithLog(context.Background(), logf.Log)
 
+func init() {
+	// version descriptors are loaded from the charts in the repository
+	common.Options.ChartsDir = "../../../../../resources/helm"
+	common.Options.DefaultTemplatesDir = "../../../../../resources/smcp-templates"
+}
+
 var userInfo = authentication.UserInfo{
 	Username: "joe-user",
 	UID:      "some-UID",
@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"reflect"
 
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
@@ -15,8 +17,9 @@ import (
 	atypes "sigs.k8s.io/controller-runtime/pkg/webhook/admission/types"
 
 	"github.com/go-logr/logr"
-	"github.com/maistra/istio-operator/pkg/apis/maistra"
+	"github.com/maistra/istio-operator/pkg/apis/maistra/conversion"
 	maistrav1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+	maistrav2 "github.com/maistra/istio-operator/pkg/apis/maistra/v2"
 	"github.com/maistra/istio-operator/pkg/controller/common"
 	webhookcommon "github.com/maistra/istio-operator/pkg/controller/servicemesh/webhooks/common"
 )
@@ -40,9 +43,11 @@ var _ inject.Decoder = (*ControlPlaneValidator)(nil)
 func (v *ControlPlaneValidator) Handle(ctx context.Context, req atypes.Request) atypes.Response {
 	logger := logf.Log.WithName("smcp-validator").
 		WithValues("ServiceMeshControlPlane", webhookcommon.ToNamespacedName(req.AdmissionRequest))
-	smcp := &maistrav1.ServiceMeshControlPlane{}
+	if req.AdmissionRequest.Operation == admissionv1beta1.Delete {
+		return v.validateDelete(ctx, req, logger)
+	}
 
-	err := v.decoder.Decode(req, smcp)
+	smcp, err := v.decodeControlPlane(req, req.AdmissionRequest.Object)
 	if err != nil {
 		logger.Error(err, "error decoding admission request")
 		return admission.ErrorResponse(http.StatusBadRequest, err)
@@ -57,12 +62,48 @@ func (v *ControlPlaneValidator) Handle(ctx context.Context, req atypes.Request)
 		return admission.ValidationResponse(true, "")
 	}
 
-	if version, err := maistra.ParseVersion(smcp.Spec.Version); err != nil {
-		return validationFailedResponse(http.StatusBadRequest, metav1.StatusReasonBadRequest, fmt.Sprintf("invalid Version specified; supported versions are: %v", maistra.GetSupportedVersions()))
-	} else if err := v.validateVersion(ctx, smcp, version); err != nil {
+	if _, err := parseControlPlaneVersion(smcp.Spec.Version); err != nil {
+		return validationFailedResponse(http.StatusBadRequest, metav1.StatusReasonBadRequest, fmt.Sprintf("invalid Version specified: %v", err))
+	} else if err := v.validateVersion(ctx, smcp); err != nil {
 		return validationFailedResponse(http.StatusBadRequest, metav1.StatusReasonBadRequest, err.Error())
 	}
 
+	var oldsmcp *maistrav1.ServiceMeshControlPlane
+	if req.AdmissionRequest.Operation == admissionv1beta1.Update {
+		oldsmcp, err = v.decodeControlPlane(req, req.AdmissionRequest.OldObject)
+		if err != nil {
+			logger.Error(err, "error decoding admission request")
+			return admission.ErrorResponse(http.StatusBadRequest, err)
+		}
+	}
+
+	// spec.istio is only validated when it changes, so control planes that
+	// were accepted with unknown values can still be updated, e.g. when the
+	// operator adds a finalizer
+	if oldsmcp == nil || !reflect.DeepEqual(oldsmcp.Spec.Istio, smcp.Spec.Istio) {
+		if err := validateIstioValues(smcp.Spec.Istio); err != nil {
+			return validationFailedResponse(http.StatusBadRequest, metav1.StatusReasonBadRequest, fmt.Sprintf("invalid spec.istio: %v", err))
+		}
+	}
+
+	if smcp.Spec.Template != "" && len(smcp.Spec.Templates) > 0 {
+		return validationFailedResponse(http.StatusBadRequest, metav1.StatusReasonBadRequest, "only one of spec.template and spec.templates may be set; list all templates in spec.templates")
+	}
+
+	for index, overlay := range smcp.Spec.Overlays {
+		if err := common.ValidateOverlay(overlay); err != nil {
+			return validationFailedResponse(http.StatusBadRequest, metav1.StatusReasonBadRequest, fmt.Sprintf("invalid spec.overlays[%d]: %v", index, err))
+		}
+	}
+
+	if err := validateDeletionPolicy(smcp.Spec.DeletionPolicy); err != nil {
+		return validationFailedResponse(http.StatusBadRequest, metav1.StatusReasonBadRequest, fmt.Sprintf("invalid spec.deletionPolicy: %v", err))
+	}
+
+	if err := validateUpgradePolicy(smcp.Spec.UpgradePolicy); err != nil {
+		return validationFailedResponse(http.StatusBadRequest, metav1.StatusReasonBadRequest, fmt.Sprintf("invalid spec.upgradePolicy: %v", err))
+	}
+
 	smcpList := &maistrav1.ServiceMeshControlPlaneList{}
 	err = v.client.List(ctx, nil, smcpList)
 	if err != nil {
@@ -81,38 +122,61 @@ func (v *ControlPlaneValidator) Handle(ctx context.Context, req atypes.Request)
 		}
 	}
 
-	if req.AdmissionRequest.Operation == admissionv1beta1.Update {
+	if oldsmcp != nil {
 		// verify update
-		oldsmcp := &maistrav1.ServiceMeshControlPlane{}
-		err := v.decoder.DecodeRaw(req.AdmissionRequest.OldObject, oldsmcp)
-		if err != nil {
-			logger.Error(err, "error decoding admission request")
-			return admission.ErrorResponse(http.StatusBadRequest, err)
-		}
-
 		return v.validateUpdate(ctx, oldsmcp, smcp, logger)
 	}
 
 	return admission.ValidationResponse(true, "")
 }
 
-func (v *ControlPlaneValidator) validateVersion(ctx context.Context, smcp *maistrav1.ServiceMeshControlPlane, version maistra.Version) error {
-	var allErrors []error
-	// version specific validation
-	switch version.Version() {
-	// UndefinedVersion defaults to legacy v1.0
-	case maistra.V1_0, maistra.UndefinedVersion:
-		// no validation existed in 1.0, so we won't validate
-	case maistra.V1_1:
-		if err := v.validateV1_1(ctx, smcp); err != nil {
-			allErrors = append(allErrors, err)
+// decodeControlPlane decodes a control plane of the request.  v2 control
+// planes are converted to v1, so they are validated like v1 control planes.
+// An error is returned if the control plane specifies fields that are not
+// supported by its version.
+func (v *ControlPlaneValidator) decodeControlPlane(req atypes.Request, raw runtime.RawExtension) (*maistrav1.ServiceMeshControlPlane, error) {
+	smcp := &maistrav1.ServiceMeshControlPlane{}
+	if req.AdmissionRequest.Kind.Version != maistrav2.SchemeGroupVersion.Version {
+		err := v.decoder.DecodeRaw(raw, smcp)
+		return smcp, err
+	}
+	smcpv2 := &maistrav2.ServiceMeshControlPlane{}
+	if err := v.decoder.DecodeRaw(raw, smcpv2); err != nil {
+		return nil, err
+	}
+	if err := conversion.ConvertV2ToV1(smcpv2, smcp); err != nil {
+		return nil, fmt.Errorf("invalid ServiceMeshControlPlane: %v", err)
+	}
+	return smcp, nil
+}
+
+// validateDelete rejects the deletion of a control plane protected by the
+// maistra.io/deletion-protection annotation while the mesh has members
+func (v *ControlPlaneValidator) validateDelete(ctx context.Context, req atypes.Request, logger logr.Logger) atypes.Response {
+	if !v.namespaceFilter.Watching(req.AdmissionRequest.Namespace) {
+		return admission.ValidationResponse(true, "")
+	}
+	// the object being deleted is not included in the request
+	smcp := &maistrav1.ServiceMeshControlPlane{}
+	err := v.client.Get(ctx, client.ObjectKey{Namespace: req.AdmissionRequest.Namespace, Name: req.AdmissionRequest.Name}, smcp)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return admission.ValidationResponse(true, "")
 		}
-	case maistra.V1_2:
-		// TODO: any special validation
-	default:
-		allErrors = append(allErrors, fmt.Errorf("version %s is not supported", version.String()))
+		logger.Error(err, "error retrieving ServiceMeshControlPlane being deleted")
+		return admission.ErrorResponse(http.StatusInternalServerError, err)
+	}
+	if !common.HasDeletionProtection(smcp) {
+		return admission.ValidationResponse(true, "")
+	}
+	blockers, err := common.FindDeletionBlockers(ctx, v.client, smcp)
+	if err != nil {
+		logger.Error(err, "error checking for members of the mesh")
+		return admission.ErrorResponse(http.StatusInternalServerError, err)
+	} else if len(blockers) > 0 {
+		return validationFailedResponse(http.StatusForbidden, metav1.StatusReasonForbidden, common.DeletionBlockedMessage(smcp, blockers))
 	}
-	return utilerrors.NewAggregate(allErrors)
+	return admission.ValidationResponse(true, "")
 }
 
 func (v *ControlPlaneValidator) validateUpdate(ctx context.Context, old, new *maistrav1.ServiceMeshControlPlane, logger logr.Logger) atypes.Response {
@@ -120,71 +184,34 @@ func (v *ControlPlaneValidator) validateUpdate(ctx context.Context, old, new *ma
 		return admission.ValidationResponse(true, "")
 	}
 
-	oldVersion, err := maistra.ParseVersion(old.Spec.Version)
+	oldVersion, err := parseOldControlPlaneVersion(old.Spec.Version)
 	if err != nil {
 		logger.Error(err, "error parsing old resource version")
-		return admission.ErrorResponse(http.StatusInternalServerError, err)
+		return validationFailedResponse(http.StatusBadRequest, metav1.StatusReasonBadRequest, fmt.Sprintf("cannot change the version of the control plane: %s", err))
 	}
-	if oldVersion == maistra.UndefinedVersion {
-		// UndefinedVersion defaults to legacy v1.0
-		oldVersion = maistra.LegacyVersion
-	}
-	newVersion, err := maistra.ParseVersion(new.Spec.Version)
+	newVersion, err := parseControlPlaneVersion(new.Spec.Version)
 	if err != nil {
 		logger.Error(err, "error parsing new resource version")
 		return admission.ErrorResponse(http.StatusInternalServerError, err)
 	}
-	if newVersion == maistra.UndefinedVersion {
-		// UndefinedVersion defaults to legacy v1.0
-		newVersion = maistra.LegacyVersion
-	}
-
-	// The logic used here is that we only verify upgrade/downgrade between adjacent versions
-	// If an upgrade/downgrade spans multiple versions, the validation for upgrade/downgrade
-	// between adjacent versions is chained together, e.g. 1.0 -> 1.3, we'd verify
-	// upgrade from 1.0 -> 1.1, then 1.1 -> 1.2, then 1.2 -> 1.3.  If all of those
-	// were successful, validation succeeds.  This approach may breakdown if a feature
-	// was removed and subsequently reintroduced (e.g. validation from 1.0 -> 1.1
-	// fails because feature X is no longer supported, but was added back in 1.3).
-	if oldVersion.Version() < newVersion.Version() {
-		for version := oldVersion.Version(); version < newVersion.Version(); version++ {
-			if err := v.validateUpgrade(ctx, version, old); err != nil {
-				return validationFailedResponse(http.StatusBadRequest, metav1.StatusReasonBadRequest, fmt.Sprintf("cannot upgrade control plane from version %s to %s: %s", oldVersion.String(), newVersion.String(), err))
-			}
-		}
-	} else {
-		for version := oldVersion.Version(); version > newVersion.Version(); version-- {
-			if err := v.validateDowngrade(ctx, version, old); err != nil {
-				return validationFailedResponse(http.StatusBadRequest, metav1.StatusReasonBadRequest, fmt.Sprintf("cannot downgrade control plane from version %s to %s: %s", oldVersion.String(), newVersion.String(), err))
-			}
-		}
+	if oldVersion == newVersion {
+		// e.g. a different patch release of the same version
+		return admission.ValidationResponse(true, "")
 	}
 
-	return admission.ValidationResponse(true, "")
-}
-
-func (v *ControlPlaneValidator) validateUpgrade(ctx context.Context, currentVersion maistra.Version, smcp *maistrav1.ServiceMeshControlPlane) error {
-	switch currentVersion.Version() {
-	case maistra.V1_0:
-		return v.validateUpgradeFromV1_0(ctx, smcp)
-	case maistra.V1_1:
-		// TODO: any custom upgrade validation
-		return nil
-	default:
-		return fmt.Errorf("upgrade from version %s is not supported", currentVersion.String())
+	issues, err := v.checkVersionChange(ctx, old, oldVersion, newVersion)
+	if err == nil {
+		err = issuesError(issues)
 	}
-}
-
-func (v *ControlPlaneValidator) validateDowngrade(ctx context.Context, currentVersion maistra.Version, smcp *maistrav1.ServiceMeshControlPlane) error {
-	switch currentVersion.Version() {
-	case maistra.V1_1:
-		return v.validateDowngradeFromV1_1(ctx, smcp)
-	case maistra.V1_2:
-		// TODO: any custom downgrade validation
-		return nil
-	default:
-		return fmt.Errorf("upgrade from version %s is not supported", currentVersion.String())
+	if err != nil {
+		change := "upgrade"
+		if oldVersion.Version() > newVersion.Version() {
+			change = "downgrade"
+		}
+		return validationFailedResponse(http.StatusBadRequest, metav1.StatusReasonBadRequest, fmt.Sprintf("cannot %s control plane from version %s to %s: %s", change, oldVersion.String(), newVersion.String(), err))
 	}
+
+	return admission.ValidationResponse(true, "")
 }
 
 func (v *ControlPlaneValidator) getSMMR(smcp *maistrav1.ServiceMeshControlPlane) (*maistrav1.ServiceMeshMemberRoll, error) {
@@ -204,3 +231,30 @@ func (v *ControlPlaneValidator) InjectDecoder(d atypes.Decoder) error {
 	v.decoder = d
 	return nil
 }
+
+func validateDeletionPolicy(policy *maistrav1.DeletionPolicy) error {
+	if policy == nil {
+		return nil
+	}
+	if policy.Default != "" && !isValidDeletionPolicyType(policy.Default) {
+		return fmt.Errorf("unknown default policy %q, must be %q or %q", policy.Default, maistrav1.DeletionPolicyDelete, maistrav1.DeletionPolicyRetain)
+	}
+	for kind, policyType := range policy.Kinds {
+		if !isValidDeletionPolicyType(policyType) {
+			return fmt.Errorf("unknown policy %q for kind %s, must be %q or %q", policyType, kind, maistrav1.DeletionPolicyDelete, maistrav1.DeletionPolicyRetain)
+		}
+	}
+	return nil
+}
+
+func isValidDeletionPolicyType(policyType maistrav1.DeletionPolicyType) bool {
+	return policyType == maistrav1.DeletionPolicyDelete || policyType == maistrav1.DeletionPolicyRetain
+}
+
+func validateUpgradePolicy(policy maistrav1.UpgradePolicyType) error {
+	switch policy {
+	case "", maistrav1.UpgradePolicyManual, maistrav1.UpgradePolicyAutomaticPatch, maistrav1.UpgradePolicyAutomaticMinor:
+		return nil
+	}
+	return fmt.Errorf("unknown policy %q, must be %q, %q or %q", policy, maistrav1.UpgradePolicyManual, maistrav1.UpgradePolicyAutomaticPatch, maistrav1.UpgradePolicyAutomaticMinor)
+}
@@ -2,6 +2,7 @@ package validation
 
 import (
 	"fmt"
+	"net/http"
 	"strings"
 	"testing"
 
@@ -20,6 +21,7 @@ import (
 	securityv1beta1 "github.com/maistra/istio-operator/pkg/apis/istio/simple/security/v1beta1"
 	"github.com/maistra/istio-operator/pkg/apis/maistra"
 	maistrav1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+	maistrav2 "github.com/maistra/istio-operator/pkg/apis/maistra/v2"
 	"github.com/maistra/istio-operator/pkg/controller/common"
 	"github.com/maistra/istio-operator/pkg/controller/common/test"
 	"github.com/maistra/istio-operator/pkg/controller/common/test/assert"
@@ -58,6 +60,181 @@ func TestOnlyOneControlPlaneIsAllowedPerNamespace(t *testing.T) {
 	assert.False(response.Response.Allowed, "Expected validator to reject ServiceMeshControlPlane with bad version", t)
 }
 
+func TestControlPlaneOverlays(t *testing.T) {
+	validator, _, _ := createControlPlaneValidatorTestFixture()
+	controlPlane := newControlPlane("my-smcp", "istio-system")
+	controlPlane.Spec.Overlays = []maistrav1.ResourceOverlay{
+		{Kind: "Deployment", Name: "istio-pilot", Patch: "spec:\n  replicas: 2"},
+	}
+	response := validator.Handle(ctx, createCreateRequest(controlPlane))
+	assert.True(response.Response.Allowed, "Expected validator to accept valid overlay", t)
+
+	controlPlane.Spec.Overlays = append(controlPlane.Spec.Overlays, maistrav1.ResourceOverlay{
+		Kind: "Deployment", Name: "istio-pilot", Type: maistrav1.OverlayPatchTypeJSON, Patch: "spec:\n  replicas: 2",
+	})
+	response = validator.Handle(ctx, createCreateRequest(controlPlane))
+	assert.False(response.Response.Allowed, "Expected validator to reject invalid overlay", t)
+}
+
+func TestControlPlaneTemplates(t *testing.T) {
+	validator, _, _ := createControlPlaneValidatorTestFixture()
+	controlPlane := newControlPlane("my-smcp", "istio-system")
+	controlPlane.Spec.Template = ""
+	controlPlane.Spec.Templates = []string{"default", "small"}
+	response := validator.Handle(ctx, createCreateRequest(controlPlane))
+	assert.True(response.Response.Allowed, "Expected validator to accept list of templates", t)
+
+	controlPlane.Spec.Template = "default"
+	response = validator.Handle(ctx, createCreateRequest(controlPlane))
+	assert.False(response.Response.Allowed, "Expected validator to reject both template and list of templates", t)
+}
+
+func TestControlPlaneDeletionPolicy(t *testing.T) {
+	validator, _, _ := createControlPlaneValidatorTestFixture()
+	controlPlane := newControlPlane("my-smcp", "istio-system")
+	controlPlane.Spec.DeletionPolicy = &maistrav1.DeletionPolicy{
+		Kinds: map[string]maistrav1.DeletionPolicyType{"PersistentVolumeClaim": maistrav1.DeletionPolicyRetain},
+	}
+	response := validator.Handle(ctx, createCreateRequest(controlPlane))
+	assert.True(response.Response.Allowed, "Expected validator to accept valid deletion policy", t)
+
+	controlPlane.Spec.DeletionPolicy.Kinds["Secret"] = "Keep"
+	response = validator.Handle(ctx, createCreateRequest(controlPlane))
+	assert.False(response.Response.Allowed, "Expected validator to reject unknown deletion policy", t)
+}
+
+func TestControlPlaneUpgradePolicy(t *testing.T) {
+	validator, _, _ := createControlPlaneValidatorTestFixture()
+	controlPlane := newControlPlane("my-smcp", "istio-system")
+	controlPlane.Spec.UpgradePolicy = maistrav1.UpgradePolicyAutomaticPatch
+	response := validator.Handle(ctx, createCreateRequest(controlPlane))
+	assert.True(response.Response.Allowed, "Expected validator to accept valid upgrade policy", t)
+
+	controlPlane.Spec.UpgradePolicy = "Automatic"
+	response = validator.Handle(ctx, createCreateRequest(controlPlane))
+	assert.False(response.Response.Allowed, "Expected validator to reject unknown upgrade policy", t)
+}
+
+func TestV2ControlPlaneIsConvertedForValidation(t *testing.T) {
+	autoMTLS := true
+	testCases := []struct {
+		name    string
+		version string
+		allowed bool
+	}{
+		{
+			name:    "supported-field",
+			version: "v1.1",
+			allowed: true,
+		},
+		{
+			name:    "field-not-supported-by-version",
+			version: "v1.0",
+			allowed: false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			validator, _, _ := createControlPlaneValidatorTestFixture()
+			controlPlane := &maistrav2.ServiceMeshControlPlane{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: maistrav2.SchemeGroupVersion.String(),
+					Kind:       "ServiceMeshControlPlane",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "my-smcp",
+					Namespace: "istio-system",
+				},
+				Spec: maistrav2.ControlPlaneSpec{
+					Version: tc.version,
+					Security: &maistrav2.SecurityConfig{
+						DataPlane: &maistrav2.DataPlaneSecurityConfig{AutoMTLS: &autoMTLS},
+					},
+				},
+			}
+			request := createCreateRequest(controlPlane)
+			request.AdmissionRequest.Kind = metav1.GroupVersionKind{
+				Group:   maistrav2.SchemeGroupVersion.Group,
+				Version: maistrav2.SchemeGroupVersion.Version,
+				Kind:    "ServiceMeshControlPlane",
+			}
+			response := validator.Handle(ctx, request)
+			assert.Equals(response.Response.Allowed, tc.allowed, "Unexpected validation result", t)
+		})
+	}
+}
+
+func TestControlPlaneDeletionProtection(t *testing.T) {
+	testCases := []struct {
+		name             string
+		protected        bool
+		members          []string
+		sidecarNamespace string
+		allowed          bool
+	}{
+		{
+			name:    "unprotected",
+			members: []string{"app-namespace"},
+			allowed: true,
+		},
+		{
+			name:      "protected-without-members",
+			protected: true,
+			allowed:   true,
+		},
+		{
+			name:      "protected-with-members",
+			protected: true,
+			members:   []string{"app-namespace"},
+			allowed:   false,
+		},
+		{
+			name:             "protected-with-sidecars",
+			protected:        true,
+			sidecarNamespace: "app-namespace",
+			allowed:          false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			controlPlane := newControlPlane("my-smcp", "istio-system")
+			if tc.protected {
+				controlPlane.Annotations = map[string]string{common.DeletionProtectionKey: "true"}
+			}
+			memberRoll := newMemberRoll(common.MemberRollName, "istio-system", tc.members...)
+			memberRoll.Status.ConfiguredMembers = tc.members
+			objects := []runtime.Object{controlPlane, memberRoll}
+			if tc.sidecarNamespace != "" {
+				objects = append(objects,
+					&corev1.Namespace{
+						ObjectMeta: meta.ObjectMeta{
+							Name:   tc.sidecarNamespace,
+							Labels: map[string]string{common.MemberOfKey: "istio-system"},
+						},
+					},
+					&corev1.Pod{
+						ObjectMeta: meta.ObjectMeta{
+							Name:        "app",
+							Namespace:   tc.sidecarNamespace,
+							Annotations: map[string]string{"sidecar.istio.io/status": "{}"},
+						},
+					})
+			}
+			validator, _, _ := createControlPlaneValidatorTestFixture(objects...)
+
+			request := createDeleteRequest(controlPlane)
+			request.AdmissionRequest.Name = controlPlane.Name
+			request.AdmissionRequest.Namespace = controlPlane.Namespace
+			response := validator.Handle(ctx, request)
+			if tc.allowed {
+				assert.True(response.Response.Allowed, "Expected validator to allow deletion of ServiceMeshControlPlane", t)
+			} else {
+				assert.False(response.Response.Allowed, "Expected validator to reject deletion of ServiceMeshControlPlane", t)
+			}
+		})
+	}
+}
+
 func TestControlPlaneValidation(t *testing.T) {
 	cases := []struct {
 		name         string
@@ -348,6 +525,23 @@ func TestUpdateOfValidControlPlane(t *testing.T) {
 	assert.True(response.Response.Allowed, "Expected validator to accept update of valid ServiceMeshControlPlane", t)
 }
 
+func TestUnknownIstioValuesAreOnlyRejectedWhenChanged(t *testing.T) {
+	// accepted before spec.istio was validated strictly
+	oldControlPlane := newControlPlaneWithVersion("my-smcp", "istio-system", "v1.1")
+	setNestedField(oldControlPlane.Spec.Istio, "galley.autoscaleEnable", true)
+	validator, _, _ := createControlPlaneValidatorTestFixture(oldControlPlane)
+
+	controlPlane := oldControlPlane.DeepCopy()
+	controlPlane.Finalizers = []string{"istio-operator-ServiceMeshControlPlane"}
+	response := validator.Handle(ctx, createUpdateRequest(oldControlPlane, controlPlane))
+	assert.True(response.Response.Allowed, "Expected validator to accept update that does not modify spec.istio", t)
+
+	controlPlane = oldControlPlane.DeepCopy()
+	setNestedField(controlPlane.Spec.Istio, "galley.enabled", true)
+	response = validator.Handle(ctx, createUpdateRequest(oldControlPlane, controlPlane))
+	assert.False(response.Response.Allowed, "Expected validator to reject update of spec.istio containing unknown values", t)
+}
+
 func TestInvalidVersion(t *testing.T) {
 	validControlPlane := newControlPlaneWithVersion("my-smcp", "istio-system", "v1.0")
 	invalidControlPlane := newControlPlaneWithVersion("my-smcp", "istio-system", "InvalidVersion")
@@ -377,6 +571,82 @@ func TestInvalidVersion(t *testing.T) {
 	}
 }
 
+func TestVersionRanges(t *testing.T) {
+	cases := []struct {
+		version string
+		allowed bool
+	}{
+		{version: "v1.1.0", allowed: true},
+		{version: "~1.1", allowed: true},
+		{version: "v1.1.x", allowed: true},
+		{version: ">=1.0, <1.2", allowed: true},
+		{version: "v1.1.1", allowed: false},
+		{version: "~2.0", allowed: false},
+		{version: "latest", allowed: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.version, func(t *testing.T) {
+			validator, _, _ := createControlPlaneValidatorTestFixture()
+			response := validator.Handle(ctx, createCreateRequest(newControlPlaneWithVersion("my-smcp", "istio-system", tc.version)))
+			assert.Equals(response.Response.Allowed, tc.allowed, "Unexpected validation result for version", t)
+		})
+	}
+}
+
+func TestPatchReleaseChangeSkipsVersionChecks(t *testing.T) {
+	oldControlPlane := newControlPlaneWithVersion("my-smcp", "istio-system", "v1.0")
+	// rejected when downgrading from v1.1, see TestVersionDowngrade1_1To1_0
+	oldControlPlane.Spec.Istio = map[string]interface{}{}
+	setNestedField(oldControlPlane.Spec.Istio, "global.proxy.envoyAccessLogService.enabled", true)
+	validator, _, _ := createControlPlaneValidatorTestFixture(oldControlPlane)
+
+	controlPlane := oldControlPlane.DeepCopy()
+	controlPlane.Spec.Version = "~1.0"
+	response := validator.Handle(ctx, createUpdateRequest(oldControlPlane, controlPlane))
+	assert.True(response.Response.Allowed, "Expected validator to accept a version range resolving to the same version", t)
+}
+
+func TestChangeOfUnresolvableVersion(t *testing.T) {
+	cases := []struct {
+		name       string
+		oldVersion string
+		newVersion string
+		allowed    bool
+	}{
+		{
+			name:       "patch-release-not-shipped",
+			oldVersion: "v1.1.1",
+			newVersion: "v1.2",
+			allowed:    true,
+		},
+		{
+			name:       "range-not-shipped",
+			oldVersion: "~1.1.1",
+			newVersion: "v1.1",
+			allowed:    true,
+		},
+		{
+			name:       "unsupported-version",
+			oldVersion: "~2.0",
+			newVersion: "v1.2",
+			allowed:    false,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			oldControlPlane := newControlPlaneWithVersion("my-smcp", "istio-system", tc.oldVersion)
+			validator, _, _ := createControlPlaneValidatorTestFixture(oldControlPlane)
+			controlPlane := oldControlPlane.DeepCopy()
+			controlPlane.Spec.Version = tc.newVersion
+			response := validator.Handle(ctx, createUpdateRequest(oldControlPlane, controlPlane))
+			assert.Equals(response.Response.Allowed, tc.allowed, "Unexpected validation result", t)
+			if !tc.allowed {
+				assert.Equals(response.Response.Result.Code, int32(http.StatusBadRequest), "Expected the update to be rejected as a bad request", t)
+			}
+		})
+	}
+}
+
 func TestVersionValidation(t *testing.T) {
 	type subcase struct {
 		name      string
@@ -952,7 +1222,7 @@ func TestVersionDowngrade1_1To1_0(t *testing.T) {
 			},
 			allowed: true,
 		},
-{
+		{
 			name: "global.proxy.alwaysInjectSelector=true",
 			configure: func(smcp *maistrav1.ServiceMeshControlPlane) {
 				setNestedField(smcp.Spec.Istio, "global.proxy.alwaysInjectSelector", true)
@@ -1106,3 +1376,14 @@ func newControlPlane(name, namespace string) *maistrav1.ServiceMeshControlPlane
 func setNestedField(obj map[string]interface{}, path string, value interface{}) {
 	unstructured.SetNestedField(obj, value, strings.Split(path, ".")...)
 }
+
+func TestVersionDescriptorChecksAreImplemented(t *testing.T) {
+	if err := ValidateVersionDescriptors(); err != nil {
+		t.Fatalf("unexpected error validating version descriptors: %v", err)
+	}
+
+	check := versionChangeChecks["mixerResources"]
+	defer func() { versionChangeChecks["mixerResources"] = check }()
+	delete(versionChangeChecks, "mixerResources")
+	assert.True(ValidateVersionDescriptors() != nil, "Expected unknown check to be rejected", t)
+}
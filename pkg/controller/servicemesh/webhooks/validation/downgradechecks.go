@@ -0,0 +1,146 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+
+	pkgerrors "github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	networkingv1alpha3 "github.com/maistra/istio-operator/pkg/apis/istio/simple/networking/v1alpha3"
+	securityv1beta1 "github.com/maistra/istio-operator/pkg/apis/istio/simple/security/v1beta1"
+	maistrav1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+	"github.com/maistra/istio-operator/pkg/controller/common"
+)
+
+var (
+	// authorizationPolicyResources are the resources reported by the
+	// authorizationPolicies check
+	authorizationPolicyResources = []runtime.Object{
+		&securityv1beta1.AuthorizationPolicyList{},
+	}
+)
+
+// memberNamespaces returns the namespaces labeled as members of the mesh, and
+// the names of the mesh namespaces, i.e. the member namespaces and the
+// namespace of the control plane
+func (v *ControlPlaneValidator) memberNamespaces(ctx context.Context, smcp *maistrav1.ServiceMeshControlPlane) (*corev1.NamespaceList, sets.String, error) {
+	meshNamespaces := sets.NewString(smcp.GetNamespace())
+	memberNamespaces := &corev1.NamespaceList{}
+	if err := v.client.List(ctx, client.MatchingLabels(map[string]string{common.MemberOfKey: smcp.GetNamespace()}), memberNamespaces); err != nil {
+		return nil, nil, pkgerrors.Wrap(err, "error listing member namespaces")
+	}
+	for _, member := range memberNamespaces.Items {
+		meshNamespaces.Insert(member.GetName())
+	}
+	return memberNamespaces, meshNamespaces, nil
+}
+
+// checkCANamespaceLabels reports the member namespaces labeled with
+// ca.istio.io/env or ca.istio.io/override, which older versions do not
+// support
+func (v *ControlPlaneValidator) checkCANamespaceLabels(ctx context.Context, smcp *maistrav1.ServiceMeshControlPlane) ([]maistrav1.UpgradeCheckIssue, error) {
+	var issues []maistrav1.UpgradeCheckIssue
+	memberNamespaces, _, err := v.memberNamespaces(ctx, smcp)
+	if err != nil {
+		return nil, err
+	}
+	for index, member := range memberNamespaces.Items {
+		// ca.istio.io/env label exists on any member namespaces
+		if common.HasLabel(&member.ObjectMeta, "ca.istio.io/env") {
+			issues = append(issues, newUpgradeCheckIssue(&memberNamespaces.Items[index], "Namespace",
+				fmt.Sprintf("ca.istio.io/env label on namespace %s is not supported in older version", member.GetName()),
+				"remove the ca.istio.io/env label from the namespace"))
+		}
+		// ca.isio.io/override label exists on any member namespaces
+		if common.HasLabel(&member.ObjectMeta, "ca.istio.io/override") {
+			issues = append(issues, newUpgradeCheckIssue(&memberNamespaces.Items[index], "Namespace",
+				fmt.Sprintf("ca.istio.io/override label on namespace %s is not supported in older version", member.GetName()),
+				"remove the ca.istio.io/override label from the namespace"))
+		}
+	}
+	return issues, nil
+}
+
+// checkMirrorPercent reports the VirtualServices in the mesh with http
+// routes using mirrorPercent, which older versions do not support
+func (v *ControlPlaneValidator) checkMirrorPercent(ctx context.Context, smcp *maistrav1.ServiceMeshControlPlane) ([]maistrav1.UpgradeCheckIssue, error) {
+	var issues []maistrav1.UpgradeCheckIssue
+	_, meshNamespaces, err := v.memberNamespaces(ctx, smcp)
+	if err != nil {
+		return nil, err
+	}
+
+	// Any VirtualService http entries use mirrorPercent attribute
+	virtualServices := &networkingv1alpha3.VirtualServiceList{}
+	// XXX: do we list all in the cluster, or list for each member namespace?
+	if err := v.client.List(ctx, nil, virtualServices); err != nil {
+		if !meta.IsNoMatchError(err) && !errors.IsNotFound(err) {
+			return nil, pkgerrors.Wrapf(err, "error listing %T resources", virtualServices)
+		}
+	}
+	for index, vs := range virtualServices.Items {
+		// we only care about resources in this mesh, which aren't being managed by the operator directly
+		if meshNamespaces.Has(vs.GetNamespace()) && !metav1.IsControlledBy(&vs, smcp) {
+			if routes, ok, _ := unstructured.NestedSlice(vs.Spec, "http"); ok {
+				for _, route := range routes {
+					if routeStruct, ok := route.(map[string]interface{}); ok {
+						if _, ok, _ := unstructured.NestedFieldNoCopy(routeStruct, "mirrorPercent"); ok {
+							issues = append(issues, newUpgradeCheckIssue(&virtualServices.Items[index], "VirtualService",
+								fmt.Sprintf("http.mirrorPercent on VirtualService %s/%s is not supported on older version", vs.GetNamespace(), vs.GetName()),
+								"remove mirrorPercent from the http routes of the VirtualService; all mirrored requests are sent to the mirror in the older version"))
+							break
+						}
+					}
+				}
+			}
+		}
+	}
+	return issues, nil
+}
+
+// checkAuthorizationPolicies reports the AuthorizationPolicies in the mesh,
+// which older versions do not support
+func (v *ControlPlaneValidator) checkAuthorizationPolicies(ctx context.Context, smcp *maistrav1.ServiceMeshControlPlane) ([]maistrav1.UpgradeCheckIssue, error) {
+	var issues []maistrav1.UpgradeCheckIssue
+	var allErrors []error
+	_, meshNamespaces, err := v.memberNamespaces(ctx, smcp)
+	if err != nil {
+		return nil, err
+	}
+
+	// report any new resources that are being used
+	for _, list := range authorizationPolicyResources {
+		list = list.DeepCopyObject()
+		// XXX: do we list all in the cluster, or list for each member namespace?
+		if err := v.client.List(ctx, nil, list); err != nil {
+			if !meta.IsNoMatchError(err) && !errors.IsNotFound(err) {
+				return nil, pkgerrors.Wrapf(err, "error listing %T resources", list)
+			}
+		}
+		meta.EachListItem(list, func(obj runtime.Object) error {
+			metaObj, err := meta.Accessor(obj)
+			if err != nil {
+				allErrors = append(allErrors, pkgerrors.Wrapf(err, "error accessing object metadata for %s resource", obj.GetObjectKind().GroupVersionKind().String()))
+				return nil
+			}
+			// we only care about resources in this mesh, which aren't being managed by the operator directly
+			if meshNamespaces.Has(metaObj.GetNamespace()) && !metav1.IsControlledBy(metaObj, smcp) {
+				issues = append(issues, newUpgradeCheckIssue(metaObj, kindOf(obj),
+					fmt.Sprintf("%s/%s of type %s is not supported in older version", metaObj.GetNamespace(), metaObj.GetName(), obj.GetObjectKind().GroupVersionKind().String()),
+					"delete the resource and configure access control using ServiceRole and ServiceRoleBinding resources instead"))
+			}
+			return nil
+		})
+	}
+
+	return issues, utilerrors.NewAggregate(allErrors)
+}
@@ -0,0 +1,374 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/maistra/istio-operator/pkg/apis/maistra/openapi"
+	maistrav1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+	"github.com/maistra/istio-operator/pkg/controller/common"
+)
+
+// patchDirectiveKey identifies template merge directives, which are not part
+// of the values themselves
+const patchDirectiveKey = "$patch"
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	gatewaysConfigType  = reflect.TypeOf(maistrav1.GatewaysConfig{})
+)
+
+// validateIstioValues validates the values against the typed representation
+// of the Istio helm values, IstioHelmValues.  Unknown keys and values of the
+// wrong type are rejected.  Unknown keys that are similar to a known key
+// include a suggestion for the known key.  Values referenced by the
+// deprecation rules of the supported versions are left to those rules, which
+// are applied when the version of a control plane changes.
+func validateIstioValues(values maistrav1.HelmValuesType) error {
+	if values == nil {
+		return nil
+	}
+	paths, err := deprecatedValuePaths()
+	if err != nil {
+		return err
+	}
+	values = values.DeepCopy()
+	for _, path := range paths {
+		removeValue(values, strings.Split(path, "."))
+	}
+	return utilerrors.NewAggregate(validateValue("", map[string]interface{}(values), reflect.TypeOf(maistrav1.IstioHelmValues{})))
+}
+
+// deprecatedValuePaths returns the paths of the values referenced by the
+// deprecation rules of the supported versions
+func deprecatedValuePaths() ([]string, error) {
+	descriptors, err := common.GetVersionDescriptors()
+	if err != nil {
+		return nil, err
+	}
+	paths := sets.NewString()
+	for _, descriptor := range descriptors {
+		for _, rule := range descriptor.Deprecations {
+			paths.Insert(rule.Path)
+			for path := range rule.When {
+				paths.Insert(path)
+			}
+		}
+	}
+	return paths.List(), nil
+}
+
+// removeValue removes the value at the path, along with the objects that are
+// left empty
+func removeValue(values map[string]interface{}, path []string) {
+	if len(path) == 0 {
+		return
+	}
+	if len(path) == 1 {
+		delete(values, path[0])
+		return
+	}
+	child, ok := values[path[0]].(map[string]interface{})
+	if !ok {
+		return
+	}
+	removeValue(child, path[1:])
+	if len(child) == 0 {
+		delete(values, path[0])
+	}
+}
+
+func validateValue(path string, value interface{}, valueType reflect.Type) []error {
+	for valueType.Kind() == reflect.Ptr {
+		valueType = valueType.Elem()
+	}
+	if value == nil {
+		return nil
+	}
+	if valueAsMap, ok := value.(map[string]interface{}); ok && len(valueAsMap) == 1 && valueAsMap[patchDirectiveKey] != nil {
+		// a template merge directive, e.g. {"$patch": "delete"}
+		return nil
+	}
+
+	if valueType == gatewaysConfigType {
+		valueAsMap, ok := value.(map[string]interface{})
+		if !ok {
+			return []error{typeMismatchError(path, "object", value)}
+		}
+		return validateGateways(path, valueAsMap)
+	}
+	if reflect.PtrTo(valueType).Implements(jsonUnmarshalerType) {
+		// types like resource.Quantity parse their own representation
+		return validateByDecoding(path, value, valueType)
+	}
+
+	switch valueType.Kind() {
+	case reflect.Struct:
+		valueAsMap, ok := value.(map[string]interface{})
+		if !ok {
+			return []error{typeMismatchError(path, "object", value)}
+		}
+		return validateStruct(path, valueAsMap, valueType)
+	case reflect.Map:
+		valueAsMap, ok := value.(map[string]interface{})
+		if !ok {
+			return []error{typeMismatchError(path, "object", value)}
+		}
+		var allErrors []error
+		for _, key := range sortedValueKeys(valueAsMap) {
+			if key == patchDirectiveKey {
+				continue
+			}
+			allErrors = append(allErrors, validateValue(joinValuePath(path, key), valueAsMap[key], valueType.Elem())...)
+		}
+		return allErrors
+	case reflect.Slice, reflect.Array:
+		valueAsList, ok := value.([]interface{})
+		if !ok {
+			return []error{typeMismatchError(path, "list", value)}
+		}
+		var allErrors []error
+		for index, item := range valueAsList {
+			allErrors = append(allErrors, validateValue(fmt.Sprintf("%s[%d]", path, index), item, valueType.Elem())...)
+		}
+		return allErrors
+	case reflect.Interface:
+		return nil
+	case reflect.String:
+		if _, ok := value.(string); ok {
+			return nil
+		}
+		return []error{typeMismatchError(path, "string", value)}
+	}
+	return validateByDecoding(path, value, valueType)
+}
+
+// validateField validates the value of the field with the given json name.
+// Some string values may be specified as integers, matching the
+// x-kubernetes-int-or-string schema the CRD uses for them.
+func validateField(path string, name string, value interface{}, fieldType reflect.Type) []error {
+	if !openapi.IsIntOrStringValue(name) {
+		return validateValue(path, value, fieldType)
+	}
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	switch {
+	case fieldType.Kind() == reflect.String:
+		return validateIntOrString(path, value)
+	case fieldType.Kind() == reflect.Map && fieldType.Elem().Kind() == reflect.String:
+		valueAsMap, ok := value.(map[string]interface{})
+		if !ok {
+			return validateValue(path, value, fieldType)
+		}
+		var allErrors []error
+		for _, key := range sortedValueKeys(valueAsMap) {
+			if key == patchDirectiveKey {
+				continue
+			}
+			allErrors = append(allErrors, validateIntOrString(joinValuePath(path, key), valueAsMap[key])...)
+		}
+		return allErrors
+	}
+	return validateValue(path, value, fieldType)
+}
+
+// validateIntOrString accepts strings and integers, which helm renders as
+// strings
+func validateIntOrString(path string, value interface{}) []error {
+	switch typedValue := value.(type) {
+	case nil, string, int, int32, int64:
+		return nil
+	case float64:
+		if typedValue == math.Trunc(typedValue) {
+			return nil
+		}
+	}
+	return []error{typeMismatchError(path, "string", value)}
+}
+
+// validateGateways validates the gateways values.  GatewaysConfig inlines the
+// individual gateways, keyed by their names, alongside the common component
+// fields.
+func validateGateways(path string, values map[string]interface{}) []error {
+	commonFields := jsonFields(reflect.TypeOf(maistrav1.CommonComponentConfig{}))
+	gatewayType := reflect.TypeOf(maistrav1.GatewayConfig{})
+	var allErrors []error
+	for _, key := range sortedValueKeys(values) {
+		if key == patchDirectiveKey {
+			continue
+		}
+		keyPath := joinValuePath(path, key)
+		if fieldType, ok := commonFields[key]; ok {
+			allErrors = append(allErrors, validateField(keyPath, key, values[key], fieldType)...)
+			continue
+		}
+		allErrors = append(allErrors, validateValue(keyPath, values[key], gatewayType)...)
+	}
+	return allErrors
+}
+
+func validateStruct(path string, values map[string]interface{}, structType reflect.Type) []error {
+	fields := jsonFields(structType)
+	var allErrors []error
+	for _, key := range sortedValueKeys(values) {
+		if key == patchDirectiveKey {
+			continue
+		}
+		keyPath := joinValuePath(path, key)
+		fieldType, ok := fields[key]
+		if !ok {
+			if suggestion := suggestKey(key, fields); len(suggestion) > 0 {
+				allErrors = append(allErrors, fmt.Errorf("%s: unknown field, did you mean %q?", keyPath, suggestion))
+			} else {
+				allErrors = append(allErrors, fmt.Errorf("%s: unknown field", keyPath))
+			}
+			continue
+		}
+		allErrors = append(allErrors, validateField(keyPath, key, values[key], fieldType)...)
+	}
+	return allErrors
+}
+
+// validateByDecoding validates the value by decoding it into the type
+func validateByDecoding(path string, value interface{}, valueType reflect.Type) []error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return []error{fmt.Errorf("%s: %s", path, err)}
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(reflect.New(valueType).Interface()); err != nil {
+		if typeErr, ok := err.(*json.UnmarshalTypeError); ok {
+			return []error{typeMismatchError(path, typeErr.Type.String(), value)}
+		}
+		return []error{fmt.Errorf("%s: %s", path, err)}
+	}
+	return nil
+}
+
+// jsonFields returns the types of the fields of the struct, keyed by their
+// json names.  The fields of inlined structs are included.
+func jsonFields(structType reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for index := 0; index < structType.NumField(); index++ {
+		field := structType.Field(index)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if field.Anonymous && name == "" {
+			embeddedType := field.Type
+			if embeddedType.Kind() == reflect.Ptr {
+				embeddedType = embeddedType.Elem()
+			}
+			if embeddedType.Kind() == reflect.Struct {
+				for embeddedName, embeddedField := range jsonFields(embeddedType) {
+					fields[embeddedName] = embeddedField
+				}
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			// unexported
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// suggestKey returns the known key most similar to key, or an empty string if
+// none of the known keys is similar enough
+func suggestKey(key string, fields map[string]reflect.Type) string {
+	suggestion := ""
+	bestDistance := len(key)/3 + 1
+	if bestDistance > 3 {
+		bestDistance = 3
+	}
+	bestDistance++
+	for _, known := range sortedFieldNames(fields) {
+		if distance := editDistance(strings.ToLower(key), strings.ToLower(known)); distance < bestDistance {
+			suggestion = known
+			bestDistance = distance
+		}
+	}
+	return suggestion
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(min(previous[j]+1, current[j-1]+1), previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func typeMismatchError(path string, expected string, value interface{}) error {
+	return fmt.Errorf("%s: expected %s, got %s", path, expected, valueTypeName(value))
+}
+
+func valueTypeName(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "list"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64, float32, int, int32, int64:
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func joinValuePath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func sortedValueKeys(values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedFieldNames(fields map[string]reflect.Type) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
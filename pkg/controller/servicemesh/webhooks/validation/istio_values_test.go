@@ -0,0 +1,155 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+
+	maistrav1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+)
+
+func TestIstioValuesValidation(t *testing.T) {
+	cases := []struct {
+		name          string
+		values        maistrav1.HelmValuesType
+		expectedError string
+	}{
+		{
+			name: "valid",
+			values: maistrav1.HelmValuesType{
+				"global": map[string]interface{}{
+					"proxy": map[string]interface{}{
+						"resources": map[string]interface{}{
+							"requests": map[string]interface{}{
+								"cpu": "100m",
+							},
+						},
+					},
+				},
+				"pilot": map[string]interface{}{
+					"autoscaleEnabled": false,
+					"replicaCount":     2,
+				},
+			},
+		},
+		{
+			name: "unknown-field-with-suggestion",
+			values: maistrav1.HelmValuesType{
+				"pilot": map[string]interface{}{
+					"autoscaleEnable": false,
+				},
+			},
+			expectedError: `pilot.autoscaleEnable: unknown field, did you mean "autoscaleEnabled"?`,
+		},
+		{
+			name: "unknown-field",
+			values: maistrav1.HelmValuesType{
+				"pilot": map[string]interface{}{
+					"somethingElse": false,
+				},
+			},
+			expectedError: "pilot.somethingElse: unknown field",
+		},
+		{
+			name: "type-mismatch",
+			values: maistrav1.HelmValuesType{
+				"pilot": map[string]interface{}{
+					"replicaCount": "two",
+				},
+			},
+			expectedError: "pilot.replicaCount: expected int32, got string",
+		},
+		{
+			name: "object-expected",
+			values: maistrav1.HelmValuesType{
+				"global": map[string]interface{}{
+					"proxy": true,
+				},
+			},
+			expectedError: "global.proxy: expected object, got boolean",
+		},
+		{
+			name: "number-for-string",
+			values: maistrav1.HelmValuesType{
+				"tracing": map[string]interface{}{
+					"zipkin": map[string]interface{}{
+						"maxSpans": 500000,
+					},
+				},
+			},
+		},
+		{
+			name: "fraction-for-string",
+			values: maistrav1.HelmValuesType{
+				"tracing": map[string]interface{}{
+					"zipkin": map[string]interface{}{
+						"maxSpans": 0.5,
+					},
+				},
+			},
+			expectedError: "tracing.zipkin.maxSpans: expected string, got number",
+		},
+		{
+			name: "number-for-env",
+			values: maistrav1.HelmValuesType{
+				"pilot": map[string]interface{}{
+					"env": map[string]interface{}{
+						"PILOT_PUSH_THROTTLE": 100,
+					},
+				},
+			},
+		},
+		{
+			name: "number-for-typed-string",
+			values: maistrav1.HelmValuesType{
+				"global": map[string]interface{}{
+					"proxy": map[string]interface{}{
+						"logLevel": 1,
+					},
+				},
+			},
+			expectedError: "global.proxy.logLevel: expected string, got number",
+		},
+		{
+			name: "patch-directive",
+			values: maistrav1.HelmValuesType{
+				"grafana": map[string]interface{}{
+					"$patch": "delete",
+				},
+				"pilot": map[string]interface{}{
+					"$patch":  "replace",
+					"enabled": true,
+				},
+			},
+		},
+		{
+			name: "gateway",
+			values: maistrav1.HelmValuesType{
+				"gateways": map[string]interface{}{
+					"enabled": true,
+					"my-gateway": map[string]interface{}{
+						"enabled":    true,
+						"replicaCnt": 1,
+					},
+				},
+			},
+			expectedError: `gateways.my-gateway.replicaCnt: unknown field, did you mean "replicaCount"?`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateIstioValues(tc.values)
+			if tc.expectedError == "" {
+				if err != nil {
+					t.Errorf("unexpected error validating values: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error %q, but values were accepted", tc.expectedError)
+			}
+			if !strings.Contains(err.Error(), tc.expectedError) {
+				t.Errorf("expected error %q, got %q", tc.expectedError, err.Error())
+			}
+		})
+	}
+}
@@ -11,7 +11,10 @@ import (
 	maistrav1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
 )
 
-func (v *ControlPlaneValidator) validateV1_1(ctx context.Context, smcp *maistrav1.ServiceMeshControlPlane) error {
+// validateZipkinTracer verifies that a zipkin address configured for the
+// tracer points to a service in the namespace of the control plane, and that
+// the tracing addons are configured for it
+func (v *ControlPlaneValidator) validateZipkinTracer(ctx context.Context, smcp *maistrav1.ServiceMeshControlPlane) error {
 	var allErrors []error
 
 	if zipkinAddress, ok, _ := unstructured.NestedString(smcp.Spec.Istio, strings.Split("global.tracer.zipkin.address", ".")...); ok && len(zipkinAddress) > 0 {
@@ -0,0 +1,144 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/maistra/istio-operator/pkg/apis/maistra"
+	maistrav1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+	"github.com/maistra/istio-operator/pkg/controller/common"
+)
+
+// CheckVersionChange runs the checks performed when spec.version of the
+// control plane is updated to targetVersion and returns every object that
+// blocks the update.  Unlike the validating webhook, it does not reject
+// anything, so it can be used to report issues ahead of an update.  Like the
+// webhook, it does not check changes between patch releases of a version.
+func CheckVersionChange(ctx context.Context, cl client.Client, smcp *maistrav1.ServiceMeshControlPlane, targetVersion string) ([]maistrav1.UpgradeCheckIssue, error) {
+	currentVersion, err := parseOldControlPlaneVersion(smcp.Spec.Version)
+	if err != nil {
+		return nil, err
+	}
+	newVersion, err := parseControlPlaneVersion(targetVersion)
+	if err != nil {
+		return nil, err
+	}
+	if currentVersion == newVersion {
+		return nil, nil
+	}
+	v := &ControlPlaneValidator{client: cl}
+	return v.checkVersionChange(ctx, smcp, currentVersion, newVersion)
+}
+
+// checkVersionChange runs the upgrade or downgrade checks for the control
+// plane and returns the issues found for all of the versions in between.
+//
+// The logic used here is that we only verify upgrade/downgrade between adjacent versions
+// If an upgrade/downgrade spans multiple versions, the validation for upgrade/downgrade
+// between adjacent versions is chained together, e.g. 1.0 -> 1.3, we'd verify
+// upgrade from 1.0 -> 1.1, then 1.1 -> 1.2, then 1.2 -> 1.3.  If all of those
+// were successful, validation succeeds.  This approach may breakdown if a feature
+// was removed and subsequently reintroduced (e.g. validation from 1.0 -> 1.1
+// fails because feature X is no longer supported, but was added back in 1.3).
+// Finally, the settings deprecated by the new version are checked.
+func (v *ControlPlaneValidator) checkVersionChange(ctx context.Context, smcp *maistrav1.ServiceMeshControlPlane, oldVersion, newVersion maistra.Version) ([]maistrav1.UpgradeCheckIssue, error) {
+	descriptor, ok := common.GetVersionDescriptor(newVersion.String())
+	if !ok {
+		return nil, fmt.Errorf("version %s is not supported", newVersion.String())
+	}
+	if oldVersion.Compare(newVersion) < 0 && !descriptor.CanUpgradeFrom(oldVersion.String()) {
+		return nil, fmt.Errorf("upgrade from version %s is not supported", oldVersion.String())
+	}
+
+	var issues []maistrav1.UpgradeCheckIssue
+	for _, version := range maistra.GetSupportedVersions() {
+		var versionIssues []maistrav1.UpgradeCheckIssue
+		var err error
+		if version.Compare(oldVersion) >= 0 && version.Compare(newVersion) < 0 {
+			versionIssues, err = v.validateUpgrade(ctx, version, smcp)
+		} else if version.Compare(oldVersion) <= 0 && version.Compare(newVersion) > 0 {
+			versionIssues, err = v.validateDowngrade(ctx, version, smcp)
+		}
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, versionIssues...)
+	}
+
+	for _, rule := range descriptor.Deprecations {
+		if rule.Matches(smcp.Spec.Istio) {
+			issues = append(issues, newUpgradeCheckIssue(smcp, "ServiceMeshControlPlane", rule.Message(newVersion.String()),
+				fmt.Sprintf("remove %s from spec.istio of the ServiceMeshControlPlane", rule.Path)))
+		}
+	}
+	return issues, nil
+}
+
+// parseControlPlaneVersion resolves spec.version of a control plane, which
+// defaults to the legacy version, and returns the version of the release it
+// resolves to, e.g. v1.1 for ~1.1
+func parseControlPlaneVersion(str string) (maistra.Version, error) {
+	release, err := common.ResolveVersion(str)
+	if err != nil {
+		return nil, err
+	}
+	return maistra.ParseVersion(release)
+}
+
+// minorVersionRegexp matches the major and minor version within a version,
+// patch release or range, e.g. 1.1 in v1.1.3 or ~1.1
+var minorVersionRegexp = regexp.MustCompile(`[0-9]+\.[0-9]+`)
+
+// parseOldControlPlaneVersion returns the version of the release the existing
+// spec.version of a control plane resolves to.  The control plane may have
+// been created by another build of the operator, so spec.version may no
+// longer resolve, e.g. if it specifies a patch release that is not shipped
+// with this build.  The minor version it refers to is used in that case.
+func parseOldControlPlaneVersion(str string) (maistra.Version, error) {
+	version, err := parseControlPlaneVersion(str)
+	if err == nil {
+		return version, nil
+	}
+	if minorVersion := minorVersionRegexp.FindString(str); minorVersion != "" {
+		if version, minorErr := maistra.ParseVersion("v" + minorVersion); minorErr == nil {
+			return version, nil
+		}
+	}
+	return nil, err
+}
+
+func newUpgradeCheckIssue(obj metav1.Object, kind, message, remediation string) maistrav1.UpgradeCheckIssue {
+	return maistrav1.UpgradeCheckIssue{
+		Kind:        kind,
+		Namespace:   obj.GetNamespace(),
+		Name:        obj.GetName(),
+		Message:     message,
+		Remediation: remediation,
+	}
+}
+
+// issuesError returns an error listing the messages of the issues, or nil if
+// there are no issues
+func issuesError(issues []maistrav1.UpgradeCheckIssue) error {
+	var allErrors []error
+	for _, issue := range issues {
+		allErrors = append(allErrors, fmt.Errorf("%s", issue.Message))
+	}
+	return utilerrors.NewAggregate(allErrors)
+}
+
+// kindOf returns the kind of the object, falling back to the name of its type
+// for objects that have been decoded without type information
+func kindOf(obj runtime.Object) string {
+	if kind := obj.GetObjectKind().GroupVersionKind().Kind; kind != "" {
+		return kind
+	}
+	return reflect.Indirect(reflect.ValueOf(obj)).Type().Name()
+}
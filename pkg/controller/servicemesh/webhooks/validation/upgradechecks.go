@@ -20,9 +20,9 @@ import (
 )
 
 var (
-
-	// These are unsupported in v1.1
-	unsupportedOldResourcesV1_1 = []runtime.Object{
+	// mixerResources are the Mixer resources reported by the mixerResources
+	// check
+	mixerResources = []runtime.Object{
 		&configv1alpha2.HTTPAPISpecBindingList{},
 		&configv1alpha2.HTTPAPISpecList{},
 		&configv1alpha2.QuotaSpecBindingList{},
@@ -61,55 +61,86 @@ var (
 	}
 )
 
-func (v *ControlPlaneValidator) validateUpgradeFromV1_0(ctx context.Context, smcp *maistrav1.ServiceMeshControlPlane) error {
-	var allErrors []error
-
+// meshNamespacesOfMemberRoll returns the namespace of the control plane and
+// the namespaces configured as members by its ServiceMeshMemberRoll
+func (v *ControlPlaneValidator) meshNamespacesOfMemberRoll(smcp *maistrav1.ServiceMeshControlPlane) (sets.String, error) {
 	meshNamespaces := sets.NewString(smcp.GetNamespace())
 	smmr, err := v.getSMMR(smcp)
 	if err != nil {
 		if !errors.IsNotFound(err) {
-			return pkgerrors.Wrap(err, "error retrieving ServiceMeshMemberRoll for mesh")
+			return nil, pkgerrors.Wrap(err, "error retrieving ServiceMeshMemberRoll for mesh")
 		}
 	}
 	meshNamespaces.Insert(smmr.Status.ConfiguredMembers...)
+	return meshNamespaces, nil
+}
 
-	// return error if any deprecated mixer resources are being used
-	for _, list := range unsupportedOldResourcesV1_1 {
+// checkMixerResources reports the Mixer adapter, template and API spec
+// resources in the mesh, which are not supported by newer versions
+func (v *ControlPlaneValidator) checkMixerResources(ctx context.Context, smcp *maistrav1.ServiceMeshControlPlane) ([]maistrav1.UpgradeCheckIssue, error) {
+	var issues []maistrav1.UpgradeCheckIssue
+	var allErrors []error
+
+	meshNamespaces, err := v.meshNamespacesOfMemberRoll(smcp)
+	if err != nil {
+		return nil, err
+	}
+
+	// report any deprecated mixer resources that are being used
+	for _, list := range mixerResources {
 		list = list.DeepCopyObject()
 		// XXX: do we list all in the cluster, or list for each member namespace?
 		if err := v.client.List(ctx, nil, list); err != nil {
 			if !meta.IsNoMatchError(err) && !errors.IsNotFound(err) {
-				return pkgerrors.Wrapf(err, "error listing %T resources", list)
+				return nil, pkgerrors.Wrapf(err, "error listing %T resources", list)
 			}
 		}
 		meta.EachListItem(list, func(obj runtime.Object) error {
 			metaObj, err := meta.Accessor(obj)
 			if err != nil {
 				allErrors = append(allErrors, pkgerrors.Wrapf(err, "error accessing object metadata for %s resource", list.GetObjectKind().GroupVersionKind().String()))
+				return nil
 			}
 			// we only care about resources in this mesh, which aren't being managed by the operator directly
 			if meshNamespaces.Has(metaObj.GetNamespace()) && !metav1.IsControlledBy(metaObj, smcp) {
-				allErrors = append(allErrors, fmt.Errorf("%s/%s of type %s is not supported in newer version", metaObj.GetNamespace(), metaObj.GetName(), list.GetObjectKind().GroupVersionKind().String()))
+				issues = append(issues, newUpgradeCheckIssue(metaObj, kindOf(obj),
+					fmt.Sprintf("%s/%s of type %s is not supported in newer version", metaObj.GetNamespace(), metaObj.GetName(), list.GetObjectKind().GroupVersionKind().String()),
+					"delete the resource; Mixer adapters, templates and API specs configured through this type are no longer supported"))
 			}
 			return nil
 		})
 	}
 
+	return issues, utilerrors.NewAggregate(allErrors)
+}
+
+// checkHTTPPort443 reports the services in the mesh that serve http or http2
+// on port 443, which newer versions do not allow
+func (v *ControlPlaneValidator) checkHTTPPort443(ctx context.Context, smcp *maistrav1.ServiceMeshControlPlane) ([]maistrav1.UpgradeCheckIssue, error) {
+	var issues []maistrav1.UpgradeCheckIssue
+
+	meshNamespaces, err := v.meshNamespacesOfMemberRoll(smcp)
+	if err != nil {
+		return nil, err
+	}
+
 	// Any service ports using 443 are using http/http2 in their name (http not allowed on port 443)
 	for namespace := range meshNamespaces {
 		memberServices := &corev1.ServiceList{}
 		// listing for each member namespace, as we expect a large number of services in the whole cluster
 		if err := v.client.List(ctx, client.InNamespace(namespace), memberServices); err != nil {
-			return pkgerrors.Wrapf(err, "error listing Service resources in namespace %s", namespace)
+			return nil, pkgerrors.Wrapf(err, "error listing Service resources in namespace %s", namespace)
 		}
-		for _, service := range memberServices.Items {
+		for index, service := range memberServices.Items {
 			for _, port := range service.Spec.Ports {
 				if port.Port == 443 && (port.Name == "http" || port.Name == "http2" || strings.HasPrefix(port.Name, "http-") || strings.HasPrefix(port.Name, "http2-")) {
-					allErrors = append(allErrors, fmt.Errorf("Port 443 is not allowed for http/http2 protocols on Service %s/%s", service.Namespace, service.Name))
+					issues = append(issues, newUpgradeCheckIssue(&memberServices.Items[index], "Service",
+						fmt.Sprintf("Port 443 is not allowed for http/http2 protocols on Service %s/%s", service.Namespace, service.Name),
+						fmt.Sprintf("rename port %s, e.g. to https, or serve http traffic on a port other than 443", port.Name)))
 				}
 			}
 		}
 	}
 
-	return utilerrors.NewAggregate(allErrors)
+	return issues, nil
 }
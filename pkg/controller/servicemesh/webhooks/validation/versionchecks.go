@@ -0,0 +1,116 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/maistra/istio-operator/pkg/apis/maistra"
+	maistrav1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
+	"github.com/maistra/istio-operator/pkg/controller/common"
+)
+
+// controlPlaneValidation validates a control plane when it is created or
+// updated
+type controlPlaneValidation func(v *ControlPlaneValidator, ctx context.Context, smcp *maistrav1.ServiceMeshControlPlane) error
+
+// versionChangeCheck returns the objects that block a change of the version of
+// a control plane
+type versionChangeCheck func(v *ControlPlaneValidator, ctx context.Context, smcp *maistrav1.ServiceMeshControlPlane) ([]maistrav1.UpgradeCheckIssue, error)
+
+// controlPlaneValidations are the checks the version descriptors may list in
+// validations, keyed by name
+var controlPlaneValidations = map[string]controlPlaneValidation{
+	"zipkinTracer": (*ControlPlaneValidator).validateZipkinTracer,
+}
+
+// versionChangeChecks are the checks the version descriptors may list in
+// upgradeChecks and downgradeChecks, keyed by name
+var versionChangeChecks = map[string]versionChangeCheck{
+	"mixerResources":        (*ControlPlaneValidator).checkMixerResources,
+	"httpPort443":           (*ControlPlaneValidator).checkHTTPPort443,
+	"caNamespaceLabels":     (*ControlPlaneValidator).checkCANamespaceLabels,
+	"mirrorPercent":         (*ControlPlaneValidator).checkMirrorPercent,
+	"authorizationPolicies": (*ControlPlaneValidator).checkAuthorizationPolicies,
+}
+
+// ValidateVersionDescriptors verifies that the checks listed by the version
+// descriptors are implemented by the webhook.  It must be called after the
+// descriptors have been loaded, so unknown checks are reported at startup.
+func ValidateVersionDescriptors() error {
+	descriptors, err := common.GetVersionDescriptors()
+	if err != nil {
+		return err
+	}
+	var allErrors []error
+	for _, descriptor := range descriptors {
+		for _, name := range descriptor.Validations {
+			if _, ok := controlPlaneValidations[name]; !ok {
+				allErrors = append(allErrors, fmt.Errorf("version %s: unknown check %s in validations", descriptor.Version, name))
+			}
+		}
+		for _, name := range append(append([]string{}, descriptor.UpgradeChecks...), descriptor.DowngradeChecks...) {
+			if _, ok := versionChangeChecks[name]; !ok {
+				allErrors = append(allErrors, fmt.Errorf("version %s: unknown check %s in upgradeChecks or downgradeChecks", descriptor.Version, name))
+			}
+		}
+	}
+	return utilerrors.NewAggregate(allErrors)
+}
+
+// validateVersion runs the validations listed by the descriptor of the
+// control plane's version
+func (v *ControlPlaneValidator) validateVersion(ctx context.Context, smcp *maistrav1.ServiceMeshControlPlane) error {
+	descriptor, ok := common.GetVersionDescriptor(smcp.Spec.Version)
+	if !ok {
+		return fmt.Errorf("version %s is not supported", smcp.Spec.Version)
+	}
+	var allErrors []error
+	for _, name := range descriptor.Validations {
+		validate, ok := controlPlaneValidations[name]
+		if !ok {
+			return fmt.Errorf("version %s: unknown check %s", descriptor.Version, name)
+		}
+		if err := validate(v, ctx, smcp); err != nil {
+			allErrors = append(allErrors, err)
+		}
+	}
+	return utilerrors.NewAggregate(allErrors)
+}
+
+// validateUpgrade runs the upgrade checks listed by the descriptor of the
+// version the control plane is upgraded from
+func (v *ControlPlaneValidator) validateUpgrade(ctx context.Context, currentVersion maistra.Version, smcp *maistrav1.ServiceMeshControlPlane) ([]maistrav1.UpgradeCheckIssue, error) {
+	descriptor, ok := common.GetVersionDescriptor(currentVersion.String())
+	if !ok {
+		return nil, fmt.Errorf("version %s is not supported", currentVersion.String())
+	}
+	return v.runVersionChangeChecks(ctx, descriptor, descriptor.UpgradeChecks, smcp)
+}
+
+// validateDowngrade runs the downgrade checks listed by the descriptor of the
+// version the control plane is downgraded from
+func (v *ControlPlaneValidator) validateDowngrade(ctx context.Context, currentVersion maistra.Version, smcp *maistrav1.ServiceMeshControlPlane) ([]maistrav1.UpgradeCheckIssue, error) {
+	descriptor, ok := common.GetVersionDescriptor(currentVersion.String())
+	if !ok {
+		return nil, fmt.Errorf("version %s is not supported", currentVersion.String())
+	}
+	return v.runVersionChangeChecks(ctx, descriptor, descriptor.DowngradeChecks, smcp)
+}
+
+func (v *ControlPlaneValidator) runVersionChangeChecks(ctx context.Context, descriptor *common.VersionDescriptor, names []string, smcp *maistrav1.ServiceMeshControlPlane) ([]maistrav1.UpgradeCheckIssue, error) {
+	var issues []maistrav1.UpgradeCheckIssue
+	for _, name := range names {
+		check, ok := versionChangeChecks[name]
+		if !ok {
+			return nil, fmt.Errorf("version %s: unknown check %s", descriptor.Version, name)
+		}
+		checkIssues, err := check(v, ctx, smcp)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, checkIssues...)
+	}
+	return issues, nil
+}